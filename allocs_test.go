@@ -0,0 +1,131 @@
+package main
+
+import "testing"
+
+// allocBudget is the maximum number of allocations an operation on a hot path
+// may make. Going over budget is a performance regression: most of these
+// run hundreds of times per recovered byte.
+type allocBudget struct {
+	name string
+
+	// allocs is the budget, in allocations per call of the operation.
+	allocs float64
+
+	// setup returns the operation to measure. It runs once, outside of the
+	// measurement.
+	setup func(tb testing.TB) func()
+}
+
+// _allocBudgets lists the hot paths and their budgets.
+// The budgets are the current allocation counts: if a change lowers one,
+// lower its budget too, so that it can't silently come back.
+var _allocBudgets = []allocBudget{
+	{
+		name:   "xorBlocks",
+		allocs: 1,
+		setup: func(testing.TB) func() {
+			a, b := make([]byte, 16), make([]byte, 16)
+			return func() { xorBlocks(a, b) }
+		},
+	},
+	{
+		// the educational implementation allocates every block it
+		// encrypts.
+		name:   "encryptAesEcb/educational/1KiB",
+		allocs: 74,
+		setup: func(testing.TB) func() {
+			var (
+				plainText = make([]byte, 1024)
+				key       = []byte("YELLOW SUBMARINE")
+			)
+			return func() { encryptAesEcb(plainText, key) }
+		},
+	},
+	{
+		name:   "encryptAesEcb/stdlib/1KiB",
+		allocs: 4,
+		setup: func(testing.TB) func() {
+			var (
+				plainText = make([]byte, 1024)
+				key       = []byte("YELLOW SUBMARINE")
+			)
+			return func() { encryptAesEcb(plainText, key, withStdlib()) }
+		},
+	},
+	{
+		name:   "ecbEncryptionOracle",
+		allocs: 5,
+		setup: func(tb testing.TB) func() {
+			oracle, err := ecbEncryptionOracle(staticSecret("Rollin' in my 5.0"))
+			if err != nil {
+				tb.Fatalf("unexpected error: %s", err)
+			}
+			plainText := make([]byte, 15)
+			return func() { oracle(plainText) }
+		},
+	},
+	{
+		name:   "xorWithChar",
+		allocs: 1,
+		setup: func(testing.TB) func() {
+			data := make([]byte, 64)
+			return func() { xorWithChar(data, 'X') }
+		},
+	},
+	{
+		name:   "computeTextScore",
+		allocs: 0,
+		setup: func(testing.TB) func() {
+			text := []byte("Cooking MC's like a pound of bacon")
+			return func() { computeTextScore(text) }
+		},
+	},
+	{
+		name:   "englishScore",
+		allocs: 0,
+		setup: func(testing.TB) func() {
+			text := []byte("Cooking MC's like a pound of bacon")
+			return func() { englishScore(text) }
+		},
+	},
+	{
+		name:   "binaryScore",
+		allocs: 0,
+		setup: func(testing.TB) func() {
+			data := make([]byte, 64)
+			return func() { binaryScore(data) }
+		},
+	},
+}
+
+func TestAllocBudgets(t *testing.T) {
+	for _, budget := range _allocBudgets {
+		t.Run(budget.name, func(t *testing.T) {
+			fn := budget.setup(t)
+			if got := testing.AllocsPerRun(100, fn); got > budget.allocs {
+				t.Errorf("%v allocations per call, budget is %v", got, budget.allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkAllocBudgets(b *testing.B) {
+	for _, budget := range _allocBudgets {
+		b.Run(budget.name, func(b *testing.B) {
+			fn := budget.setup(b)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				fn()
+			}
+			b.StopTimer()
+
+			// b.ReportAllocs averages over the whole run, including any other
+			// goroutine, so we measure the budget on its own.
+			if got := testing.AllocsPerRun(100, fn); got > budget.allocs {
+				b.Errorf("%v allocations per call, budget is %v", got, budget.allocs)
+			}
+		})
+	}
+}
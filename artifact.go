@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// _artifactMagic starts every artifact file, and identifies the version of
+// its format.
+var _artifactMagic = []byte("CPA1")
+
+// _maxArtifactLen is the maximum length of an artifact's name or data we
+// accept when reading, so that a corrupted length doesn't make us allocate
+// gigabytes.
+const _maxArtifactLen = 1 << 26
+
+// errMalformedArtifact is returned when reading an artifact file that doesn't
+// follow the format written by writeArtifacts.
+var errMalformedArtifact = errors.New("malformed artifact file")
+
+// artifactKind identifies what an artifact holds.
+type artifactKind uint8
+
+const (
+	// artifactKey is a recovered (or generated) key.
+	artifactKey artifactKind = iota + 1
+
+	// artifactSecret is a recovered plain text or secret.
+	artifactSecret
+
+	// artifactKeystream is a recovered keystream.
+	artifactKeystream
+
+	// artifactState is the opaque state of an attack or of a generator,
+	// e.g., to resume a long attack.
+	artifactState
+)
+
+func (k artifactKind) String() string {
+	switch k {
+	case artifactKey:
+		return "key"
+	case artifactSecret:
+		return "secret"
+	case artifactKeystream:
+		return "keystream"
+	case artifactState:
+		return "state"
+	default:
+		return fmt.Sprintf("artifactKind(%d)", k)
+	}
+}
+
+// artifact is a named piece of data produced by an attack that is worth
+// persisting.
+type artifact struct {
+	kind artifactKind
+	name string
+	data []byte
+}
+
+// writeArtifacts writes the artifacts to w in a tagged binary format:
+//
+//	magic ("CPA1")
+//	for each artifact: kind (1 byte) || len(name) || name || len(data) || data
+//
+// where the lengths are unsigned varints.
+func writeArtifacts(w io.Writer, artifacts ...artifact) error {
+	var buf bytes.Buffer
+	buf.Write(_artifactMagic)
+
+	for _, a := range artifacts {
+		buf.WriteByte(byte(a.kind))
+		buf.Write(binary.AppendUvarint(nil, uint64(len(a.name))))
+		buf.WriteString(a.name)
+		buf.Write(binary.AppendUvarint(nil, uint64(len(a.data))))
+		buf.Write(a.data)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readArtifacts reads the artifacts written by writeArtifacts from r.
+func readArtifacts(r io.Reader) ([]artifact, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(_artifactMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, _artifactMagic) {
+		return nil, fmt.Errorf("%w: missing magic number", errMalformedArtifact)
+	}
+
+	var artifacts []artifact
+	for {
+		kind, err := br.ReadByte()
+		if err == io.EOF {
+			return artifacts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := readArtifactField(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading name of artifact %d: %w", len(artifacts), err)
+		}
+		data, err := readArtifactField(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading data of artifact %d: %w", len(artifacts), err)
+		}
+
+		artifacts = append(artifacts, artifact{
+			kind: artifactKind(kind),
+			name: string(name),
+			data: data,
+		})
+	}
+}
+
+// readArtifactField reads a length prefixed field of an artifact.
+func readArtifactField(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedArtifact, err)
+	}
+	if n > _maxArtifactLen {
+		return nil, fmt.Errorf("%w: field length %d is too large", errMalformedArtifact, n)
+	}
+
+	field := make([]byte, n)
+	if _, err := io.ReadFull(br, field); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedArtifact, err)
+	}
+	return field, nil
+}
+
+// saveArtifacts writes the artifacts to the file at path, replacing it
+// atomically, so that an interrupted save doesn't lose the previous ones: they
+// are written and synced to a temporary file, which is then renamed to path.
+// The file is only readable by its owner, as artifacts hold keys and secrets.
+func saveArtifacts(path string, artifacts ...artifact) (err error) {
+	// CreateTemp creates the file with mode 0600, next to path so that the
+	// rename doesn't cross file systems.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating artifact file: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	if err := writeArtifacts(f, artifacts...); err != nil {
+		f.Close()
+		return fmt.Errorf("writing artifacts: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("syncing artifact file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing artifact file: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// loadArtifacts reads the artifacts from the file at path.
+func loadArtifacts(path string) ([]artifact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening artifact file: %w", err)
+	}
+	defer f.Close()
+
+	return readArtifacts(f)
+}
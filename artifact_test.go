@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestArtifactsRoundTrip(t *testing.T) {
+	want := []artifact{
+		{kind: artifactKey, name: "ecb-suffix", data: []byte("YELLOW SUBMARINE")},
+		{kind: artifactSecret, name: "", data: bytes.Repeat([]byte{0, 0xff}, 300)},
+		{kind: artifactState, name: "empty", data: []byte{}},
+	}
+
+	path := filepath.Join(t.TempDir(), "artifacts.bin")
+	if err := saveArtifacts(path, want...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := loadArtifacts(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\nwant:\t%v\ngot:\t%v\n", want, got)
+	}
+}
+
+func TestSaveArtifactsFile(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "artifacts.bin")
+		key  = artifact{kind: artifactKey, name: "key", data: []byte("YELLOW SUBMARINE")}
+	)
+	if err := saveArtifacts(path, key); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("want mode 0600 for a file holding keys, got %o", info.Mode().Perm())
+	}
+
+	// a directory can't be replaced by the file: the save fails after
+	// writing the temporary file, which must not be left behind.
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "sub"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := saveArtifacts(blocked, key); err == nil {
+		t.Fatal("want error saving over a directory, got nil")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == ".tmp" {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestReadArtifactsMalformed(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeArtifacts(&buf, artifact{kind: artifactKey, name: "k", data: []byte("YELLOW SUBMARINE")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	encoded := buf.Bytes()
+
+	tests := map[string][]byte{
+		"no magic":  []byte("nope"),
+		"truncated": encoded[:len(encoded)-1],
+		"too long":  append(append([]byte("CPA1"), byte(artifactKey)), 0xff, 0xff, 0xff, 0xff, 0x7f),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readArtifacts(bytes.NewReader(data))
+			if !errors.Is(err, errMalformedArtifact) {
+				t.Errorf("want %v, got %v", errMalformedArtifact, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+)
+
+var (
+	// errMaxOracleCalls is returned by the oracle of an attack that exceeded
+	// the number of queries set with withMaxOracleCalls.
+	errMaxOracleCalls = errors.New("too many oracle calls")
+
+	// errSecretTooLong is returned by the attacks that recover an oracle's
+	// secret when it's longer than the limit set with withMaxSecretLen.
+	errSecretTooLong = errors.New("secret too long")
+)
+
+// _defaultMaxSecretLen is the default of withMaxSecretLen. Recovering a byte
+// takes about 128 queries, so a longer secret is more likely to be a
+// misbehaving oracle than something we want to recover.
+const _defaultMaxSecretLen = 1 << 14
+
+// attackOptions configures the attacks.
+// Each attack documents the options it honors; the others are ignored.
+type attackOptions struct {
+	progress       progressReporter
+	explain        io.Writer
+	scorer         scorer
+	blockSize      int
+	maxOracleCalls int64
+	parallelism    int
+	maxSecretLen   int
+
+	// wordCheckMargin is the margin set with withWordCheck. The check is off
+	// when it's 0.
+	wordCheckMargin float64
+
+	// verifier is the verifier set with withVerifier, if any.
+	verifier verifier
+}
+
+// attackOption defines a type that sets an option of the attacks.
+type attackOption func(*attackOptions)
+
+// withBlockSize sets the block size of the cipher under attack, in bytes.
+// It defaults to AES's block size.
+func withBlockSize(size int) attackOption {
+	return func(o *attackOptions) {
+		o.blockSize = size
+	}
+}
+
+// withMaxOracleCalls makes the attack fail with errMaxOracleCalls if it
+// queries its oracle more than n times. By default there's no limit.
+func withMaxOracleCalls(n int64) attackOption {
+	return func(o *attackOptions) {
+		o.maxOracleCalls = n
+	}
+}
+
+// withMaxSecretLen makes the attacks that recover an oracle's secret fail with
+// errSecretTooLong, before querying the oracle any further, if the secret
+// (together with whatever else the oracle adds to our input) is longer than
+// n bytes. It defaults to _defaultMaxSecretLen; n <= 0 means no limit.
+func withMaxSecretLen(n int) attackOption {
+	return func(o *attackOptions) {
+		o.maxSecretLen = n
+	}
+}
+
+// withParallelism sets how many goroutines the attack may run concurrently.
+// It defaults to GOMAXPROCS.
+func withParallelism(n int) attackOption {
+	return func(o *attackOptions) {
+		o.parallelism = n
+	}
+}
+
+// newAttackOptions returns the attackOptions resulting from applying opts to
+// the default options.
+func newAttackOptions(opts []attackOption) attackOptions {
+	o := attackOptions{
+		progress:     func(attackEvent) {},
+		explain:      io.Discard,
+		scorer:       englishScore,
+		blockSize:    aes.BlockSize,
+		parallelism:  runtime.GOMAXPROCS(0),
+		maxSecretLen: _defaultMaxSecretLen,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// validate checks that the options are usable.
+func (o attackOptions) validate() error {
+	if o.blockSize <= 0 {
+		return fmt.Errorf("invalid block size %d", o.blockSize)
+	}
+	if o.parallelism <= 0 {
+		return fmt.Errorf("invalid parallelism %d", o.parallelism)
+	}
+	return nil
+}
+
+// checkSecretLen returns errSecretTooLong if n is over the limit set with
+// withMaxSecretLen.
+func (o attackOptions) checkSecretLen(n int) error {
+	if o.maxSecretLen > 0 && n > o.maxSecretLen {
+		return fmt.Errorf("%w: %d bytes, limit is %d", errSecretTooLong, n, o.maxSecretLen)
+	}
+	return nil
+}
+
+// limitOracle returns the given oracle wrapped so that it honors the maximum
+// number of calls set with withMaxOracleCalls, if any.
+func (o attackOptions) limitOracle(oracle aesOracle) aesOracle {
+	if o.maxOracleCalls <= 0 {
+		return oracle
+	}
+
+	var calls atomic.Int64
+	return func(plainText []byte) ([]byte, error) {
+		if calls.Add(1) > o.maxOracleCalls {
+			return nil, fmt.Errorf("%w: limit is %d", errMaxOracleCalls, o.maxOracleCalls)
+		}
+		return oracle(plainText)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithMaxOracleCalls(t *testing.T) {
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	counted, calls := countOracleCalls(o)
+
+	const maxCalls = 100
+	_, err = decryptOracleSecret(counted, withMaxOracleCalls(maxCalls))
+	if !errors.Is(err, errMaxOracleCalls) {
+		t.Fatalf("want %q error, got %v", errMaxOracleCalls, err)
+	}
+	if got := calls(); got > maxCalls {
+		t.Errorf("oracle called %d times, want at most %d", got, maxCalls)
+	}
+
+	// a generous limit doesn't get in the way.
+	if _, err := decryptOracleSecret(o, withMaxOracleCalls(1<<20)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestWithMaxOracleCallsRandomPrefix(t *testing.T) {
+	o, err := randomPrefixEcbOracle(_challenge12Secret, 32)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, stats, err := decryptRandomPrefixOracleSecret(o, withMaxOracleCalls(500))
+	if !errors.Is(err, errMaxOracleCalls) {
+		t.Fatalf("want %q error, got %v", errMaxOracleCalls, err)
+	}
+	// the call that hit the limit is counted, but not answered.
+	if stats.oracleCalls > 501 {
+		t.Errorf("oracle called %d times, want at most 500", stats.oracleCalls)
+	}
+}
+
+func TestAttackOptionsValidate(t *testing.T) {
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := decryptOracleSecret(o, withBlockSize(0)); err == nil {
+		t.Errorf("expected an error for a zero block size")
+	}
+	if _, _, err := detectSingleByteXOR(nil, withParallelism(-1)); err == nil {
+		t.Errorf("expected an error for a negative parallelism")
+	}
+}
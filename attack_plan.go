@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+const (
+	// _expectedTextGuesses is how many guesses the byte-at-a-time attacks
+	// need on average for a byte of a secret made of printable ASCII
+	// characters: they try the byte values in increasing order, and printable
+	// characters go from 32 (' ') to 126 ('~').
+	_expectedTextGuesses = (' '+'~')/2 + 1
+
+	// _maxGuesses is how many guesses the byte-at-a-time attacks need at
+	// most for a byte of the secret, and exactly for the bytes past its end,
+	// where no guess matches.
+	_maxGuesses = 256
+)
+
+// attackPlan is the estimated cost of the attack recovering an oracle's
+// secret, computed by planOracleSecretAttack without running it.
+// When marshaled to JSON, durations are in nanoseconds.
+type attackPlan struct {
+	// Attack names the attack the plan is for.
+	Attack string `json:"attack"`
+
+	// BlockSize, PrefixLen and SuffixLen are what probing the oracle found
+	// out (see oracleProfile).
+	BlockSize int `json:"blockSize"`
+	PrefixLen int `json:"prefixLen"`
+	SuffixLen int `json:"suffixLen"`
+
+	// ProbeCalls is the number of queries spent probing the oracle, and
+	// Latency is the median of how long they took.
+	ProbeCalls int64         `json:"probeCalls"`
+	Latency    time.Duration `json:"latencyNs"`
+
+	// InFlight is the number of queries the attack sends at the same time.
+	InFlight int `json:"inFlight"`
+
+	// ExpectedCalls and ExpectedDuration are the cost of the attack if the
+	// secret is made of printable ASCII characters; MaxCalls and MaxDuration
+	// are the worst case. The durations assume the oracle keeps answering
+	// with the measured latency.
+	ExpectedCalls    int64         `json:"expectedCalls"`
+	MaxCalls         int64         `json:"maxCalls"`
+	ExpectedDuration time.Duration `json:"expectedDurationNs"`
+	MaxDuration      time.Duration `json:"maxDurationNs"`
+}
+
+// planOracleSecretAttack estimates how many queries recovering the secret of
+// an ECB oracle takes, and how long, without running the attack: it probes
+// the oracle with probeOracle, measuring its latency, and computes the cost
+// of the attack that applies to it. That's decryptOracleSecret if the oracle
+// doesn't prepend anything to our input, or decryptOracleSecretPipelined if
+// the parallelism set with withParallelism is greater than 1, and
+// decryptRandomPrefixOracleSecret if it prepends a fixed prefix.
+// Oracles whose prefix changes between queries can't be probed, so it can't
+// plan attacks on them.
+// Probing takes a few dozen queries. It honors withParallelism,
+// withMaxOracleCalls and withMaxSecretLen.
+func planOracleSecretAttack(oracle aesOracle, opts ...attackOption) (attackPlan, error) {
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return attackPlan{}, err
+	}
+
+	// probeOracle queries the oracle sequentially.
+	var latencies []time.Duration
+	timed := func(plainText []byte) ([]byte, error) {
+		start := time.Now()
+		cipherText, err := oracle(plainText)
+		latencies = append(latencies, time.Since(start))
+		return cipherText, err
+	}
+	profile, err := probeOracle(timed, opts...)
+	if err != nil {
+		return attackPlan{}, fmt.Errorf("probing oracle: %w", err)
+	}
+
+	// the first queries to a remote oracle are often slower (e.g., they
+	// open a connection): the median is closer to what the attack will see
+	// than the mean.
+	slices.Sort(latencies)
+	plan := attackPlan{
+		BlockSize:  profile.blockSize,
+		PrefixLen:  profile.prefixLen,
+		SuffixLen:  profile.suffixLen,
+		ProbeCalls: int64(len(latencies)),
+		Latency:    latencies[len(latencies)/2],
+		InFlight:   1,
+	}
+	switch {
+	case profile.family == modeFamilyStream:
+		return attackPlan{}, errors.New("the oracle uses a stream cipher")
+	case !profile.ecb:
+		return attackPlan{}, errors.New("the oracle doesn't use ECB mode")
+	case profile.suffixLen == 0:
+		return attackPlan{}, errors.New("the oracle doesn't append a secret to our input")
+	}
+
+	var (
+		expectedCalls, expectedRounds int64
+		maxCalls, maxRounds           int64
+	)
+	switch {
+	case profile.prefixLen > 0:
+		plan.Attack = "byte-at-a-time with prefix alignment"
+
+		// the attack cycles the filler's length from 0 on every query,
+		// until the prefix and the filler end on a block boundary. It needs
+		// to see that happen twice to find the encrypted marker.
+		var (
+			perQuery = int64(fillLen(profile.prefixLen, profile.blockSize) + 1)
+			marker   = perQuery + int64(profile.blockSize)
+		)
+		expectedCalls, _ = byteAtATimeCost(profile.suffixLen, profile.blockSize, _expectedTextGuesses, 1)
+		maxCalls, _ = byteAtATimeCost(profile.suffixLen, profile.blockSize, _maxGuesses, 1)
+		expectedCalls = marker + expectedCalls*perQuery
+		maxCalls = marker + maxCalls*perQuery
+		expectedRounds, maxRounds = expectedCalls, maxCalls
+
+	case options.parallelism > 1:
+		plan.Attack = "byte-at-a-time (pipelined)"
+		plan.InFlight = options.parallelism
+
+		expectedCalls, expectedRounds = byteAtATimeCost(profile.suffixLen, profile.blockSize, _expectedTextGuesses, plan.InFlight)
+		maxCalls, maxRounds = byteAtATimeCost(profile.suffixLen, profile.blockSize, _maxGuesses, plan.InFlight)
+
+	default:
+		plan.Attack = "byte-at-a-time"
+
+		expectedCalls, expectedRounds = byteAtATimeCost(profile.suffixLen, profile.blockSize, _expectedTextGuesses, 1)
+		maxCalls, maxRounds = byteAtATimeCost(profile.suffixLen, profile.blockSize, _maxGuesses, 1)
+	}
+
+	plan.ExpectedCalls = expectedCalls
+	plan.MaxCalls = maxCalls
+	plan.ExpectedDuration = time.Duration(expectedRounds) * plan.Latency
+	plan.MaxDuration = time.Duration(maxRounds) * plan.Latency
+
+	return plan, nil
+}
+
+// byteAtATimeCost returns the number of queries decryptOracleSecret makes to
+// recover a secret of secretLen bytes, if each of its bytes takes guesses
+// queries to guess, and the number of rounds of queries it takes if inFlight
+// of them are sent at the same time, like decryptOracleSecretPipelined does.
+// Each round takes the latency of a query.
+func byteAtATimeCost(secretLen, blockSize, guesses, inFlight int) (int64, int64) {
+	// how many rounds n queries take, if inFlight of them are sent at once.
+	rounds := func(n int) int64 {
+		return int64((n + inFlight - 1) / inFlight)
+	}
+
+	var (
+		// the first byte of padding is always 0x01 when it's guessed, so it
+		// takes 2 guesses. The byte after it, if the padding is longer than
+		// that, can't be guessed at all, and the attacks stop there.
+		tail = min(alignDown(secretLen, blockSize)+blockSize-secretLen-1, 1)
+
+		// the attacks query the target block of every byte they try.
+		positions = secretLen + 1 + tail
+
+		// the cipher text of the secret alone, a few times over.
+		calls       = int64(3)
+		roundsTotal = int64(3)
+	)
+
+	if inFlight <= 1 {
+		// a query for the target block of every byte, then the guesses.
+		calls += int64(positions + secretLen*guesses + 2 + tail*_maxGuesses)
+		return calls, calls
+	}
+
+	// the target blocks are fetched at the start of every block, up to the
+	// one holding the last byte tried. The guesses in flight when a match
+	// comes back are wasted: we count inFlight-1 of them per byte. That's
+	// only an estimate: the workers trying the guesses drift apart when some
+	// queries are slower than others, which wastes more guesses, or fewer if
+	// the worker finding the match got ahead.
+	var (
+		blocks = (secretLen+tail)/blockSize + 1
+		wasted = min(inFlight-1, _maxGuesses-guesses)
+	)
+	calls += int64(blocks*blockSize + secretLen*(guesses+wasted) + 2 + min(inFlight-1, _maxGuesses-2) + tail*_maxGuesses)
+	roundsTotal += int64(blocks)*rounds(blockSize) + int64(secretLen)*rounds(guesses) + rounds(2) + int64(tail)*rounds(_maxGuesses)
+
+	return calls, roundsTotal
+}
+
+// writeText writes the plan in a human readable format.
+func (p *attackPlan) writeText(w io.Writer) error {
+	const formatStr = `attack: %s
+oracle: %d-byte blocks, %d-byte prefix, %d-byte suffix
+latency: %s per query (measured over %d queries)
+queries in flight: %d
+expected: %d queries, %s (printable secret)
+worst case: %d queries, %s
+`
+	_, err := fmt.Fprintf(w, formatStr,
+		p.Attack,
+		p.BlockSize, p.PrefixLen, p.SuffixLen,
+		p.Latency, p.ProbeCalls,
+		p.InFlight,
+		p.ExpectedCalls, p.ExpectedDuration.Round(time.Millisecond),
+		p.MaxCalls, p.MaxDuration.Round(time.Millisecond),
+	)
+	return err
+}
+
+// write writes the plan as JSON if asJSON is true, otherwise in a human
+// readable format.
+func (p *attackPlan) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(p)
+	}
+	return p.writeText(w)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// _planSecret is a secret whose bytes take exactly _expectedTextGuesses
+// guesses each, so that the expected cost of an attack on it is exact.
+var _planSecret = strings.Repeat(string(rune(_expectedTextGuesses-1)), 21)
+
+func TestPlanOracleSecretAttack(t *testing.T) {
+	oracle, err := ecbEncryptionOracle(staticSecret(_planSecret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	plan, err := planOracleSecretAttack(oracle, withParallelism(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if plan.Attack != "byte-at-a-time" || plan.BlockSize != 16 || plan.PrefixLen != 0 || plan.SuffixLen != len(_planSecret) {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if plan.ProbeCalls == 0 || plan.Latency <= 0 {
+		t.Errorf("latency not measured: %+v", plan)
+	}
+
+	counted, calls := countOracleCalls(oracle)
+	if _, err := decryptOracleSecret(counted); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls() != plan.ExpectedCalls {
+		t.Errorf("want %d oracle calls, got %d", plan.ExpectedCalls, calls())
+	}
+	if plan.MaxCalls <= plan.ExpectedCalls || plan.MaxDuration < plan.ExpectedDuration {
+		t.Errorf("worst case cheaper than expected: %+v", plan)
+	}
+}
+
+func TestPlanOracleSecretAttackPrefix(t *testing.T) {
+	oracle := fixedAffixOracle(t, "a fixed prefix", _planSecret, false)
+
+	plan, err := planOracleSecretAttack(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if plan.PrefixLen != len("a fixed prefix") || plan.SuffixLen != len(_planSecret) {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	secret, stats, err := decryptRandomPrefixOracleSecret(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := string(delPadPkcs7(secret)); got != _planSecret {
+		t.Fatalf("\nwant:\t%q\ngot:\t%q\n", _planSecret, got)
+	}
+	if int64(stats.oracleCalls) != plan.ExpectedCalls {
+		t.Errorf("want %d oracle calls, got %d", plan.ExpectedCalls, stats.oracleCalls)
+	}
+}
+
+func TestPlanOracleSecretAttackPipelined(t *testing.T) {
+	oracle, err := ecbEncryptionOracle(staticSecret(_planSecret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const inFlight = 8
+	plan, err := planOracleSecretAttack(oracle, withParallelism(inFlight))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if plan.InFlight != inFlight {
+		t.Errorf("want %d queries in flight, got %d", inFlight, plan.InFlight)
+	}
+
+	sequential, err := planOracleSecretAttack(oracle, withParallelism(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if plan.ExpectedDuration >= sequential.ExpectedDuration {
+		t.Errorf("pipelining doesn't save time: %s vs %s", plan.ExpectedDuration, sequential.ExpectedDuration)
+	}
+
+	// the guesses the workers send before one of them finds the match depend
+	// on scheduling, so the expected count is only an estimate. The worst
+	// case isn't.
+	counted, calls := countOracleCalls(oracle)
+	if _, err := decryptOracleSecretPipelined(counted, withParallelism(inFlight)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Logf("expected %d oracle calls, made %d", plan.ExpectedCalls, calls())
+	if calls() > plan.MaxCalls {
+		t.Errorf("want at most %d oracle calls, got %d", plan.MaxCalls, calls())
+	}
+}
+
+func TestPlanOracleSecretAttackUnsupported(t *testing.T) {
+	noSecret, err := ecbEncryptionOracle(staticSecret(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	randomPrefix, err := randomPrefixEcbOracle(staticSecret(_planSecret), 32)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		oracle aesOracle
+	}{
+		{name: "CBC", oracle: fixedAffixOracle(t, "", _planSecret, true)},
+		{name: "no secret", oracle: noSecret},
+		{name: "random prefix", oracle: randomPrefix},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := planOracleSecretAttack(test.oracle); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestAttackPlanWrite(t *testing.T) {
+	plan := attackPlan{Attack: "byte-at-a-time", BlockSize: 16, SuffixLen: 21, ExpectedCalls: 1234}
+
+	var out bytes.Buffer
+	if err := plan.write(&out, false); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{"attack: byte-at-a-time", "21-byte suffix", "expected: 1234 queries"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := plan.write(&out, true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), `"expectedCalls":1234`) {
+		t.Errorf("unexpected JSON %s", out.String())
+	}
+}
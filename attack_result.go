@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// attackResult holds the outcome of an attack.
+// When marshaled to JSON, Key and PlainText are encoded in base64.
+type attackResult struct {
+	// Attack is the name of the attack that produced this result.
+	Attack string `json:"attack"`
+
+	// Reason explains why the attack was chosen, when it was chosen
+	// automatically (see crack auto).
+	Reason string `json:"reason,omitempty"`
+
+	// Key is the recovered key, if the attack recovers one.
+	Key []byte `json:"key,omitempty"`
+
+	// Passphrase is the passphrase the key is derived from, and
+	// KeyDerivation names how (see keyDerivation), if the attack recovers
+	// one.
+	Passphrase    string `json:"passphrase,omitempty"`
+	KeyDerivation string `json:"keyDerivation,omitempty"`
+
+	// PlainText is the recovered plain text (or secret).
+	PlainText []byte `json:"plainText"`
+
+	// Score is how plausible the plain text is, if the attack ranks its
+	// candidates (e.g., with computeTextScore).
+	Score float64 `json:"score,omitempty"`
+
+	// OracleCalls is the number of queries made to the oracle, if the attack
+	// uses one.
+	OracleCalls int64 `json:"oracleCalls,omitempty"`
+
+	// Duration is how long the attack took.
+	Duration time.Duration `json:"durationNs"`
+}
+
+// writeText writes the result in a human readable format.
+func (r *attackResult) writeText(w io.Writer) error {
+	if r.Reason != "" {
+		if _, err := fmt.Fprintf(w, "attack: %s (%s)\n", r.Attack, r.Reason); err != nil {
+			return err
+		}
+	}
+	if r.Key != nil {
+		if _, err := fmt.Fprintf(w, "key: %q\n", r.Key); err != nil {
+			return err
+		}
+	}
+	if r.Passphrase != "" {
+		if _, err := fmt.Fprintf(w, "passphrase: %q (%s)\n", r.Passphrase, r.KeyDerivation); err != nil {
+			return err
+		}
+	}
+	if r.Score != 0 {
+		if _, err := fmt.Fprintf(w, "score: %.4f\n", r.Score); err != nil {
+			return err
+		}
+	}
+	if r.OracleCalls > 0 {
+		if _, err := fmt.Fprintf(w, "oracle calls: %d\n", r.OracleCalls); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "time: %s\nplain text:\n%s\n", r.Duration, r.PlainText)
+	return err
+}
+
+// writeJSON writes the result as a JSON object on a single line.
+func (r *attackResult) writeJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// write writes the result as JSON if asJSON is true, otherwise in a human
+// readable format.
+func (r *attackResult) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return r.writeJSON(w)
+	}
+	return r.writeText(w)
+}
+
+// countOracleCalls returns an aesOracle that wraps the given oracle and counts
+// how many times it's called, and a function returning the current count.
+func countOracleCalls(oracle aesOracle) (aesOracle, func() int64) {
+	var calls atomic.Int64
+
+	counted := func(plainText []byte) ([]byte, error) {
+		calls.Add(1)
+		return oracle(plainText)
+	}
+
+	return counted, calls.Load
+}
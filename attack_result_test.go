@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCountOracleCalls(t *testing.T) {
+	echo := func(plainText []byte) ([]byte, error) { return plainText, nil }
+
+	oracle, calls := countOracleCalls(echo)
+	for range 5 {
+		if _, err := oracle(nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if got := calls(); got != 5 {
+		t.Errorf("want 5 oracle calls, got %d", got)
+	}
+}
+
+func TestAttackResultJSON(t *testing.T) {
+	res := attackResult{
+		Attack:    "test",
+		Key:       []byte("ICE"),
+		PlainText: []byte("Burning 'em"),
+	}
+
+	var out bytes.Buffer
+	if err := res.write(&out, true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// key and plain text are base64 encoded.
+	for _, want := range []string{`"key":"SUNF"`, `"plainText":"QnVybmluZyAnZW0="`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %s:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// auditEntry records a query made to an oracle server.
+type auditEntry struct {
+	Time time.Time `json:"time"`
+
+	// Caller is the host the query came from.
+	Caller string `json:"caller"`
+
+	// PlainTextHash is the hex encoded SHA-256 of the plain text. We don't
+	// log the plain text itself: the log shows the shape of an attack (how
+	// many queries, how fast, how similar), not its data.
+	// It's empty if the query was refused before its plain text was known,
+	// i.e., if it was throttled or malformed.
+	PlainTextHash string `json:"plainTextSha256,omitempty"`
+	PlainTextLen  int    `json:"plainTextLen"`
+
+	// Status is the HTTP status code of the reply.
+	Status int `json:"status"`
+}
+
+// auditLog keeps the entries of the queries made to an oracle server, so that
+// we can look at an attack from the defender's side. It's safe for concurrent
+// use.
+type auditLog struct {
+	// now returns the current time. Tests replace it with a fixed clock.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries []auditEntry
+}
+
+// newAuditLog returns an empty auditLog.
+func newAuditLog() *auditLog {
+	return &auditLog{now: time.Now}
+}
+
+// record adds an entry for a query with the given plain text from the given
+// remote address, answered with the given HTTP status code. plainText is nil
+// if the query was refused before its plain text was known.
+func (l *auditLog) record(remoteAddr string, plainText []byte, status int) {
+	caller, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		caller = remoteAddr
+	}
+
+	var plainTextHash string
+	if plainText != nil {
+		hash := sha256.Sum256(plainText)
+		plainTextHash = hex.EncodeToString(hash[:])
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, auditEntry{
+		Time:          l.now(),
+		Caller:        caller,
+		PlainTextHash: plainTextHash,
+		PlainTextLen:  len(plainText),
+		Status:        status,
+	})
+}
+
+// snapshot returns a copy of the entries recorded so far.
+func (l *auditLog) snapshot() []auditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]auditEntry(nil), l.entries...)
+}
+
+// exportHandler returns an http.Handler that replies to GET requests with the
+// entries recorded so far, as JSON objects one per line.
+func (l *auditLog) exportHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, e := range l.snapshot() {
+			if err := enc.Encode(e); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// withAuditLog makes the oracle handler record every query in log, including
+// the ones it refuses because they're throttled (see withRateLimit) or
+// malformed.
+func withAuditLog(log *auditLog) oracleHandlerOption {
+	return func(o *oracleHandlerOptions) {
+		o.audit = log
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	var (
+		log   = newAuditLog()
+		clock = time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)
+	)
+	log.now = func() time.Time { return clock }
+
+	oracle, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/oracle", oracleHandler(oracle, withAuditLog(log)))
+	mux.Handle("/audit", log.exportHandler())
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	o := httpOracle(srv.Client(), srv.URL+"/oracle")
+	for _, pt := range []string{"A", "AA", "AAA"} {
+		if _, err := o([]byte(pt)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/audit")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var (
+		s       = bufio.NewScanner(resp.Body)
+		entries []auditEntry
+	)
+	for s.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(entries))
+	}
+
+	hash := sha256.Sum256([]byte("AA"))
+	want := auditEntry{
+		Time:          clock,
+		Caller:        "127.0.0.1",
+		PlainTextHash: hex.EncodeToString(hash[:]),
+		PlainTextLen:  2,
+		Status:        http.StatusOK,
+	}
+	if entries[1] != want {
+		t.Errorf("\nwant:\t%+v\ngot:\t%+v\n", want, entries[1])
+	}
+}
+
+func TestAuditLogRefusedQueries(t *testing.T) {
+	oracle, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		log = newAuditLog()
+		rl  = newRateLimiter(2, time.Minute, time.Minute)
+		srv = httptest.NewServer(oracleHandler(oracle, withAuditLog(log), withRateLimit(rl)))
+	)
+	defer srv.Close()
+
+	for _, body := range []string{"41", "not hex", "4141"} {
+		resp, err := srv.Client().Post(srv.URL, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	entries := log.snapshot()
+	wantStatus := []int{http.StatusOK, http.StatusBadRequest, http.StatusTooManyRequests}
+	if len(entries) != len(wantStatus) {
+		t.Fatalf("want %d entries, got %d: %+v", len(wantStatus), len(entries), entries)
+	}
+	for i, e := range entries {
+		if e.Status != wantStatus[i] {
+			t.Errorf("entry %d: want status %d, got %d", i, wantStatus[i], e.Status)
+		}
+	}
+	if entries[0].PlainTextHash == "" || entries[0].PlainTextLen != 1 {
+		t.Errorf("the answered query has no plain text: %+v", entries[0])
+	}
+	for _, e := range entries[1:] {
+		if e.PlainTextHash != "" || e.PlainTextLen != 0 {
+			t.Errorf("a refused query has a plain text: %+v", e)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import "iter"
+
+// blockBounds returns the boundaries of block i of data split into blocks of
+// size bytes: the block is data[start:end].
+func blockBounds(i, size int) (int, int) {
+	return i * size, (i + 1) * size
+}
+
+// blockAt returns block i of data split into blocks of size bytes. It's a
+// view of data, not a copy, and its capacity is limited to its length, so
+// that appending to it can't overwrite the next block.
+// It panics if data has no such block, including if its last block is
+// shorter than size.
+func blockAt(data []byte, i, size int) []byte {
+	start, end := blockBounds(i, size)
+	return data[start:end:end]
+}
+
+// numBlocks returns the number of blocks of size bytes it takes to hold n
+// bytes, counting a shorter last block.
+func numBlocks(n, size int) int {
+	return (n + size - 1) / size
+}
+
+// alignUp returns the smallest multiple of size that is at least n, e.g., the
+// length of n bytes padded to whole blocks of size bytes, if they need no
+// extra block of padding.
+func alignUp(n, size int) int {
+	return numBlocks(n, size) * size
+}
+
+// alignDown returns the largest multiple of size that is at most n, e.g.,
+// the offset of the block of size bytes holding byte n.
+func alignDown(n, size int) int {
+	return n / size * size
+}
+
+// blockRange returns an iterator over the indexes and the blocks of size
+// bytes of data. If the length of data is not a multiple of size, the last
+// block is shorter.
+// The blocks are views of data, like the ones returned by blockAt.
+// It panics if size is not positive.
+func blockRange(data []byte, size int) iter.Seq2[int, []byte] {
+	if size <= 0 {
+		panic("invalid block size")
+	}
+
+	return func(yield func(int, []byte) bool) {
+		for i := range numBlocks(len(data), size) {
+			start, end := blockBounds(i, size)
+			end = min(end, len(data))
+			if !yield(i, data[start:end:end]) {
+				return
+			}
+		}
+	}
+}
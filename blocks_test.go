@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBlockAt(t *testing.T) {
+	data := []byte("YELLOW SUBMARINE")
+
+	if got := blockAt(data, 2, 4); string(got) != "UBMA" {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "UBMA", got)
+	}
+
+	// appending to a block must not overwrite the next one.
+	_ = append(blockAt(data, 0, 8), '!')
+	if string(data) != "YELLOW SUBMARINE" {
+		t.Errorf("data was modified: %q", data)
+	}
+
+	if start, end := blockBounds(3, 16); start != 48 || end != 64 {
+		t.Errorf("want block 3 at [48:64], got [%d:%d]", start, end)
+	}
+}
+
+func TestBlockMath(t *testing.T) {
+	for _, tt := range []struct {
+		n, size           int
+		nBlocks, up, down int
+	}{
+		{0, 16, 0, 0, 0},
+		{1, 16, 1, 16, 0},
+		{15, 16, 1, 16, 0},
+		{16, 16, 1, 16, 16},
+		{17, 16, 2, 32, 16},
+		{10, 3, 4, 12, 9},
+	} {
+		if got := numBlocks(tt.n, tt.size); got != tt.nBlocks {
+			t.Errorf("numBlocks(%d, %d): want %d, got %d", tt.n, tt.size, tt.nBlocks, got)
+		}
+		if got := alignUp(tt.n, tt.size); got != tt.up {
+			t.Errorf("alignUp(%d, %d): want %d, got %d", tt.n, tt.size, tt.up, got)
+		}
+		if got := alignDown(tt.n, tt.size); got != tt.down {
+			t.Errorf("alignDown(%d, %d): want %d, got %d", tt.n, tt.size, tt.down, got)
+		}
+	}
+}
+
+func TestBlockRange(t *testing.T) {
+	var (
+		indexes []int
+		blocks  [][]byte
+	)
+	for i, block := range blockRange([]byte("YELLOW SUBMARINE"), 5) {
+		indexes = append(indexes, i)
+		blocks = append(blocks, block)
+	}
+
+	want := [][]byte{[]byte("YELLO"), []byte("W SUB"), []byte("MARIN"), []byte("E")}
+	if !slices.EqualFunc(blocks, want, slices.Equal) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, blocks)
+	}
+	if !slices.Equal(indexes, []int{0, 1, 2, 3}) {
+		t.Errorf("want indexes 0 to 3, got %v", indexes)
+	}
+
+	for range blockRange(nil, 16) {
+		t.Error("want no blocks for empty data")
+	}
+}
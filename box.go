@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// errBoxForged is returned by openBox when a box's tag doesn't match its
+// contents: the box was modified, or sealed with another key.
+var errBoxForged = errors.New("box failed authentication")
+
+const (
+	// _boxKeySize is the size of the key of sealBox and openBox.
+	_boxKeySize = 32
+
+	// _boxTagSize is the size of the tag of a box, unless truncated with
+	// withTagSize.
+	_boxTagSize = sha256.Size
+)
+
+// boxOptions configures sealBox and openBox.
+type boxOptions struct {
+	// nonce, if not nil, is used instead of a random nonce.
+	nonce []byte
+
+	// tagSize is the number of bytes of the HMAC kept as the tag.
+	tagSize int
+}
+
+// boxOption defines a type that sets an option of sealBox and openBox.
+// The options exist to demonstrate how misusing the construction breaks it:
+// the defaults are the only safe settings.
+type boxOption func(*boxOptions)
+
+// withBoxNonce makes sealBox use the given nonce instead of a random one.
+// Sealing two messages with the same key and nonce encrypts them with the same
+// keystream: their tags are still valid, but the XOR of the cipher texts is
+// the XOR of the plain texts.
+func withBoxNonce(nonce []byte) boxOption {
+	return func(o *boxOptions) {
+		o.nonce = nonce
+	}
+}
+
+// withTagSize makes sealBox and openBox truncate the tag to size bytes.
+// A tag of n bytes can be guessed in 2^(8n) tries at most, and with CTR an
+// attacker can flip any bit of the plain text: short tags make forgeries
+// cheap.
+func withTagSize(size int) boxOption {
+	return func(o *boxOptions) {
+		o.tagSize = size
+	}
+}
+
+// newBoxOptions returns the boxOptions resulting from applying opts to the
+// default options: a random nonce and the whole HMAC as the tag.
+func newBoxOptions(opts []boxOption) (boxOptions, error) {
+	o := boxOptions{tagSize: _boxTagSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.tagSize < 1 || o.tagSize > _boxTagSize {
+		const formatStr = "invalid tag size %d (must be between 1 and %d)"
+		return boxOptions{}, fmt.Errorf(formatStr, o.tagSize, _boxTagSize)
+	}
+	if o.nonce != nil && len(o.nonce) != _ctrNonceSize {
+		return boxOptions{}, fmt.Errorf("invalid nonce size %d; want %d", len(o.nonce), _ctrNonceSize)
+	}
+
+	return o, nil
+}
+
+// boxKeys derives the encryption key (AES-128) and the MAC key (HMAC-SHA256)
+// of a box from its key, with the expansion step of HKDF (RFC 5869), as the
+// key is already random. Using the same key for both would let an attack on
+// one of them spill over to the other.
+func boxKeys(key []byte) ([]byte, []byte) {
+	var (
+		encKey = hmacSHA256(key, []byte("cryptopals box encryption\x01"))
+		macKey = hmacSHA256(key, []byte("cryptopals box authentication\x01"))
+	)
+	return encKey[:aes.BlockSize], macKey
+}
+
+// sealBox encrypts and authenticates plainText under key, which must be
+// _boxKeySize random bytes (e.g., from newAESKey(256)), and returns the box:
+// [nonce || cipher text || tag].
+// It's encrypt-then-MAC built from the package's primitives: the plain text is
+// encrypted with AES-CTR under a random nonce, then the nonce and the cipher
+// text are authenticated with HMAC-SHA256. The two keys are derived from key
+// (see boxKeys). openBox checks the tag before decrypting anything, so a
+// modified box is rejected without revealing anything about its contents.
+// It honors withBoxNonce and withTagSize, which break it on purpose.
+func sealBox(key, plainText []byte, opts ...boxOption) ([]byte, error) {
+	options, err := newBoxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != _boxKeySize {
+		return nil, fmt.Errorf("invalid box key size %d; want %d", len(key), _boxKeySize)
+	}
+
+	nonce := options.nonce
+	if nonce == nil {
+		nonce, err = newNonce(_ctrNonceSize)
+		if err != nil {
+			return nil, fmt.Errorf("generating random nonce: %w", err)
+		}
+	}
+
+	encKey, macKey := boxKeys(key)
+	cipherText, err := encryptAesCtr(plainText, encKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	box := concatInto(nil, nonce, cipherText)
+	tag := hmacSHA256(macKey, box)[:options.tagSize]
+
+	return append(box, tag...), nil
+}
+
+// openBox checks the tag of a box sealed by sealBox with the same key and
+// options, and returns its plain text. It returns errBoxForged if the tag
+// doesn't match.
+func openBox(key, box []byte, opts ...boxOption) ([]byte, error) {
+	options, err := newBoxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != _boxKeySize {
+		return nil, fmt.Errorf("invalid box key size %d; want %d", len(key), _boxKeySize)
+	}
+	if len(box) < _ctrNonceSize+options.tagSize {
+		const formatStr = "%w: box is %d bytes long, shorter than a nonce and a tag"
+		return nil, fmt.Errorf(formatStr, errBoxForged, len(box))
+	}
+
+	var (
+		tagStart       = len(box) - options.tagSize
+		encKey, macKey = boxKeys(key)
+		wantTag        = hmacSHA256(macKey, box[:tagStart])[:options.tagSize]
+	)
+	// like verifyHMAC, but the tag may be truncated.
+	if !hmac.Equal(box[tagStart:], wantTag) {
+		return nil, errBoxForged
+	}
+
+	return encryptAesCtr(box[_ctrNonceSize:tagStart], encKey, box[:_ctrNonceSize])
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// randomBoxKey returns a random key for sealBox.
+func randomBoxKey(tb testing.TB) []byte {
+	tb.Helper()
+
+	key, err := newAESKey(8 * _boxKeySize)
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err)
+	}
+	return key
+}
+
+func TestSealOpenBox(t *testing.T) {
+	key := randomBoxKey(t)
+
+	for _, plainText := range []string{
+		"",
+		"x",
+		"YELLOW SUBMARINE",
+		"comment1=cooking%20MCs;userdata=;admin=true;comment2=%20like%20a%20pound%20of%20bacon",
+	} {
+		box, err := sealBox(key, []byte(plainText))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := _ctrNonceSize + len(plainText) + _boxTagSize; len(box) != want {
+			t.Errorf("want a %d-byte box, got %d bytes", want, len(box))
+		}
+
+		opened, err := openBox(key, box)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(opened) != plainText {
+			t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, opened)
+		}
+	}
+}
+
+func TestSealBoxRandomNonce(t *testing.T) {
+	var (
+		key       = randomBoxKey(t)
+		plainText = []byte("YELLOW SUBMARINE")
+	)
+
+	box1, err := sealBox(key, plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	box2, err := sealBox(key, plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bytes.Equal(box1, box2) {
+		t.Error("sealing the same plain text twice gave the same box")
+	}
+}
+
+func TestOpenBoxRejectsForgeries(t *testing.T) {
+	key := randomBoxKey(t)
+
+	box, err := sealBox(key, []byte("comment1=cooking%20MCs;userdata=;admin=false"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// flipping a bit anywhere, nonce and tag included, breaks the tag.
+	for i := range box {
+		tampered := bytes.Clone(box)
+		tampered[i] ^= 0x01
+		if _, err := openBox(key, tampered); !errors.Is(err, errBoxForged) {
+			t.Fatalf("byte %d flipped: want %v, got %v", i, errBoxForged, err)
+		}
+	}
+
+	if _, err := openBox(key, box[:len(box)-1]); !errors.Is(err, errBoxForged) {
+		t.Errorf("truncated box: want %v, got %v", errBoxForged, err)
+	}
+	if _, err := openBox(key, box[:_ctrNonceSize]); !errors.Is(err, errBoxForged) {
+		t.Errorf("short box: want %v, got %v", errBoxForged, err)
+	}
+	if _, err := openBox(randomBoxKey(t), box); !errors.Is(err, errBoxForged) {
+		t.Errorf("wrong key: want %v, got %v", errBoxForged, err)
+	}
+}
+
+func TestBoxKeysAreSeparate(t *testing.T) {
+	encKey, macKey := boxKeys(randomBoxKey(t))
+	if bytes.Contains(macKey, encKey) {
+		t.Error("the encryption key is part of the MAC key")
+	}
+}
+
+// TestBoxNonceReuse shows what reusing a nonce costs: both boxes are
+// authentic, but knowing one plain text reveals the other.
+func TestBoxNonceReuse(t *testing.T) {
+	var (
+		key    = randomBoxKey(t)
+		nonce  = make([]byte, _ctrNonceSize)
+		known  = []byte("Attack at dawn, bring the coffee.")
+		secret = []byte("The password is YELLOW SUBMARINE!")
+	)
+
+	box1, err := sealBox(key, known, withBoxNonce(nonce))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	box2, err := sealBox(key, secret, withBoxNonce(nonce))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := openBox(key, box2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		cipherText1 = box1[_ctrNonceSize : len(box1)-_boxTagSize]
+		cipherText2 = box2[_ctrNonceSize : len(box2)-_boxTagSize]
+		recovered   = make([]byte, len(cipherText2))
+	)
+	for i := range recovered {
+		recovered[i] = cipherText1[i] ^ cipherText2[i] ^ known[i]
+	}
+	if !bytes.Equal(recovered, secret) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, recovered)
+	}
+}
+
+// TestBoxTruncatedTag shows what truncating the tag costs: CTR lets us
+// rewrite a plain text we know part of without the key, and with a 1-byte tag
+// guessing the tag of the result takes at most 256 tries.
+func TestBoxTruncatedTag(t *testing.T) {
+	var (
+		key       = randomBoxKey(t)
+		plainText = []byte("to=alice;amount=0010")
+		want      = []byte("to=alice;amount=9999")
+	)
+
+	forge := func(opts ...boxOption) ([]byte, int, error) {
+		options, err := newBoxOptions(opts)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		box, err := sealBox(key, plainText, opts...)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		forged := bytes.Clone(box)
+		for i := range plainText {
+			forged[_ctrNonceSize+i] ^= plainText[i] ^ want[i]
+		}
+
+		tag := forged[len(forged)-options.tagSize:]
+		for tries := 1; tries <= 256; tries++ {
+			tag[len(tag)-1] = byte(tries - 1)
+			opened, err := openBox(key, forged, opts...)
+			if err == nil {
+				return opened, tries, nil
+			}
+			if !errors.Is(err, errBoxForged) {
+				return nil, tries, err
+			}
+		}
+		return nil, 256, errBoxForged
+	}
+
+	opened, tries, err := forge(withTagSize(1))
+	if err != nil {
+		t.Fatalf("1-byte tag: unexpected error: %s", err)
+	}
+	if !bytes.Equal(opened, want) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, opened)
+	}
+	t.Logf("forged a box with a 1-byte tag in %d tries", tries)
+
+	if _, _, err := forge(); !errors.Is(err, errBoxForged) {
+		t.Errorf("full tag: want %v, got %v", errBoxForged, err)
+	}
+}
+
+func TestBoxInvalidOptions(t *testing.T) {
+	key := randomBoxKey(t)
+
+	for _, opts := range [][]boxOption{
+		{withTagSize(0)},
+		{withTagSize(_boxTagSize + 1)},
+		{withBoxNonce(make([]byte, 12))},
+	} {
+		if _, err := sealBox(key, []byte("YELLOW SUBMARINE"), opts...); err == nil {
+			t.Error("want error for invalid options, got nil")
+		}
+	}
+
+	if _, err := sealBox(key[:16], []byte("YELLOW SUBMARINE")); err == nil {
+		t.Error("want error for invalid key size, got nil")
+	}
+}
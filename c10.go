@@ -9,18 +9,27 @@ import (
 // key and initialization vector.
 // In case of an error during encryption, it returns the error and the cipher
 // text generated up to when the error occurred.
-func encryptAesCbc(plainText, key, iv []byte) ([]byte, error) {
+func encryptAesCbc(plainText, key, iv []byte, opts ...cipherOption) ([]byte, error) {
+	options := newCipherOptions(opts)
+
+	plainText, err := options.padPlainText(plainText, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.stdlib {
+		return encryptAesCbcStdlib(plainText, key, iv)
+	}
+
 	var (
 		ivLen  = len(iv)
 		keyLen = len(key)
 	)
 	if ivLen%keyLen != 0 {
-		const formatStr = "initialization vector length %d is not a multiple of the key size %d"
-		return nil, fmt.Errorf(formatStr, ivLen, keyLen)
+		const formatStr = "%w: initialization vector length %d is not a multiple of the key size %d"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, ivLen, keyLen)
 	}
 
-	plainText = padPkcs7(plainText, aes.BlockSize)
-
 	encrypter, err := aesEncrypter(key)
 	if err != nil {
 		return nil, err
@@ -29,31 +38,33 @@ func encryptAesCbc(plainText, key, iv []byte) ([]byte, error) {
 	var (
 		plainTextLen = len(plainText)
 		blockSize    = aes.BlockSize
-		nBlocks      = (plainTextLen + blockSize - 1) / blockSize
+		nBlocks      = numBlocks(plainTextLen, blockSize)
 		cipherText   = make([]byte, 0, plainTextLen)
 	)
+	// without padding, an empty plain text has no blocks to encrypt, as with
+	// the standard library.
+	if plainTextLen == 0 {
+		return cipherText, nil
+	}
+
 	// The first plaintext block, which has no associated previous ciphertext
 	// block, is added to the the initialization vector.
 	firstBlock, err := xorBlocks(plainText[:blockSize], iv)
 	if err != nil {
-		return nil, fmt.Errorf("xor first plain text block with IV: %s", err)
+		return nil, fmt.Errorf("xor first plain text block with IV: %w", err)
 	}
 	cipherText = append(cipherText, encrypter(firstBlock)...)
 
 	for b := 1; b < nBlocks; b++ {
 		var (
-			prevBlockStart = (b - 1) * blockSize
-			prevBlockEnd   = (b-1)*blockSize + blockSize
-			prevBlock      = cipherText[prevBlockStart:prevBlockEnd]
-
-			currBlockStart = b * blockSize
-			currBlockEnd   = b*blockSize + blockSize
-			currBlock      = plainText[currBlockStart:currBlockEnd]
+			prevBlock = blockAt(cipherText, b-1, blockSize)
+			currBlock = blockAt(plainText, b, blockSize)
 		)
 		cipherTextBlock, err := xorBlocks(prevBlock, currBlock)
 		if err != nil {
-			const formatStr = "xor plain text blocks %d and %d: %s"
-			return cipherText[:prevBlockEnd], fmt.Errorf(formatStr, b-1, b, err)
+			const formatStr = "xor plain text blocks %d and %d: %w"
+			start, _ := blockBounds(b, blockSize)
+			return cipherText[:start], fmt.Errorf(formatStr, b-1, b, err)
 		}
 		cipherText = append(cipherText, encrypter(cipherTextBlock)...)
 	}
@@ -65,20 +76,24 @@ func encryptAesCbc(plainText, key, iv []byte) ([]byte, error) {
 // key and initialization vector.
 // In case of an error during decryption, it returns the error and the plain
 // text decrypted up to when the error occurred.
-func decryptAesCbc(cipherText, key, iv []byte) ([]byte, error) {
+func decryptAesCbc(cipherText, key, iv []byte, opts ...cipherOption) ([]byte, error) {
+	if newCipherOptions(opts).stdlib {
+		return decryptAesCbcStdlib(cipherText, key, iv)
+	}
+
 	var (
 		cipherTextLen = len(cipherText)
 		keyLen        = len(key)
 	)
-	if cipherTextLen%keyLen != 0 {
-		const formatStr = "cipher text's length (%d) is not a multiple of the decryption key's length (%d)"
-		return nil, fmt.Errorf(formatStr, len(cipherText), len(key))
+	if cipherTextLen == 0 || cipherTextLen%keyLen != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the decryption key's length (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), len(key))
 	}
 
 	ivLen := len(iv)
 	if ivLen%keyLen != 0 {
-		const formatStr = "initialization vector length %d is not a multiple of the key size %d"
-		return nil, fmt.Errorf(formatStr, ivLen, keyLen)
+		const formatStr = "%w: initialization vector length %d is not a multiple of the key size %d"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, ivLen, keyLen)
 	}
 
 	decrypter, err := aesDecrypter(key)
@@ -88,31 +103,27 @@ func decryptAesCbc(cipherText, key, iv []byte) ([]byte, error) {
 
 	var (
 		blockSize = keyLen
-		nBlocks   = (cipherTextLen + blockSize - 1) / blockSize
+		nBlocks   = numBlocks(cipherTextLen, blockSize)
 		plainText = make([]byte, 0, cipherTextLen)
 	)
 	// The first plaintext block, which has no associated previous ciphertext
 	// block, is xored with the initialization vector.
 	firstBlock, err := xorBlocks(decrypter(cipherText[:blockSize]), iv)
 	if err != nil {
-		return nil, fmt.Errorf("xor first plain text block with IV: %s", err)
+		return nil, fmt.Errorf("xor first plain text block with IV: %w", err)
 	}
 	plainText = append(plainText, firstBlock...)
 
 	for b := 1; b < nBlocks; b++ {
 		var (
-			prevBlockStart = (b - 1) * blockSize
-			prevBlockEnd   = (b-1)*blockSize + blockSize
-			prevBlock      = cipherText[prevBlockStart:prevBlockEnd]
-
-			currBlockStart = b * blockSize
-			currBlockEnd   = b*blockSize + blockSize
-			currBlock      = decrypter(cipherText[currBlockStart:currBlockEnd])
+			prevBlock = blockAt(cipherText, b-1, blockSize)
+			currBlock = decrypter(blockAt(cipherText, b, blockSize))
 		)
 		plainTextBlock, err := xorBlocks(prevBlock, currBlock)
 		if err != nil {
-			const formatStr = "xor plain text blocks %d and %d: %s"
-			return plainText[:prevBlockEnd], fmt.Errorf(formatStr, b-1, b, err)
+			const formatStr = "xor plain text blocks %d and %d: %w"
+			start, _ := blockBounds(b, blockSize)
+			return plainText[:start], fmt.Errorf(formatStr, b-1, b, err)
 		}
 		plainText = append(plainText, plainTextBlock...)
 	}
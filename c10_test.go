@@ -2,10 +2,10 @@ package main
 
 import (
 	"bytes"
-	"encoding/base64"
-	"io"
-	"os"
+	"errors"
 	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
 )
 
 func TestAesCbcEncryption(t *testing.T) {
@@ -36,30 +36,30 @@ func TestAesCbcEncryption(t *testing.T) {
 }
 
 func TestAesCbcDecryption(t *testing.T) {
-	f, err := os.Open("./files/2_10.txt")
-	if err != nil {
-		t.Fatalf("opening file: %s", err)
-	}
-	defer f.Close()
-
-	decoder := base64.NewDecoder(base64.StdEncoding, f)
-	cipherText, err := io.ReadAll(decoder)
-	if err != nil {
-		t.Fatalf("reading file: %s", err)
-	}
+	cipherText := testutil.MustLoadBase64(t, "./files/2_10.txt")
 
 	var (
 		key = []byte("YELLOW SUBMARINE")
 		iv  = make([]byte, len(key))
 	)
-	for i := range iv {
-		iv[i] = byte(0)
-	}
 
 	plainText, err := decryptAesCbc(cipherText, key, iv)
 	if err != nil {
-		t.Error(err)
+		t.Fatalf("unexpected error: %s", err)
 	}
 
-	t.Log(string(plainText))
+	// it's the same text as challenge 7's.
+	testutil.Golden(t, "./files/1_7.golden", delPadPkcs7(plainText))
+}
+
+func TestAesCbcDecryptionEmpty(t *testing.T) {
+	var (
+		key = []byte("YELLOW SUBMARINE")
+		iv  = make([]byte, len(key))
+	)
+
+	_, err := decryptAesCbc(nil, key, iv)
+	if !errors.Is(err, errNotBlockAligned) {
+		t.Errorf("want %v, got %v", errNotBlockAligned, err)
+	}
 }
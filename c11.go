@@ -11,49 +11,51 @@ import (
 func encryptionOracle(plainText []byte) ([]byte, error) {
 	padded, err := addRandomNoise(plainText)
 	if err != nil {
-		return nil, fmt.Errorf("secretly adding noise to plain text: %s", err)
+		return nil, fmt.Errorf("secretly adding noise to plain text: %w", err)
 	}
 
-	key, err := randomBytes(aes.BlockSize, aes.BlockSize)
+	key, err := newAESKey(128)
 	if err != nil {
-		return nil, fmt.Errorf("generating random AES key: %s", err)
+		return nil, fmt.Errorf("generating random AES key: %w", err)
 	}
+	defer secureZero(key)
 
 	if coinFlip := mrand.IntN(2); coinFlip == 0 {
-		return encryptAesEcb(padded, key)
+		return encryptAesEcb(padded, key, withStdlib())
 	}
 
-	iv, err := randomBytes(aes.BlockSize, aes.BlockSize)
+	iv, err := newIV(aes.BlockSize)
 	if err != nil {
-		const formatStr = "generating random IV for AES CBC encryption: %s"
+		const formatStr = "generating random IV for AES CBC encryption: %w"
 		return nil, fmt.Errorf(formatStr, err)
 	}
+	defer secureZero(iv)
 
-	return encryptAesCbc(padded, key, iv)
+	return encryptAesCbc(padded, key, iv, withStdlib())
 }
 
 // encryptAesEcb encrypts a plain text using AES-128 in ECB mode with the given
 // key.
-func encryptAesEcb(plainText, key []byte) ([]byte, error) {
-	plainText = padPkcs7(plainText, aes.BlockSize)
+func encryptAesEcb(plainText, key []byte, opts ...cipherOption) ([]byte, error) {
+	options := newCipherOptions(opts)
+
+	plainText, err := options.padPlainText(plainText, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.stdlib {
+		return encryptAesEcbStdlib(plainText, key)
+	}
 
 	encrypter, err := aesEncrypter(key)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		plainTextLen = len(plainText)
-		blockSize    = aes.BlockSize
-		nBlocks      = (plainTextLen + blockSize - 1) / blockSize
-		cipherText   = make([]byte, 0, plainTextLen)
-	)
-	for b := range nBlocks {
-		var (
-			start = b * blockSize
-			end   = start + blockSize
-		)
-		cipherText = append(cipherText, encrypter(plainText[start:end])...)
+	cipherText := make([]byte, 0, len(plainText))
+	for block := range chunks(plainText, aes.BlockSize) {
+		cipherText = append(cipherText, encrypter(block)...)
 	}
 
 	return cipherText, nil
@@ -69,19 +71,14 @@ func encryptAesEcbString(plainText, key string) (string, error) {
 // addRandomNoise prepends and appends random bytes to the given data.
 // It does not modify the forged data slice.
 func addRandomNoise(data []byte) ([]byte, error) {
-	prefix, err := randomBytes(5, 10)
+	prefix, err := randomBytesRange(5, 10)
 	if err != nil {
 		return nil, err
 	}
-	suffix, err := randomBytes(5, 10)
+	suffix, err := randomBytesRange(5, 10)
 	if err != nil {
 		return nil, err
 	}
 
-	buf := make([]byte, len(prefix)+len(data)+len(suffix))
-	copy(buf, prefix)
-	copy(buf[len(prefix):], data)
-	copy(buf[len(prefix)+len(data):], suffix)
-
-	return buf, nil
+	return concatInto(nil, prefix, data, suffix), nil
 }
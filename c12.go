@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
-	"crypto/aes"
+	"context"
+	"errors"
 	"fmt"
+
+	"github.com/alesforz/cryptopals/internal/brute"
 )
 
 // decryptOracleSecret implements a byte-at-a-time decryption attack, aka
@@ -16,11 +19,24 @@ import (
 // This method exploits the deterministic nature of block ciphers and the
 // feedback from the oracle to reveal the hidden data.
 // See file example_byte_at_a_time.txt for a visual example of this method.
+// It honors withBlockSize, withMaxOracleCalls, withMaxSecretLen, withProgress,
+// withExplain and withVerifier, which it calls with the bytes of the secret
+// recovered so far after each byte: if it accepts them, they are returned
+// without the rest of the secret (and without padding).
 // Challenge 12 of set 2.
-func decryptOracleSecret(encryptionOracle aesOracle) ([]byte, error) {
+func decryptOracleSecret(
+	encryptionOracle aesOracle,
+	opts ...attackOption,
+) ([]byte, error) {
+
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	encryptionOracle = options.limitOracle(encryptionOracle)
 
 	var (
-		blockSize   = aes.BlockSize
+		blockSize   = options.blockSize
 		shortBlocks = make([][]byte, blockSize)
 	)
 	// craft blocks of known bytes shorter than a full AES block.
@@ -43,46 +59,56 @@ func decryptOracleSecret(encryptionOracle aesOracle) ([]byte, error) {
 	// (shortBlocks[0] stores an empty block, therefore the oracle will
 	// encrypt [{}||secret]).
 	// We do this to know the number of blocks we have to decrypt.
-	encryptedSecret, err := encryptionOracle(shortBlocks[0])
+	encryptedSecret, err := secretCipherText(encryptionOracle, shortBlocks[0])
 	if err != nil {
 		return nil, err
 	}
+	if err := checkSecretCipherText(encryptedSecret, options); err != nil {
+		return nil, err
+	}
 
 	var (
 		nBlocks = len(encryptedSecret) / blockSize
 		secret  = make([]byte, 0, len(encryptedSecret))
+
+		// the plain text we send to the oracle, reused across iterations.
+		forged []byte
 	)
+	const formatStr = "the oracle encrypts the secret into %d blocks (%d bytes)"
+	explainf(options.explain, formatStr, nBlocks, len(encryptedSecret))
+	options.progress(attackEvent{
+		kind:       eventTarget,
+		blockSize:  blockSize,
+		cipherText: encryptedSecret,
+	})
 	for blockIdx := range nBlocks {
 		for size := blockSize - 1; size >= 0; size-- {
 			knownBytes := shortBlocks[size]
 
-			// these could be cached, as the encryption of a given short block
-			// is always the same cipher text.
-			cipherText, err := encryptionOracle(knownBytes)
+			// boundaries of the cipher text block being targeted for
+			// decryption.
+			start, end := blockBounds(blockIdx, blockSize)
+
+			// the encryption of a given short block is always the same cipher
+			// text, but we query it again for every block rather than cache
+			// it: a cache would keep blockSize whole cipher texts for the
+			// whole attack, while we only hold this one, and only its target
+			// block is used.
+			cipherText, err := queryOracle(encryptionOracle, knownBytes, end)
 			if err != nil {
 				return secret, err
 			}
 
-			var (
-				// boundaries of the cipher text block being targeted for
-				// decryption.
-				start = blockIdx * blockSize
-				end   = blockIdx*blockSize + blockSize
-
-				// the cipher text block being targeted for decryption.
-				targetBlock = cipherText[start:end]
+			// the cipher text block being targeted for decryption.
+			targetBlock := cipherText[start:end]
 
-				// a combination of known bytes, previously
-				// decrypted bytes of the secret, and the byte currently being
-				// guessed (the +1, which we will brute force in the loop
-				// below).
-				forged = make([]byte, len(knownBytes)+len(secret)+1)
-			)
-			copy(forged, knownBytes)
-			copy(forged[len(knownBytes):], secret)
+			// a combination of known bytes, previously decrypted bytes of the
+			// secret, and the byte currently being guessed (the last one,
+			// which we will brute force below).
+			forged = concatInto(forged, knownBytes, secret, []byte{0})
 
 			// the "byte-at-a-time" part of the attack.
-			// This loop is responsible for guessing the value of the unknown
+			// This search is responsible for guessing the value of the unknown
 			// byte of the secret by iterating through all possible byte values
 			// (0 to 255) and checking which one produces a ciphertext block
 			// that matches the target block.
@@ -108,21 +134,66 @@ func decryptOracleSecret(encryptionOracle aesOracle) ([]byte, error) {
 			// If the cipher text generated by oracle("AAAAAAAAAAAAAADA")
 			// matches, then 'A' is the next byte of the secret.
 			// And so on until we decrypted the entire secret.
-			for i := range 255 {
-				char := byte(i)
-
+			// the guesses must be tried in order: they share the forged
+			// buffer, and progress reporters expect the events in order.
+			var sampleCipherText []byte
+			guess := func(char byte) (bool, error) {
 				forged[len(forged)-1] = char
 
-				sampleCipherText, err := encryptionOracle(forged)
+				var err error
+				sampleCipherText, err = queryOracle(encryptionOracle, forged, end)
 				if err != nil {
-					const formatStr = "trying byte %d (%c): %s"
-					return secret, fmt.Errorf(formatStr, i, char, err)
+					const formatStr = "trying byte %d (%c): %w"
+					return false, fmt.Errorf(formatStr, char, char, err)
 				}
 
-				if bytes.Equal(sampleCipherText[start:end], targetBlock) {
-					secret = append(secret, char)
-					break
-				}
+				options.progress(attackEvent{
+					kind:        eventGuess,
+					blockSize:   blockSize,
+					targetBlock: blockIdx,
+					plainText:   forged,
+					cipherText:  sampleCipherText,
+					guessPos:    len(forged) - 1,
+					recovered:   secret,
+				})
+
+				return bytes.Equal(sampleCipherText[start:end], targetBlock), nil
+			}
+
+			char, err := brute.Search(context.Background(), brute.Bytes{}, guess, brute.Options{Workers: 1})
+			if errors.Is(err, brute.ErrNotFound) {
+				// we reached the padding, whose bytes change with the
+				// length of our input: there is nothing left to recover.
+				// Going on would misalign the bytes we forge with the
+				// secret (e.g., if a random prefix made us miss a byte).
+				return secret, nil
+			}
+			if err != nil {
+				return secret, err
+			}
+
+			secret = append(secret, char)
+
+			options.progress(attackEvent{
+				kind:        eventRecovered,
+				blockSize:   blockSize,
+				targetBlock: blockIdx,
+				plainText:   forged,
+				cipherText:  sampleCipherText,
+				guessPos:    len(forged) - 1,
+				recovered:   secret,
+			})
+
+			const formatStr = "block %d, %2d filler bytes: %q matches the target block: secret[%d] = %q"
+			explainf(options.explain, formatStr, blockIdx, size, forged[start:end], len(secret)-1, char)
+
+			done, err := options.verify(secret)
+			if err != nil {
+				return secret, err
+			}
+			if done {
+				explainf(options.explain, "the verifier accepts the %d bytes recovered so far", len(secret))
+				return secret, nil
 			}
 		}
 	}
@@ -130,20 +201,106 @@ func decryptOracleSecret(encryptionOracle aesOracle) ([]byte, error) {
 	return secret, nil
 }
 
-// ecbEncryptionOracle returns an aesOracle that appends the secret to the
-// plain text before encrypting it with the same (randomly generated) key.
-func ecbEncryptionOracle(secret []byte) (aesOracle, error) {
-	key, err := randomBytes(aes.BlockSize, aes.BlockSize)
+// _maxOracleRetries is how many times decryptOracleSecret queries an oracle
+// that fails or returns a truncated cipher text before giving up.
+const _maxOracleRetries = 10
+
+// queryOracle asks the oracle to encrypt the given plain text, retrying if the
+// oracle fails or returns less than minLen bytes of cipher text.
+// Truncation can only remove bytes from the end of the cipher text, so
+// whatever is within the first minLen bytes is reliable.
+func queryOracle(oracle aesOracle, plainText []byte, minLen int) ([]byte, error) {
+	var err error
+	for range _maxOracleRetries {
+		var cipherText []byte
+		cipherText, err = oracle(plainText)
+		if errors.Is(err, errMaxOracleCalls) {
+			// retrying can't help.
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+		if len(cipherText) >= minLen {
+			return cipherText, nil
+		}
+
+		const formatStr = "truncated cipher text: got %d bytes, want at least %d"
+		err = fmt.Errorf(formatStr, len(cipherText), minLen)
+	}
+
+	const formatStr = "oracle failed %d times, last error: %w"
+	return nil, fmt.Errorf(formatStr, _maxOracleRetries, err)
+}
+
+// checkSecretCipherText checks that the encryption of an oracle's secret is
+// made of whole blocks, and that the secret isn't longer than allowed by
+// withMaxSecretLen, before we spend hundreds of queries per byte on it.
+func checkSecretCipherText(encryptedSecret []byte, options attackOptions) error {
+	if len(encryptedSecret)%options.blockSize != 0 {
+		const formatStr = "%w: the oracle's cipher text is %d bytes long, but the block size is %d"
+		return fmt.Errorf(formatStr, errNotBlockAligned, len(encryptedSecret), options.blockSize)
+	}
+
+	// the last block is at least one byte of padding.
+	return options.checkSecretLen(len(encryptedSecret) - 1)
+}
+
+// secretCipherText asks the oracle to encrypt the given plain text a few
+// times, and returns the longest cipher text it got back, so that a truncated
+// answer doesn't make us underestimate the length of the secret.
+func secretCipherText(oracle aesOracle, plainText []byte) ([]byte, error) {
+	const nQueries = 3
+
+	var longest []byte
+	for range nQueries {
+		cipherText, err := queryOracle(oracle, plainText, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(cipherText) > len(longest) {
+			longest = cipherText
+		}
+	}
+
+	return longest, nil
+}
+
+// _challenge12Secret is the secret the oracle of challenge 12 appends to the
+// plain texts it encrypts.
+var _challenge12Secret = base64Secret{staticSecret(
+	"Um9sbGluJyBpbiBteSA1LjAKV2l0aCBteSByYWctdG9wIGRvd24gc28gbXkgaGFpciBjYW4gYmxvdwpUaGUgZ2lybGllcyBvbiBzdGFuZGJ5IHdhdmluZyBqdXN0IHRvIHNheSBoaQpEaWQgeW91IHN0b3A/IE5vLCBJIGp1c3QgZHJvdmUgYnkK",
+)}
+
+// ecbEncryptionOracle returns an aesOracle that appends the secret provided by
+// sp to the plain text before encrypting it with the same (randomly generated)
+// key. It honors withCompression and withLengthBuckets.
+func ecbEncryptionOracle(sp secretProvider, opts ...oracleOption) (aesOracle, error) {
+	options, err := newOracleOptions(opts)
 	if err != nil {
-		return nil, fmt.Errorf("generating random AES key: %s", err)
+		return nil, err
 	}
 
-	encOracle := func(plainText []byte) ([]byte, error) {
-		padded := make([]byte, len(plainText)+len(secret))
-		copy(padded, plainText)
-		copy(padded[len(plainText):], secret)
+	secret, err := sp.secret()
+	if err != nil {
+		return nil, fmt.Errorf("getting oracle's secret: %w", err)
+	}
+	// the provider may hand out memory the caller can still modify.
+	secret = cloneBytes(secret)
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, fmt.Errorf("generating random AES key: %w", err)
+	}
 
-		return encryptAesEcb(padded, key)
+	recordGroundTruth("ecbEncryptionOracle", key, secret)
+
+	encOracle := func(plainText []byte) ([]byte, error) {
+		encoded, err := options.encode(concatInto(nil, plainText, secret))
+		if err != nil {
+			return nil, err
+		}
+		return encryptAesEcb(encoded, key, withStdlib())
 	}
 
 	return encOracle, nil
@@ -1,20 +1,28 @@
 package main
 
 import (
-	"encoding/base64"
+	"bytes"
+	"strings"
 	"testing"
 )
 
 func TestDecryptOracleSecret(t *testing.T) {
-	const secret = `Um9sbGluJyBpbiBteSA1LjAKV2l0aCBteSByYWctdG9wIGRvd24gc28gbXkgaGFpciBjYW4gYmxvdwpUaGUgZ2lybGllcyBvbiBzdGFuZGJ5IHdhdmluZyBqdXN0IHRvIHNheSBoaQpEaWQgeW91IHN0b3A/IE5vLCBJIGp1c3QgZHJvdmUgYnkK`
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	decodedSecret, err := base64.StdEncoding.DecodeString(secret)
+	decryptedSecret, err := decryptOracleSecret(o)
 	if err != nil {
-		t.Fatalf("decoding secret suffix: %s", err)
+		t.Fatal(err)
 	}
+	t.Log(string(delPadPkcs7(decryptedSecret)))
+}
 
-	// const s = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
-	o, err := ecbEncryptionOracle([]byte(decodedSecret))
+func TestDecryptOracleShortSecret(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -23,5 +31,24 @@ func TestDecryptOracleSecret(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(string(delPadPkcs7(decryptedSecret)))
+
+	if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, []byte(secret)) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, got)
+	}
+}
+
+// BenchmarkDecryptOracleSecretLargeSecret measures the attack on a secret of
+// many blocks.
+func BenchmarkDecryptOracleSecretLargeSecret(b *testing.B) {
+	o, err := ecbEncryptionOracle(staticSecret(strings.Repeat("YELLOW SUBMARINE", 64)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := decryptOracleSecret(o); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -1,70 +1,199 @@
 package main
 
 import (
+	"crypto/aes"
 	"errors"
-	"math/rand"
+	"fmt"
 	"net/url"
 	"slices"
-	"strconv"
 	"strings"
 )
 
+// _defaultProfileService is the profileService used by profileFor.
+var _defaultProfileService, _ = newProfileService()
+
 // profileFor returns the encoding of a user formatted as a URL query.
 // e.g., given email "foo@bar.com", it returns
-// "email=foo@bar.com&role=user&uid=10"
+// "email=foo%40bar.com&role=user&uid=42", where the uid is a random number
+// from 10 to 99.
 func profileFor(email string) (string, error) {
-	if strings.ContainsAny(email, "&=") {
-		const errMsg = "invalid email address; can't contain '&' or '=' characters"
-		return "", errors.New(errMsg)
-	}
-
-	v := url.Values{}
-	v.Set("email", email)
-
-	// ID: 10 to 99
-	v.Add("uid", strconv.Itoa(10+rand.Intn(90)))
-
-	v.Add("role", "user")
-
-	return v.Encode(), nil
+	return _defaultProfileService.profileFor(email)
 }
 
+// profileEncoder defines a type that encodes the profile of the user with the
+// given email. The attack uses it to learn the layout of the profiles
+// encrypted by the oracle (but not their content, e.g., the uid).
+type profileEncoder func(email string) (string, error)
+
+// createAdminProfile implements the ECB cut-and-paste attack: using only the
+// encryption oracle, it forges a cipher text that decrypts to a profile with
+// role=admin, and returns whether the admin oracle accepts it.
+// encode must produce profiles with the same layout as the ones encrypted by
+// the oracle; the attack uses it to align its blocks, so it works whatever the
+// order of the profile's fields, as long as "role" comes after "email".
+// It honors withBlockSize, withMaxOracleCalls and withExplain.
+// Challenge 13 of set 2.
 func createAdminProfile(
 	encryptionOracle aesOracle,
-	adminOracle func([]byte) (bool, error),
+	isAdmin adminOracle,
+	encode profileEncoder,
+	opts ...attackOption,
 ) (bool, error) {
 
-	// This email generate a ciphertext with the following blocks:
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return false, err
+	}
+	encryptionOracle = options.limitOracle(encryptionOracle)
+
+	blockSize := options.blockSize
+
+	// We need an email that makes the profile's "role=" end on a block
+	// boundary. With the default layout, the email "foo@bar.aaaaaaaaaa"
+	// generates a cipher text with the following blocks:
 	// block 0: email=foo%40bar.
-	// block 1: aaaaaaacom&role=
+	// block 1: aaaaaaaaaa&role=
 	// block 2: user&uid=42 + padding
-	const forgedUserEmail = "foo@bar.aaaaaaaaaa"
-	forgedUser, err := encryptionOracle([]byte(forgedUserEmail))
+	forgedUserEmail, roleEnd, err := alignProfile(encode, blockSize, "foo@bar.", "", func(profile string) int {
+		if strings.HasPrefix(profile, "role=") {
+			return len("role=")
+		}
+		idx := strings.Index(profile, "&role=")
+		if idx < 0 {
+			return -1
+		}
+		return idx + len("&role=")
+	})
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("aligning role field: %w", err)
 	}
 
-	// This email generate a ciphertext with the following blocks:
+	// And an email that makes "admin" start on a block boundary. With the
+	// default layout, the email "foo@aaaaadmin" generates a cipher text with
+	// the following blocks:
 	// block 0: email=foo%40aaaa
 	// block 1: admin&role=user&
 	// block 2: uid=42 + padding
-	const maliciousAdminEmail = "foo@aaaaadmin"
+	maliciousAdminEmail, adminStart, err := alignProfile(encode, blockSize, "foo@", "admin", func(profile string) int {
+		return strings.Index(profile, "admin")
+	})
+	if err != nil {
+		return false, fmt.Errorf("aligning admin value: %w", err)
+	}
+
+	forgedUser, err := encryptionOracle([]byte(forgedUserEmail))
+	if err != nil {
+		return false, err
+	}
+
 	maliciousProfile, err := encryptionOracle([]byte(maliciousAdminEmail))
 	if err != nil {
 		return false, err
 	}
 
-	var (
-		// We can now compose a cipher text by copy pasting blocks of the two
-		// different cipher texts, so that they form an encrypted user profile
-		// that decrypts to an admin user.
-		b1 = forgedUser[:16]         // email=foo%40bar.
-		b2 = forgedUser[16:32]       // aaaaaaacom&role=
-		b3 = maliciousProfile[16:32] // admin&role=user&
-		b4 = maliciousProfile[32:]   // uid=XX+padding
-	)
+	if roleEnd > len(forgedUser) || adminStart >= len(maliciousProfile) {
+		return false, errors.New("cipher texts are shorter than the profile layout")
+	}
+
+	explainf(options.explain, "cipher text of email %q:", forgedUserEmail)
+	explainHexBlocks(options.explain, forgedUser, blockSize)
+	explainf(options.explain, "cipher text of email %q:", maliciousAdminEmail)
+	explainHexBlocks(options.explain, maliciousProfile, blockSize)
 
-	// the concatenation of the blocks above gives us:
+	// We can now compose a cipher text by copy pasting blocks of the two
+	// different cipher texts, so that they form an encrypted user profile
+	// that decrypts to an admin user. With the default layout, this gives us:
 	// email=foo%40bar.aaaaaaaaaa&role=admin&role=user&uid=XX
-	return adminOracle(slices.Concat(b1, b2, b3, b4))
+	forged := slices.Concat(forgedUser[:roleEnd], maliciousProfile[adminStart:])
+
+	const formatStr = "forged cipher text (blocks 0-%d of the first, %d-%d of the second):"
+	explainf(
+		options.explain,
+		formatStr,
+		roleEnd/blockSize-1,
+		adminStart/blockSize,
+		len(maliciousProfile)/blockSize-1,
+	)
+	explainHexBlocks(options.explain, forged, blockSize)
+
+	return isAdmin(forged)
+}
+
+// alignProfile looks for an email of the form [prefix || filler || suffix],
+// where the filler is made of 0 to blockSize-1 'a' characters, such that the
+// offset returned by find for the encoded profile lies on a block boundary.
+// It returns the email and the offset.
+func alignProfile(
+	encode profileEncoder,
+	blockSize int,
+	prefix, suffix string,
+	find func(profile string) int,
+) (string, int, error) {
+
+	for fillerLen := range blockSize {
+		email := prefix + strings.Repeat("a", fillerLen) + suffix
+
+		profile, err := encode(email)
+		if err != nil {
+			return "", 0, err
+		}
+
+		offset := find(profile)
+		if offset < 0 {
+			return "", 0, errors.New("field not found in the encoded profile")
+		}
+		if offset%blockSize == 0 {
+			return email, offset, nil
+		}
+	}
+
+	return "", 0, errors.New("the email's length doesn't affect the field's offset")
+}
+
+// adminOracle defines a type that decrypts an encrypted user profile and
+// reports whether it belongs to an admin.
+type adminOracle func([]byte) (bool, error)
+
+// newProfileOracles returns the two oracles of challenge 13, sharing the same
+// (randomly generated) key:
+//   - an aesOracle that, given an email, encrypts the user profile returned by
+//     the service with AES ECB.
+//   - an adminOracle that decrypts an encrypted profile and reports whether its
+//     role is "admin".
+func newProfileOracles(svc *profileService) (aesOracle, adminOracle, error) {
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	recordGroundTruth("newProfileOracles", key, nil)
+
+	encryptionOracle := func(email []byte) ([]byte, error) {
+		userProfile, err := svc.profileFor(string(email))
+		if err != nil {
+			return nil, err
+		}
+		return encryptAesEcb([]byte(userProfile), key)
+	}
+
+	isAdmin := func(cipherText []byte) (bool, error) {
+		plainText, err := decryptAesEcb(cipherText, key)
+		if err != nil {
+			return false, err
+		}
+
+		plainText, err = unpadPkcs7Exact(plainText, aes.BlockSize)
+		if err != nil {
+			return false, err
+		}
+
+		v, err := url.ParseQuery(string(plainText))
+		if err != nil {
+			return false, err
+		}
+
+		return v.Get("role") == "admin", nil
+	}
+
+	return encryptionOracle, isAdmin, nil
 }
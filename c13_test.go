@@ -1,10 +1,6 @@
 package main
 
-import (
-	"crypto/aes"
-	"net/url"
-	"testing"
-)
+import "testing"
 
 func TestProfileFor(t *testing.T) {
 	const email = "foo@bar.com"
@@ -18,45 +14,114 @@ func TestProfileFor(t *testing.T) {
 }
 
 func TestCreateAdminUser(t *testing.T) {
-	key, err := randomBytes(aes.BlockSize, aes.BlockSize)
+	encryptionOracle, isAdmin, err := newProfileOracles(_defaultProfileService)
 	if err != nil {
-		t.Fatalf("generating random AES key: %s", err)
+		t.Fatalf("unexpected error: %s", err)
 	}
 
-	encryptionOracle := func(email []byte) ([]byte, error) {
-		userProfile, err := profileFor(string(email))
-		if err != nil {
-			return nil, err
-		}
-		return encryptAesEcb([]byte(userProfile), key)
+	admin, err := createAdminProfile(encryptionOracle, isAdmin, profileFor)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
 	}
+	if !admin {
+		t.Fatalf("Profile is not admin")
+	}
+}
 
-	adminOracle := func(cipherText []byte) (bool, error) {
-		const (
-			roleKey   = "role"
-			adminRole = "admin"
-		)
-		plainText, err := decryptAesEcb(cipherText, key)
-		if err != nil {
-			return false, err
-		}
+func TestCreateAdminUserLayouts(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []profileOption
+	}{
+		{
+			name: "uid before role",
+			opts: []profileOption{withFieldOrder("email", "uid", "role")},
+		},
+		{
+			name: "uid first",
+			opts: []profileOption{withFieldOrder("uid", "email", "role")},
+		},
+		{
+			name: "extra field",
+			opts: []profileOption{
+				withProfileField("org", "cryptopals"),
+				withFieldOrder("org", "email", "role", "uid"),
+				withFixedUID(1234),
+			},
+		},
+	}
 
-		adminProfile := delPadPkcs7(plainText)
-		v, err := url.ParseQuery(string(adminProfile))
-		if err != nil {
-			return false, err
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := newProfileService(tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
 
-		t.Log(string(adminProfile))
+			encryptionOracle, isAdmin, err := newProfileOracles(svc)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
 
-		return v.Get(roleKey) == adminRole, nil
+			admin, err := createAdminProfile(encryptionOracle, isAdmin, svc.profileFor)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !admin {
+				t.Fatalf("Profile is not admin")
+			}
+		})
 	}
+}
 
-	isAdmin, err := createAdminProfile(encryptionOracle, adminOracle)
+func TestCreateAdminUserRoleFirst(t *testing.T) {
+	svc, err := newProfileService(withFieldOrder("role", "email", "uid"))
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err)
 	}
-	if !isAdmin {
-		t.Fatalf("Profile is not admin")
+
+	encryptionOracle, isAdmin, err := newProfileOracles(svc)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// "role=" ends at a fixed offset that's not on a block boundary, so
+	// there's nothing to align.
+	if _, err := createAdminProfile(encryptionOracle, isAdmin, svc.profileFor); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestProfileService(t *testing.T) {
+	svc, err := newProfileService(
+		withFieldOrder("uid", "role", "email"),
+		withProfileField("role", "guest"),
+		withFixedUID(7),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "uid=7&role=guest&email=foo%40bar.com"
+	got, err := svc.profileFor("foo@bar.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+
+	if _, err := svc.profileFor("foo@bar.com&role=admin"); err == nil {
+		t.Errorf("expected an error for an email containing '&'")
+	}
+
+	for i, opts := range [][]profileOption{
+		{withFieldOrder("email", "uid")},
+		{withFieldOrder("email", "role", "uid", "role")},
+		{withFieldOrder("email", "role", "uid", "org")},
+	} {
+		if _, err := newProfileService(opts...); err == nil {
+			t.Errorf("expected an error for the options of case %d", i)
+		}
 	}
 }
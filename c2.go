@@ -24,7 +24,7 @@ func xorHexStrings(s1, s2 string) (string, error) {
 
 	xored, err := xorBlocks(b1, b2)
 	if err != nil {
-		return "", fmt.Errorf("can't xor given strings: %s", err)
+		return "", fmt.Errorf("can't xor given strings: %w", err)
 	}
 
 	return hex.EncodeToString(xored), nil
@@ -1,30 +1,151 @@
 package main
 
-import "unicode/utf8"
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alesforz/cryptopals/internal/parallel"
+)
+
+// _maxBinaryLikelihood is the largest binary likelihood (see
+// scoreBinaryLikelihood) of a candidate plain text that englishScore
+// considers text.
+const _maxBinaryLikelihood = 0.1
 
 // singleByteXOR attempts to decrypt a given ciphertext by XORing it against
 // each 255 1-byte keys. It then checks which resulting plaintext has character
 // frequencies closest to typical English text.
 func singleByteXOR(cipherText []byte) (string, byte) {
-	var (
-		bestScore float64
-		plainText []byte
-		key       byte
-	)
+	candidates := singleByteXORCandidates(cipherText, 1)
+	if len(candidates) == 0 || candidates[0].score <= 0 {
+		return "", 0
+	}
+	return string(candidates[0].plainText), candidates[0].key
+}
+
+// xorCandidate is a possible decryption of a cipher text encrypted with
+// single-byte XOR.
+type xorCandidate struct {
+	plainText []byte
+	key       byte
+	score     float64
+}
+
+// singleByteXORCandidates is like singleByteXOR, but it returns the n most
+// plausible decryptions, sorted by decreasing score (ties are sorted by key).
+// This is useful when the cipher text is so short that the best scoring
+// decryption may not be the right one.
+// Candidates are rated with englishScore, or with the scorer set by
+// withScorer, and then checked against a word list if set with
+// withWordCheck. The ones the scorer rejects are discarded, so it may return
+// less than n of them.
+func singleByteXORCandidates(
+	cipherText []byte,
+	n int,
+	opts ...attackOption,
+) []xorCandidate {
+
+	if len(cipherText) == 0 || n <= 0 {
+		return nil
+	}
+
+	options := newAttackOptions(opts)
 
 	const asciiBytes = 256
+	candidates := make([]xorCandidate, 0, asciiBytes)
 	for char := range asciiBytes {
 		decrypted := xorWithChar(cipherText, byte(char))
-		score := computeTextScore(decrypted)
 
-		if score > bestScore {
-			bestScore = score
-			plainText = decrypted
-			key = byte(char)
+		score := options.scorer(decrypted)
+		if math.IsInf(score, -1) {
+			continue
 		}
+
+		candidates = append(candidates, xorCandidate{
+			plainText: decrypted,
+			key:       byte(char),
+			score:     score,
+		})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b xorCandidate) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	if options.wordCheckMargin > 0 {
+		rankByWords(candidates, options.wordCheckMargin)
+	}
+
+	return candidates[:min(n, len(candidates))]
+}
+
+// rankByWords sorts the candidates whose score is within margin of the best
+// one by decreasing wordFraction. The candidates must be sorted by decreasing
+// score; the order of the others is left untouched.
+func rankByWords(candidates []xorCandidate, margin float64) {
+	if len(candidates) == 0 {
+		return
+	}
+
+	nClose := 1
+	for nClose < len(candidates) && candidates[0].score-candidates[nClose].score <= margin {
+		nClose++
 	}
 
-	return string(plainText), key
+	slices.SortStableFunc(candidates[:nClose], func(a, b xorCandidate) int {
+		return cmp.Compare(wordFraction(b.plainText), wordFraction(a.plainText))
+	})
+}
+
+// detectSingleByteXOR finds which of the given lines has been encrypted with
+// single-byte XOR, i.e., the one whose best decryption looks the most like
+// English text. It returns the line's index and its best decryption.
+// Lines are processed in parallel, one per available CPU unless set otherwise
+// with withParallelism. It also honors withScorer.
+// Challenge 4 of set 1.
+func detectSingleByteXOR(lines [][]byte, opts ...attackOption) (int, xorCandidate, error) {
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return 0, xorCandidate{}, err
+	}
+
+	bestCandidate := func(_ context.Context, i int) ([]xorCandidate, error) {
+		return singleByteXORCandidates(lines[i], 1, opts...), nil
+	}
+
+	type best struct {
+		line      int
+		candidate xorCandidate
+	}
+
+	// on a tie, the first line wins, so that the result doesn't depend on
+	// scheduling. The error can be ignored, as bestCandidate never fails.
+	b, _ := parallel.MapReduce(
+		context.Background(),
+		len(lines),
+		options.parallelism,
+		bestCandidate,
+		func(b best, i int, candidates []xorCandidate) best {
+			if len(candidates) == 0 {
+				return b
+			}
+			if b.line < 0 || candidates[0].score > b.candidate.score {
+				return best{line: i, candidate: candidates[0]}
+			}
+			return b
+		},
+		best{line: -1},
+	)
+
+	if b.line < 0 {
+		return 0, xorCandidate{}, errors.New("no line decrypts to text")
+	}
+
+	return b.line, b.candidate, nil
 }
 
 // xorWithChar XORs each byte of data with the provided character.
@@ -39,29 +160,36 @@ func xorWithChar(data []byte, char byte) []byte {
 // computeTextScore calculates and returns a score for the given text based on
 // how closely its character frequencies match typical English text. A higher
 // score indicates a closer match to valid English.
+// The text is decoded as UTF-8, so that a multi-byte character is scored once.
+// Control characters (other than whitespace) and invalid UTF-8 sequences are
+// penalized, as they are very unlikely in text.
 func computeTextScore(data []byte) float64 {
-	const uppercaseToLowercaseShift = 'a' - 'A'
 	var (
-		// we use [utf8.RuneCountInString] instead of len(text) because
-		// len(text) returns the number of *bytes*. However, recall that in
-		// UTF-8 some characters are encoded using 2 bytes, therefore len(text)
-		// could return a number which is higher than the actual number of
-		// characters in the text. In contrast [utf8.RuneCountInString] returns
-		// the exact number of *characters* in the text, which is what we want
-		// here.
-		nChars = float64(utf8.RuneCount(data))
+		nChars float64
 		score  float64
 	)
+	for len(data) > 0 {
+		nChars++
 
-	for _, b := range data {
-		if b >= 'A' && b <= 'Z' {
-			b += uppercaseToLowercaseShift
+		// ASCII characters, the vast majority, are scored by _asciiScores.
+		if c := data[0]; c < utf8.RuneSelf {
+			score += _asciiScores[c]
+			data = data[1:]
+			continue
 		}
 
-		if b >= 'a' && b <= 'z' {
-			score += _englishLetterFrequencies[b-'a']
-		} else if b == ' ' {
-			score += _spaceFrequency
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			score += _nonTextPenalty
+		case unicode.IsControl(r):
+			score += _nonTextPenalty
+		default:
+			// punctuation is scored by its own frequency; any other printable
+			// character (e.g., a non-ASCII letter) scores 0.
+			score += _punctuationFrequencies[r]
 		}
 	}
 
@@ -73,3 +201,26 @@ func computeTextScore(data []byte) float64 {
 	// per-character basis.
 	return score / nChars
 }
+
+// scoreBinaryLikelihood returns the fraction of the characters of data that
+// are unlikely to appear in text: control characters other than whitespace,
+// and bytes that are not valid UTF-8. It's 0 for clean text, and close to 1
+// for random or binary data, which makes it a cheap way to reject non-text
+// candidates before scoring them.
+func scoreBinaryLikelihood(data []byte) float64 {
+	var nChars, nBinary float64
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		nChars++
+
+		if (r == utf8.RuneError && size == 1) || (unicode.IsControl(r) && !unicode.IsSpace(r)) {
+			nBinary++
+		}
+	}
+
+	if nChars == 0 {
+		return 0
+	}
+	return nBinary / nChars
+}
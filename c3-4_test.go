@@ -1,22 +1,23 @@
 package main
 
 import (
-	"bufio"
-	"encoding/hex"
-	"fmt"
-	"os"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
 )
 
 func TestSingleByteXOR(t *testing.T) {
 	hexStr := "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
 
-	cipherText, err := decodeTestHex(t, hexStr)
-	if err != nil {
-		t.Fatalf("unexpected error: %s", err)
-	}
+	cipherText := testutil.MustDecodeHex(t, hexStr)
 
 	gotStr, gotKey := singleByteXOR(cipherText)
+	checkEnglish(t, []byte(gotStr), 0.6)
 
 	t.Logf("Key: %c", gotKey)
 	t.Logf("Decoded string: %s", gotStr)
@@ -25,50 +26,166 @@ func TestSingleByteXOR(t *testing.T) {
 
 // Challenge 4 of Set 1.
 func TestSingleByteXORFile(t *testing.T) {
-	f, err := os.Open("./files/1_4.txt")
+	lines := testutil.MustLoadHexLines(t, "./files/1_4.txt")
+
+	line, best, err := detectSingleByteXOR(lines)
 	if err != nil {
-		t.Fatalf("opening file: %s", err)
+		t.Fatalf("unexpected error: %s", err)
 	}
-	defer f.Close()
 
+	const want = "Now that the party is jumping\n"
+	if string(best.plainText) != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, best.plainText)
+	}
+
+	t.Logf("Line: %d", line)
+	t.Logf("Key: %c", best.key)
+	t.Logf("Decoded string: %s", best.plainText)
+}
+
+func BenchmarkDetectSingleByteXOR(b *testing.B) {
 	var (
-		s         = bufio.NewScanner(f)
-		bestScore float64
-		plainText string
-		key       byte
+		lines = testutil.MustLoadHexLines(b, "./files/1_4.txt")
+		// ~33k lines.
+		corpus = slices.Repeat(lines, 100)
 	)
-	for s.Scan() {
-		cipherText, err := decodeTestHex(t, s.Text())
-		if err != nil {
-			t.Fatalf("unexpected error: %s", err)
+	b.ResetTimer()
+
+	for range b.N {
+		if _, _, err := detectSingleByteXOR(corpus); err != nil {
+			b.Fatalf("unexpected error: %s", err)
 		}
+	}
+}
+
+func TestSingleByteXORCandidates(t *testing.T) {
+	hexStr := "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
 
-		gotStr, gotKey := singleByteXOR(cipherText)
+	cipherText := testutil.MustDecodeHex(t, hexStr)
 
-		score := computeTextScore([]byte(gotStr))
-		if score > bestScore {
-			bestScore = score
-			plainText = gotStr
-			key = gotKey
+	const n = 5
+	candidates := singleByteXORCandidates(cipherText, n)
+	if len(candidates) != n {
+		t.Fatalf("want %d candidates, got %d", n, len(candidates))
+	}
+
+	if candidates[0].key != 'X' {
+		t.Errorf("best candidate has key %q, want 'X'", candidates[0].key)
+	}
+	for i := 1; i < n; i++ {
+		if candidates[i].score > candidates[i-1].score {
+			t.Errorf("candidates are not sorted by score: %f > %f", candidates[i].score, candidates[i-1].score)
 		}
 	}
 
-	if err := s.Err(); err != nil {
-		t.Fatalf("parsing file: %s", err)
+	if got := singleByteXORCandidates(nil, n); got != nil {
+		t.Errorf("want no candidates for an empty cipher text, got %d", len(got))
+	}
+}
+
+func TestComputeTextScore(t *testing.T) {
+	const text = "Now that the party is jumping"
+
+	var (
+		textScore   = computeTextScore([]byte(text))
+		binaryScore = computeTextScore([]byte("\x00\x01\x02\xff\xfe jumping"))
+	)
+	if textScore <= binaryScore {
+		t.Errorf("text scored %f, not higher than binary data's %f", textScore, binaryScore)
 	}
 
-	t.Logf("Key: %c", key)
-	t.Logf("Decoded string: %s", plainText)
+	// 'é' is 2 bytes long in UTF-8, but it must be counted as 1 character
+	// (scoring 0), not as 2 invalid bytes.
+	var (
+		withAccent    = computeTextScore([]byte("café")) * 4
+		withoutAccent = computeTextScore([]byte("caf")) * 3
+	)
+	if math.Abs(withAccent-withoutAccent) > 1e-9 {
+		t.Errorf("\nwant:\t%f\ngot:\t%f\n", withoutAccent, withAccent)
+	}
 }
 
-// decodeTestHex attempts to decode the provided hex string into a byte slice.
-func decodeTestHex(t *testing.T, hexStr string) ([]byte, error) {
-	t.Helper()
+func TestComputeTextScoreMatchesReference(t *testing.T) {
+	inputs := [][]byte{
+		[]byte("Now that the party is jumping\n"),
+		[]byte("café, naïve: \"déjà vu\"!\x00\x7f\xff"),
+		[]byte("\u0085\u2014\u00a0"),
+	}
+	rng := rand.NewChaCha8([32]byte{})
+	for range 100 {
+		in := make([]byte, 64)
+		rng.Read(in)
+		inputs = append(inputs, in)
+	}
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	inputs = append(inputs, all)
 
-	decodedHex, err := hex.DecodeString(hexStr)
-	if err != nil {
-		return nil, fmt.Errorf("malformed hex string '%s': %s", hexStr, err)
+	for _, in := range inputs {
+		if want, got := referenceTextScore(in), computeTextScore(in); want != got {
+			t.Errorf("%q\nwant:\t%v\ngot:\t%v\n", in, want, got)
+		}
 	}
+}
 
-	return decodedHex, nil
+// referenceTextScore is how computeTextScore scores text, without the
+// _asciiScores lookup table.
+func referenceTextScore(data []byte) float64 {
+	var nChars, score float64
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		nChars++
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			score += _nonTextPenalty
+		case r >= 'a' && r <= 'z':
+			score += _englishLetterFrequencies[r-'a']
+		case r >= 'A' && r <= 'Z':
+			score += _englishLetterFrequencies[r-'A']
+		case r == ' ':
+			score += _spaceFrequency
+		case r >= '0' && r <= '9':
+			score += _digitFrequency
+		case r == '\n' || r == '\r' || r == '\t':
+			score += _whitespaceFrequency
+		case unicode.IsControl(r):
+			score += _nonTextPenalty
+		default:
+			score += _punctuationFrequencies[r]
+		}
+	}
+
+	return score / nChars
+}
+
+func TestScoreBinaryLikelihood(t *testing.T) {
+	tests := []struct {
+		data string
+		want float64
+	}{
+		{data: "", want: 0},
+		{data: "plain text\n", want: 0},
+		{data: "café", want: 0},
+		{data: "\x00\x01\x02\x03", want: 1},
+		{data: "ab\xff\x00", want: 0.5},
+	}
+
+	for _, tt := range tests {
+		if got := scoreBinaryLikelihood([]byte(tt.data)); got != tt.want {
+			t.Errorf("%q:\nwant:\t%f\ngot:\t%f\n", tt.data, tt.want, got)
+		}
+	}
+}
+
+func BenchmarkComputeTextScore(b *testing.B) {
+	text := []byte("Now that the party is jumping, and the bass kicks in: 1, 2, 3!\n")
+	b.SetBytes(int64(len(text)))
+
+	for range b.N {
+		computeTextScore(text)
+	}
 }
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/bits"
-	"sync"
 
-	"golang.org/x/sync/errgroup"
+	"github.com/alesforz/cryptopals/internal/parallel"
 )
 
 // breakRepeatingKeyXOR:
@@ -16,17 +16,33 @@ import (
 // 3. Recovers the decryption key with frequency analysis on each transposed
 // block to determine the key's byte used to encrypt that particular block.
 // 4. Decrypts the cipher text
+// The frequency analysis assumes the plain text is English text, unless a
+// different scorer is set with withScorer (e.g., binaryScore).
+// It honors withExplain, withScorer and withParallelism.
 // Returns the decrypted text, the key used to encrypt/decrypt it, and an error
 // (if any).
 func breakRepeatingKeyXOR(
 	cipherText []byte,
 	maxKeySize int,
+	opts ...attackOption,
 ) (string, string, error) {
 
-	keySize, err := estimateKeySize(cipherText, maxKeySize)
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return "", "", err
+	}
+
+	distances, err := keySizeDistances(cipherText, maxKeySize, options.parallelism)
 	if err != nil {
-		return "", "", fmt.Errorf("breaking repeating key XOR: %s", err)
+		return "", "", fmt.Errorf("breaking repeating key XOR: %w", err)
+	}
+	if len(distances) == 0 {
+		const formatStr = "breaking repeating key XOR: no key size of at most %d fits twice in %d bytes of cipher text"
+		return "", "", fmt.Errorf(formatStr, maxKeySize, len(cipherText))
 	}
+	keySize := bestKeySize(distances)
+	plotKeySizeDistances(options.explain, distances, keySize)
+	explainf(options.explain, "estimated key size: %d", keySize)
 
 	var (
 		cipherTextLen = len(cipherText)
@@ -38,8 +54,22 @@ func breakRepeatingKeyXOR(
 		// number of blocks, thus giving us the correct number of blocks even
 		// if there's a remainder.
 		nBlocks = (cipherTextLen + keySize - 1) / keySize
+
+		// the bytes in the last, incomplete block. The rows of the first
+		// lastBlockLen key bytes are nBlocks bytes long, the others are one
+		// byte shorter.
+		lastBlockLen = cipherTextLen % keySize
 	)
 
+	// rowStart returns where the row of the given key byte starts in the
+	// transposed cipher text: after the rows of the key bytes before it.
+	rowStart := func(row int) int {
+		if lastBlockLen == 0 || row <= lastBlockLen {
+			return row * nBlocks
+		}
+		return row*nBlocks - (row - lastBlockLen)
+	}
+
 	// Loop through all indices of the input cipherText.
 	// Now that we have an estimation of the key's size, we break the
 	// ciphertext into blocks of keySize length and transpose them.
@@ -72,29 +102,9 @@ func breakRepeatingKeyXOR(
 			// That is, this is the index of this byte in the transposed matrix
 			// where each row represents a position in the key, and each column
 			// represents a sequential block of key-sized length.
-			transposedIndex = byteIdx*nBlocks + blockIdx
+			transposedIndex = rowStart(byteIdx) + blockIdx
 		)
 
-		// Handle the case where we would be out-of-bounds due to an incomplete
-		// last block. We need to adjust the transposedIndex to ensure we don't
-		// go out of range.
-		if transposedIndex >= cipherTextLen {
-			var (
-				// the current row in the transposed blocks.
-				currRow = byteIdx + 1
-
-				// how many bytes are missing in the last, incomplete block.
-				missingBytesInLastBlock = keySize - cipherTextLen%keySize
-			)
-
-			// By multiplying these two, we calculate the total number of
-			// "missing" positions up to the current row.
-			// Subtracting this from transposedIndex adjusts our index to
-			// account for the absence of these positions in the transposed
-			// blocks.
-			transposedIndex -= currRow * missingBytesInLastBlock
-		}
-
 		transposed[transposedIndex] = char
 	}
 
@@ -109,22 +119,21 @@ func breakRepeatingKeyXOR(
 		// corresponds to the k-th byte of the key.
 		// That is, this block contains all the bytes that were XORed with the
 		// same byte of the key during encryption.
-		blockStart := k * nBlocks
-
-		// remember that this is the transposed matrix, therefore each row has
-		// nBlocks columns.
-		blockEnd := blockStart + nBlocks
+		var (
+			blockStart = rowStart(k)
+			blockEnd   = rowStart(k + 1)
+			block      = transposed[blockStart:blockEnd]
+		)
 
-		// Ensure we don't go beyond the end of the transposed slice, which can
-		// happen if the last block is not full.
-		if blockEnd > len(transposed) {
-			blockEnd = len(transposed)
+		var blockKey byte
+		if candidates := singleByteXORCandidates(block, 1, opts...); len(candidates) > 0 {
+			blockKey = candidates[0].key
 		}
 
-		block := transposed[blockStart:blockEnd]
-		_, blockKey := singleByteXOR(block)
-
 		decryptionKey[k] = blockKey
+
+		const formatStr = "transposed block %d [%d:%d] starts with %q: encrypted with key byte %q"
+		explainf(options.explain, formatStr, k, blockStart, blockEnd, block[:min(len(block), 16)], blockKey)
 	}
 
 	plainText := repeatingKeyXOR(cipherText, decryptionKey)
@@ -132,83 +141,100 @@ func breakRepeatingKeyXOR(
 	return string(plainText), string(decryptionKey), nil
 }
 
-// estimateKeySize tries to deduce the most probable key size for a given
-// ciphertext.
-// It computes the normalized Hamming distances between blocks of bytes of the
-// ciphertext. The key size producing the smaller Hamming distance between
-// blocks is the most likely key size used to encrypt the ciphertext.
-// This function takes in a ciphertext and a maximum key size to consider.
-// It returns the guessed key size and any potential error encountered.
-func estimateKeySize(cipherText []byte, maxKeySize int) (int, error) {
-	var (
-		cipherTextLen = len(cipherText)
-		minEditDist   = math.MaxFloat64
-		keySizeGuess  int
-		errG          errgroup.Group
-		mu            sync.Mutex
-	)
+// keySizeDistance is the normalized Hamming distance between the blocks of a
+// cipher text split with a candidate key size.
+type keySizeDistance struct {
+	keySize  int
+	distance float64
+}
 
-	// the loop condition size*2 < cipherTextLen is there to ensure we can
-	// have at least two blocks of cipher-text to compare using the Hamming
-	// distance.
-	for size := 2; size <= maxKeySize && size*2 < cipherTextLen; size++ {
-
-		k := size
-		errG.Go(func() error {
-
-			// Calculate the number of pairs of blocks we can compare for this
-			// key size.
-			nPairs := cipherTextLen / (2 * k)
-
-			var totEditDist int
-			for pair := range nPairs {
-				var (
-					// blockA's start index is calculated as pair*2*k.
-					// Each pair covers 2*k bytes in the ciphertext.
-					// So, for the n-th pair, blockA starts at 2*k and occupies
-					// the first k bytes.
-					// For example, for the first pair (pair=0), blockA covers
-					// bytes from position 0 to k-1.
-					blockA = cipherText[pair*2*k : (pair*2+1)*k]
-
-					// blockB's start index is (pair*2+1)*k, which is
-					// immediately after blockA's end index.
-					// It covers the next k bytes in the ciphertext.
-					// So, for the first pair, this would be from position k to
-					// 2k-1.
-					blockB = cipherText[(pair*2+1)*k : (pair*2+2)*k]
-				)
-				editDist, err := hammingDistance(blockA, blockB)
-				if err != nil {
-					return fmt.Errorf("key length %d: %s", k, err)
-				}
-
-				totEditDist += editDist
-			}
+// keySizeDistances tries to deduce the most probable key size for a given
+// ciphertext: it computes the normalized Hamming distances between blocks of
+// bytes of the ciphertext split with every candidate key size, from the
+// smallest to maxKeySize, evaluating parallelism of them concurrently. The key
+// size producing the smaller Hamming distance between blocks (see bestKeySize)
+// is the most likely key size used to encrypt the ciphertext.
+// Looking at the whole table shows why a key size was chosen (see
+// plotKeySizeDistances), or why none stands out, e.g., because the cipher
+// text is too short.
+func keySizeDistances(cipherText []byte, maxKeySize, parallelism int) ([]keySizeDistance, error) {
+	const minKeySize = 2
+
+	// we need at least two blocks of cipher-text to compare using the
+	// Hamming distance.
+	cipherTextLen := len(cipherText)
+	maxKeySize = min(maxKeySize, (cipherTextLen-1)/2)
+	if maxKeySize < minKeySize {
+		return nil, nil
+	}
 
+	editDistance := func(_ context.Context, i int) (keySizeDistance, error) {
+		k := minKeySize + i
+
+		// Calculate the number of pairs of blocks we can compare for this
+		// key size.
+		nPairs := cipherTextLen / (2 * k)
+
+		var totEditDist int
+		for pair := range nPairs {
 			var (
-				avgEditDist        = float64(totEditDist) / float64(nPairs)
-				normalizedEditDist = avgEditDist / float64(k)
+				// blockA's start index is calculated as pair*2*k.
+				// Each pair covers 2*k bytes in the ciphertext.
+				// So, for the n-th pair, blockA starts at 2*k and occupies
+				// the first k bytes.
+				// For example, for the first pair (pair=0), blockA covers
+				// bytes from position 0 to k-1.
+				blockA = cipherText[pair*2*k : (pair*2+1)*k]
+
+				// blockB's start index is (pair*2+1)*k, which is
+				// immediately after blockA's end index.
+				// It covers the next k bytes in the ciphertext.
+				// So, for the first pair, this would be from position k to
+				// 2k-1.
+				blockB = cipherText[(pair*2+1)*k : (pair*2+2)*k]
 			)
-
-			mu.Lock()
-			if normalizedEditDist < minEditDist {
-				minEditDist = normalizedEditDist
-				keySizeGuess = k
+			editDist, err := hammingDistance(blockA, blockB)
+			if err != nil {
+				return keySizeDistance{}, fmt.Errorf("key length %d: %w", k, err)
 			}
-			mu.Unlock()
 
-			// log.Println("key size:", k, "edit distance:", normalizedEditDist)
+			totEditDist += editDist
+		}
+
+		avgEditDist := float64(totEditDist) / float64(nPairs)
+		return keySizeDistance{keySize: k, distance: avgEditDist / float64(k)}, nil
+	}
 
-			return nil
-		})
+	distances, err := parallel.Map(
+		context.Background(),
+		maxKeySize-minKeySize+1,
+		parallelism,
+		editDistance,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("estimating key length: %w", err)
 	}
 
-	if err := errG.Wait(); err != nil {
-		return 0, fmt.Errorf("estimating key length: %s", err)
+	return distances, nil
+}
+
+// bestKeySize returns the key size with the smallest distance, or 0 if there
+// are none.
+// On a tie, the smaller key size wins: a multiple of the key size is just as
+// good, but it's not the key size.
+func bestKeySize(distances []keySizeDistance) int {
+	var (
+		best        int
+		minEditDist = math.MaxFloat64
+	)
+	for _, d := range distances {
+		if d.distance < minEditDist {
+			minEditDist = d.distance
+			best = d.keySize
+		}
 	}
 
-	return keySizeGuess, nil
+	return best
 }
 
 // hammingDistance computes the Hamming distance between two byte slices.
@@ -216,7 +242,7 @@ func estimateKeySize(cipherText []byte, maxKeySize int) (int, error) {
 // representations.
 func hammingDistance(a, b []byte) (int, error) {
 	if len(a) != len(b) {
-		return 0, fmt.Errorf("byte slices are of different lengths")
+		return 0, fmt.Errorf("%w: byte slices are %d and %d bytes long", errLengthMismatch, len(a), len(b))
 	}
 
 	var distance int
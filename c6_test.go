@@ -1,37 +1,62 @@
 package main
 
 import (
-	"encoding/base64"
-	"io"
-	"os"
+	"bytes"
 	"slices"
+	"strings"
 	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
 )
 
 func TestBreakRepeatingKeyXOR(t *testing.T) {
-	f, err := os.Open("./files/1_6.txt")
-	if err != nil {
-		t.Fatalf("opening file: %s", err)
-	}
-	defer f.Close()
-
-	decoder := base64.NewDecoder(base64.StdEncoding, f)
-	cipherText, err := io.ReadAll(decoder)
-	if err != nil {
-		t.Fatalf("reading file: %s", err)
-	}
+	cipherText := testutil.MustLoadBase64(t, "./files/1_6.txt")
 
 	var maxKeySize = 40
 	plainText, key, err := breakRepeatingKeyXOR(cipherText, maxKeySize)
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err)
 	}
+	checkEnglish(t, []byte(plainText), 0.6)
 
 	t.Logf("Key: %s", key)
 	t.Logf("Key Size: %d", len(key))
 	t.Logf("Plain-text:\n%s", plainText)
 }
 
+func TestBreakRepeatingKeyXORIncompleteBlock(t *testing.T) {
+	// the last block is 3 bytes long: the rows of the first 3 key bytes of
+	// the transposed cipher text are one byte longer than the others.
+	var (
+		plainText  = strings.Repeat("I go crazy when I hear a cymbal and a hi-hat. ", 10) + "Yo, y"
+		key        = "Vanilla"
+		cipherText = repeatingKeyXOR([]byte(plainText), []byte(key))
+	)
+	if rem := len(cipherText) % len(key); rem != 3 {
+		t.Fatalf("want a 3-byte last block, got %d bytes", rem)
+	}
+
+	var explanation bytes.Buffer
+	gotPlainText, gotKey, err := breakRepeatingKeyXOR(cipherText, 10, withExplain(&explanation))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// 465 bytes: the rows of the first 3 key bytes are 67 bytes long, the
+	// others 66.
+	for _, want := range []string{"transposed block 2 [134:201]", "transposed block 3 [201:267]", "transposed block 6 [399:465]"} {
+		if !strings.Contains(explanation.String(), want) {
+			t.Errorf("explanation does not contain %q:\n%s", want, explanation.String())
+		}
+	}
+	if gotKey != key {
+		t.Errorf("want key %q, got %q", key, gotKey)
+	}
+	if gotPlainText != plainText {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, gotPlainText)
+	}
+}
+
 func TestHammingDistance(t *testing.T) {
 	var (
 		inputText1   = "this is a test"
@@ -83,3 +108,64 @@ func TestTranspose(t *testing.T) {
 		t.Errorf("want: %c, but got %c", want, transposed)
 	}
 }
+
+func BenchmarkBreakRepeatingKeyXOR(b *testing.B) {
+	cipherText := testutil.MustLoadBase64(b, "./files/1_6.txt")
+	b.ResetTimer()
+
+	for range b.N {
+		if _, _, err := breakRepeatingKeyXOR(cipherText, 40); err != nil {
+			b.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+func TestKeySizeDistances(t *testing.T) {
+	cipherText := testutil.MustLoadBase64(t, "./files/1_6.txt")
+
+	distances, err := keySizeDistances(cipherText, 40, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(distances) != 39 {
+		t.Fatalf("want 39 key sizes, got %d", len(distances))
+	}
+	for i, d := range distances {
+		if d.keySize != i+2 {
+			t.Fatalf("distance %d is for key size %d, want %d", i, d.keySize, i+2)
+		}
+	}
+	if got := bestKeySize(distances); got != 29 {
+		t.Errorf("want key size 29, got %d", got)
+	}
+
+	// too short to compare two blocks of any key size.
+	distances, err = keySizeDistances(cipherText[:4], 40, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(distances) != 0 || bestKeySize(distances) != 0 {
+		t.Errorf("want no key sizes, got %v", distances)
+	}
+}
+
+func TestBreakRepeatingKeyXORNoKeySize(t *testing.T) {
+	for _, tt := range []struct {
+		cipherText []byte
+		maxKeySize int
+	}{
+		{[]byte("hey"), 40},
+		{[]byte("hello world, this is long enough"), 1},
+	} {
+		if _, _, err := breakRepeatingKeyXOR(tt.cipherText, tt.maxKeySize); err == nil {
+			t.Errorf("%d bytes, max key size %d: want error, got nil", len(tt.cipherText), tt.maxKeySize)
+		}
+	}
+}
+
+func TestBestKeySizeTie(t *testing.T) {
+	distances := []keySizeDistance{{2, 3}, {3, 1.5}, {6, 1.5}}
+	if got := bestKeySize(distances); got != 3 {
+		t.Errorf("want key size 3, got %d", got)
+	}
+}
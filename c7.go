@@ -7,10 +7,14 @@ import (
 
 // decryptAesEcb decrypts a cipher text encrypted using AES-128 in ECB mode with
 // the given key.
-func decryptAesEcb(cipherText, key []byte) ([]byte, error) {
+func decryptAesEcb(cipherText, key []byte, opts ...cipherOption) ([]byte, error) {
+	if newCipherOptions(opts).stdlib {
+		return decryptAesEcbStdlib(cipherText, key)
+	}
+
 	if len(cipherText)%len(key) != 0 {
-		const formatStr = "cipher text's length (%d) is not a multiple of the decryption key's length (%d)"
-		return nil, fmt.Errorf(formatStr, len(cipherText), len(key))
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the decryption key's length (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), len(key))
 	}
 
 	decrypter, err := aesDecrypter(key)
@@ -18,17 +22,9 @@ func decryptAesEcb(cipherText, key []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	var (
-		blockSize = len(key)
-		nBlocks   = (len(cipherText) + blockSize - 1) / blockSize
-		plainText = make([]byte, 0, len(cipherText))
-	)
-	for b := range nBlocks {
-		var (
-			start = b * blockSize
-			end   = start + blockSize
-		)
-		plainText = append(plainText, decrypter(cipherText[start:end])...)
+	plainText := make([]byte, 0, len(cipherText))
+	for block := range chunks(cipherText, len(key)) {
+		plainText = append(plainText, decrypter(block)...)
 	}
 
 	return plainText, nil
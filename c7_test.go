@@ -1,30 +1,19 @@
 package main
 
 import (
-	"encoding/base64"
-	"io"
-	"os"
 	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
 )
 
 func TestDecryptAesEcb(t *testing.T) {
-	f, err := os.Open("./files/1_7.txt")
-	if err != nil {
-		t.Fatalf("opening file: %s", err)
-	}
-	defer f.Close()
-
-	decoder := base64.NewDecoder(base64.StdEncoding, f)
-	cipherText, err := io.ReadAll(decoder)
-	if err != nil {
-		t.Fatalf("reading file: %s", err)
-	}
+	cipherText := testutil.MustLoadBase64(t, "./files/1_7.txt")
 
 	const key = "YELLOW SUBMARINE"
 	plainText, err := decryptAesEcb(cipherText, []byte(key))
 	if err != nil {
-		t.Error(err)
+		t.Fatalf("unexpected error: %s", err)
 	}
 
-	t.Log(string(plainText))
+	testutil.Golden(t, "./files/1_7.golden", delPadPkcs7(plainText))
 }
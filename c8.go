@@ -1,6 +1,15 @@
 package main
 
-import "crypto/aes"
+import (
+	"cmp"
+	"context"
+	"crypto/aes"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/alesforz/cryptopals/internal/parallel"
+)
 
 // isEncryptedAesEcb returns true if the given cipherText was encrypted using
 // AES ECB. It leverages the fact that ECB is stateless and deterministic; the
@@ -16,18 +25,11 @@ func isEncryptedAesEcb(cipherText []byte) bool {
 
 	type block [blockSize]byte
 
-	var (
-		nBlocks = (cipherTextLen + blockSize - 1) / blockSize
-		set     = make(map[block]struct{}, nBlocks)
-	)
-	for b := range nBlocks {
-		var (
-			start = b * blockSize
-			end   = start + blockSize
+	set := make(map[block]struct{}, cipherTextLen/blockSize)
+	for chunk := range chunks(cipherText, blockSize) {
+		// get the slice's underlying array
+		currBlock := (block)(chunk)
 
-			// get the slice's underlying array
-			currBlock = (block)(cipherText[start:end])
-		)
 		if _, ok := set[currBlock]; ok {
 			return true
 		}
@@ -35,3 +37,147 @@ func isEncryptedAesEcb(cipherText []byte) bool {
 	}
 	return false
 }
+
+// ecbScore measures how strongly a cipher text looks like the output of AES
+// ECB.
+type ecbScore struct {
+	// duplicates is the number of blocks equal to a block that comes before
+	// them in the cipher text.
+	duplicates int
+
+	// blocks is the total number of blocks of the cipher text.
+	blocks int
+
+	// falsePositive is the probability that a cipher text of the same length
+	// produced by a mode without repetitions (i.e., made of uniformly random
+	// blocks) had at least as many duplicates. The smaller, the more
+	// confident we are that the cipher text was encrypted with ECB.
+	falsePositive float64
+}
+
+// detectAesEcbScore is like isEncryptedAesEcb, but instead of a yes/no answer
+// it returns how many blocks repeat, and how likely that is by chance.
+// By the birthday bound, n random 16 byte blocks contain about
+// n(n-1)/2 / 2^128 pairs of equal blocks, so even one duplicate is a very
+// strong hint of ECB, unless the cipher text is astronomically long.
+// Conversely, no duplicates doesn't rule ECB out: the plain text may simply
+// have no repeated blocks.
+func detectAesEcbScore(cipherText []byte) (ecbScore, error) {
+	const blockSize = aes.BlockSize
+
+	if len(cipherText)%blockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the block size (%d)"
+		return ecbScore{}, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), blockSize)
+	}
+
+	type block [blockSize]byte
+
+	var (
+		nBlocks = len(cipherText) / blockSize
+		seen    = make(map[block]struct{}, nBlocks)
+		score   = ecbScore{blocks: nBlocks}
+	)
+	for chunk := range chunks(cipherText, blockSize) {
+		b := (block)(chunk)
+		if _, ok := seen[b]; ok {
+			score.duplicates++
+			continue
+		}
+		seen[b] = struct{}{}
+	}
+
+	// the number of equal pairs among n random blocks is approximately a
+	// Poisson variable with mean n(n-1)/2 / 2^(8*blockSize). Every duplicate
+	// adds at least one equal pair, so the probability of seeing at least as
+	// many pairs as duplicates is an upper bound of the false positive rate.
+	var (
+		n    = float64(nBlocks)
+		mean = n * (n - 1) / 2 / math.Pow(2, 8*blockSize)
+	)
+	score.falsePositive = poissonTail(mean, score.duplicates)
+
+	return score, nil
+}
+
+// poissonTail returns the probability that a Poisson variable with the given
+// mean is >= k. It sums the probabilities of the tail directly rather than
+// computing 1 - P(X < k), which would round to 0 for tiny means.
+func poissonTail(mean float64, k int) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if mean == 0 {
+		return 0
+	}
+
+	var (
+		logMean = math.Log(mean)
+		tail    float64
+	)
+	for i := k; i < k+1000; i++ {
+		lgamma, _ := math.Lgamma(float64(i + 1))
+		p := math.Exp(-mean + float64(i)*logMean - lgamma)
+		tail += p
+		// past the mode (the mean), the terms only get smaller: once one
+		// underflows to 0, so do all the next ones.
+		if p < tail*1e-17 || (p == 0 && float64(i) >= mean) {
+			break
+		}
+	}
+
+	return min(tail, 1)
+}
+
+// ecbCandidate is a cipher text that looks like it was encrypted with AES
+// ECB.
+type ecbCandidate struct {
+	// line is the index of the cipher text among the ones scanned.
+	line  int
+	score ecbScore
+}
+
+// findAesEcbCipherTexts scores every cipher text with detectAesEcbScore, and
+// returns the ones with duplicate blocks, from the most to the least likely to
+// be encrypted with ECB. Cipher texts that aren't block aligned are skipped,
+// as they can't be the output of AES ECB.
+// The cipher texts are scored in parallel, with at most parallelism
+// goroutines (GOMAXPROCS if <= 0).
+// Challenge 8 of set 1, at scale.
+func findAesEcbCipherTexts(cipherTexts [][]byte, parallelism int) []ecbCandidate {
+	// the error can be ignored, as the scoring function never fails.
+	scores, _ := parallel.Map(
+		context.Background(),
+		len(cipherTexts),
+		parallelism,
+		func(_ context.Context, i int) (ecbScore, error) {
+			score, err := detectAesEcbScore(cipherTexts[i])
+			if err != nil {
+				return ecbScore{}, nil
+			}
+			return score, nil
+		},
+	)
+
+	var candidates []ecbCandidate
+	for i, score := range scores {
+		if score.duplicates > 0 {
+			candidates = append(candidates, ecbCandidate{line: i, score: score})
+		}
+	}
+	rankEcbCandidates(candidates)
+
+	return candidates
+}
+
+// rankEcbCandidates sorts the candidates from the most to the least likely to
+// be encrypted with ECB: by false positive probability, then by number of
+// duplicates, then by line.
+func rankEcbCandidates(candidates []ecbCandidate) {
+	slices.SortFunc(candidates, func(a, b ecbCandidate) int {
+		return cmp.Or(
+			cmp.Compare(a.score.falsePositive, b.score.falsePositive),
+			cmp.Compare(b.score.duplicates, a.score.duplicates),
+			cmp.Compare(a.line, b.line),
+		)
+	})
+}
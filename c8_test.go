@@ -1,38 +1,99 @@
 package main
 
 import (
-	"bufio"
-	"encoding/hex"
-	"os"
+	"errors"
+	"math"
 	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
 )
 
 func TestDetectAesEcb(t *testing.T) {
-	f, err := os.Open("./files/1_8.txt")
-	if err != nil {
-		t.Fatalf("opening file: %s", err)
+	for i, cipherText := range testutil.MustLoadHexLines(t, "./files/1_8.txt") {
+		if isEncryptedAesEcb(cipherText) {
+			t.Logf("cipher text %d is encrypted using AES ECB", i+1)
+			break
+		}
 	}
-	defer f.Close()
+}
 
+func TestDetectAesEcbScore(t *testing.T) {
 	var (
-		s     = bufio.NewScanner(f)
-		count int
+		lines   = testutil.MustLoadHexLines(t, "./files/1_8.txt")
+		ecbLine = -1
 	)
-	for s.Scan() {
-		count++
+	for i, cipherText := range lines {
+		score, err := detectAesEcbScore(cipherText)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if score.blocks != len(cipherText)/16 {
+			t.Errorf("line %d: want %d blocks, got %d", i, len(cipherText)/16, score.blocks)
+		}
 
-		cipherText := s.Text()
+		if score.duplicates == 0 {
+			if score.falsePositive != 1 {
+				t.Errorf("line %d: no duplicates, but false positive probability %g", i, score.falsePositive)
+			}
+			continue
+		}
 
-		decoded, err := hex.DecodeString(cipherText)
-		if err != nil {
-			t.Errorf("decoding cipher text %d: %x from hex", count, cipherText)
+		if ecbLine >= 0 {
+			t.Errorf("both lines %d and %d have duplicate blocks", ecbLine, i)
 		}
-		if isEncryptedAesEcb(decoded) {
-			t.Logf("cipher text %d is encrypted using AES ECB", count)
-			break
+		ecbLine = i
+
+		if score.falsePositive <= 0 || score.falsePositive > 1e-30 {
+			t.Errorf("line %d: implausible false positive probability %g", i, score.falsePositive)
 		}
 	}
-	if err := s.Err(); err != nil {
-		t.Errorf("reading input file: %s", err)
+
+	// challenge 8's answer.
+	if ecbLine != 132 {
+		t.Errorf("want line 132 to be encrypted with ECB, got %d", ecbLine)
+	}
+
+	if _, err := detectAesEcbScore(make([]byte, 17)); !errors.Is(err, errNotBlockAligned) {
+		t.Errorf("want %v, got %v", errNotBlockAligned, err)
+	}
+}
+
+func TestPoissonTail(t *testing.T) {
+	if got := poissonTail(1, 1); math.Abs(got-(1-math.Exp(-1))) > 1e-12 {
+		t.Errorf("want %g, got %g", 1-math.Exp(-1), got)
+	}
+	if got := poissonTail(1e-40, 1); math.Abs(got-1e-40)/1e-40 > 1e-9 {
+		t.Errorf("want ~1e-40, got %g", got)
+	}
+
+	// many duplicates: every term underflows, and the sum stops at the
+	// first one.
+	if got := poissonTail(1e-70, 50); got != 0 {
+		t.Errorf("want 0, got %g", got)
+	}
+	// the first terms underflow too, but the ones around the mean don't.
+	if got := poissonTail(800, 1); math.Abs(got-1) > 1e-9 {
+		t.Errorf("want ~1, got %g", got)
+	}
+}
+
+func BenchmarkPoissonTailUnderflow(b *testing.B) {
+	for range b.N {
+		poissonTail(1e-70, 50)
+	}
+}
+
+func TestFindAesEcbCipherTexts(t *testing.T) {
+	lines := testutil.MustLoadHexLines(t, "./files/1_8.txt")
+
+	// a second ECB line with fewer duplicates, and a misaligned one.
+	lines = append(lines, append(make([]byte, 32), lines[0][:32]...), make([]byte, 17))
+
+	candidates := findAesEcbCipherTexts(lines, 4)
+	if len(candidates) != 2 {
+		t.Fatalf("want 2 candidates, got %d", len(candidates))
+	}
+	if candidates[0].line != 132 || candidates[1].line != len(lines)-2 {
+		t.Errorf("want lines 132 and %d, got %d and %d", len(lines)-2, candidates[0].line, candidates[1].line)
 	}
 }
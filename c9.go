@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 // padPkcs7 pads the given data to a multiple of size by appending the number of
 // bytes of padding to the end of the it.
 // For instance, "YELLOW SUBMARINE" (16 bytes) padded to 20 bytes would be:
@@ -25,3 +27,52 @@ func padPkcs7(data []byte, size int) []byte {
 
 	return padded
 }
+
+// unpadPkcs7 validates and removes the PKCS#7 padding of data, and returns the
+// unpadded data and the length of the padding.
+// Unlike delPadPkcs7, which trusts its input, it returns errInvalidPadding if
+// data is empty, if the padding length is 0, longer than data, or larger than
+// blockSize, or if any of the padding bytes differs from the padding length.
+// If blockSize is positive, data must also be a multiple of it; otherwise the
+// padding can be up to 255 bytes long.
+// Challenge 15 of set 2.
+func unpadPkcs7(data []byte, blockSize int) ([]byte, int, error) {
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("%w: empty data", errInvalidPadding)
+	}
+	if blockSize > 0 && len(data)%blockSize != 0 {
+		const formatStr = "%w: data length (%d) is not a multiple of the block size (%d)"
+		return nil, 0, fmt.Errorf(formatStr, errNotBlockAligned, len(data), blockSize)
+	}
+
+	maxPad := 255
+	if blockSize > 0 {
+		maxPad = min(blockSize, maxPad)
+	}
+
+	pad := int(data[len(data)-1])
+	if pad == 0 || pad > maxPad || pad > len(data) {
+		return nil, 0, fmt.Errorf("%w: padding length %d", errInvalidPadding, pad)
+	}
+
+	for _, b := range data[len(data)-pad:] {
+		if int(b) != pad {
+			const formatStr = "%w: padding byte %#x, want %#x"
+			return nil, 0, fmt.Errorf(formatStr, errInvalidPadding, b, pad)
+		}
+	}
+
+	return data[:len(data)-pad], pad, nil
+}
+
+// unpadPkcs7Exact is like unpadPkcs7, but the block size is mandatory. Use it
+// on the output of a block cipher decryption (e.g., decryptAesCbc), which is
+// always block aligned.
+func unpadPkcs7Exact(data []byte, blockSize int) ([]byte, error) {
+	if blockSize <= 0 || blockSize > 255 {
+		return nil, fmt.Errorf("invalid block size %d", blockSize)
+	}
+
+	unpadded, _, err := unpadPkcs7(data, blockSize)
+	return unpadded, err
+}
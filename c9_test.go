@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestPadPkcs7(t *testing.T) {
 	data := []byte("YELLOW SUBMARINE")
@@ -13,3 +16,58 @@ func TestPadPkcs7(t *testing.T) {
 		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, string(padded))
 	}
 }
+
+func TestUnpadPkcs7(t *testing.T) {
+	unpadded, pad, err := unpadPkcs7([]byte("ICE ICE BABY\x04\x04\x04\x04"), 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "ICE ICE BABY"
+	if string(unpadded) != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, unpadded)
+	}
+	if pad != 4 {
+		t.Errorf("want padding length 4, got %d", pad)
+	}
+
+	// a whole block of padding.
+	fullBlock := []byte("YELLOW SUBMARINE\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10")
+	unpadded, pad, err = unpadPkcs7(fullBlock, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(unpadded) != "YELLOW SUBMARINE" || pad != 16 {
+		t.Errorf("want %q and padding length 16, got %q and %d", "YELLOW SUBMARINE", unpadded, pad)
+	}
+}
+
+func TestUnpadPkcs7Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		blockSize int
+		wantErr   error
+	}{
+		{"empty", "", 16, errInvalidPadding},
+		{"wrong padding bytes", "ICE ICE BABY\x05\x05\x05\x05", 16, errInvalidPadding},
+		{"mixed padding bytes", "ICE ICE BABY\x01\x02\x03\x04", 16, errInvalidPadding},
+		{"zero padding", "ICE ICE BABY\x00\x00\x00\x00", 16, errInvalidPadding},
+		{"padding longer than the block", "ICE ICE BABY\x11\x11\x11\x11", 16, errInvalidPadding},
+		{"padding longer than the data", "ICE\x05", 0, errInvalidPadding},
+		{"not block aligned", "ICE ICE BABY\x01", 16, errNotBlockAligned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := unpadPkcs7([]byte(tt.data), tt.blockSize)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("want %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := unpadPkcs7Exact([]byte("ICE ICE BABY\x04\x04\x04\x04"), 0); err == nil {
+		t.Error("unpadPkcs7Exact accepted a block size of 0")
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// _chachaKeySize is the size of a ChaCha20 key in bytes.
+	_chachaKeySize = 32
+
+	// _chachaNonceSize is the size of a ChaCha20 nonce in bytes (RFC 8439).
+	_chachaNonceSize = 12
+
+	// _chachaBlockSize is the size of a block of ChaCha20 keystream in bytes.
+	_chachaBlockSize = 64
+)
+
+// _chachaConstants are the first 4 words of the ChaCha20 state: "expand
+// 32-byte k" in little endian.
+var _chachaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+// errCounterOverflow is returned when a ChaCha20 message is so long that the
+// 32-bit block counter would wrap around, reusing the keystream.
+var errCounterOverflow = errors.New("ChaCha20 block counter overflow")
+
+// chachaQuarterRound is the ChaCha quarter round: it mixes the 4 words of the
+// state at indexes a, b, c, and d with additions, XORs and rotations (ARX).
+func chachaQuarterRound(state *[16]uint32, a, b, c, d int) {
+	state[a] += state[b]
+	state[d] = bits.RotateLeft32(state[d]^state[a], 16)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], 12)
+	state[a] += state[b]
+	state[d] = bits.RotateLeft32(state[d]^state[a], 8)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], 7)
+}
+
+// chachaState returns the initial ChaCha20 state for the given key, block
+// counter, and nonce:
+//
+//	cccccccc  cccccccc  cccccccc  cccccccc
+//	kkkkkkkk  kkkkkkkk  kkkkkkkk  kkkkkkkk
+//	kkkkkkkk  kkkkkkkk  kkkkkkkk  kkkkkkkk
+//	bbbbbbbb  nnnnnnnn  nnnnnnnn  nnnnnnnn
+//
+// where c is a constant, k the key, b the counter and n the nonce.
+func chachaState(key []byte, counter uint32, nonce []byte) [16]uint32 {
+	var state [16]uint32
+	copy(state[:4], _chachaConstants[:])
+	for i := range 8 {
+		state[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	state[12] = counter
+	for i := range 3 {
+		state[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	return state
+}
+
+// chachaBlock returns the block of keystream for the given key, counter, and
+// nonce: it runs 20 rounds (10 column rounds alternated with 10 diagonal
+// rounds) on the initial state, and adds the initial state to the result, so
+// that the rounds can't be inverted.
+// Key and nonce must be _chachaKeySize and _chachaNonceSize bytes long.
+func chachaBlock(key []byte, counter uint32, nonce []byte) [_chachaBlockSize]byte {
+	var (
+		initial = chachaState(key, counter, nonce)
+		state   = initial
+	)
+	chachaRounds(&state)
+
+	var block [_chachaBlockSize]byte
+	for i := range state {
+		binary.LittleEndian.PutUint32(block[4*i:], state[i]+initial[i])
+	}
+	return block
+}
+
+// chachaRounds runs the 20 rounds of ChaCha20 on state: 10 column rounds
+// alternated with 10 diagonal rounds.
+func chachaRounds(state *[16]uint32) {
+	for range 10 {
+		// column rounds.
+		chachaQuarterRound(state, 0, 4, 8, 12)
+		chachaQuarterRound(state, 1, 5, 9, 13)
+		chachaQuarterRound(state, 2, 6, 10, 14)
+		chachaQuarterRound(state, 3, 7, 11, 15)
+
+		// diagonal rounds.
+		chachaQuarterRound(state, 0, 5, 10, 15)
+		chachaQuarterRound(state, 1, 6, 11, 12)
+		chachaQuarterRound(state, 2, 7, 8, 13)
+		chachaQuarterRound(state, 3, 4, 9, 14)
+	}
+}
+
+// chacha20XOR encrypts (or decrypts, it's the same operation) data with
+// ChaCha20: it XORs data with the keystream generated from the given key and
+// nonce, starting from the block with the given counter.
+// ChaCha20 is a stream cipher: as with CTR mode, encrypting two messages with
+// the same key and nonce reveals the XOR of their plain texts (see
+// breakChachaNonceReuse).
+func chacha20XOR(data, key, nonce []byte, counter uint32) ([]byte, error) {
+	if len(key) != _chachaKeySize {
+		return nil, fmt.Errorf("invalid ChaCha20 key size %d; want %d", len(key), _chachaKeySize)
+	}
+	if len(nonce) != _chachaNonceSize {
+		return nil, fmt.Errorf("invalid ChaCha20 nonce size %d; want %d", len(nonce), _chachaNonceSize)
+	}
+
+	nBlocks := (uint64(len(data)) + _chachaBlockSize - 1) / _chachaBlockSize
+	if uint64(counter)+nBlocks > 1<<32 {
+		return nil, errCounterOverflow
+	}
+
+	out := make([]byte, len(data))
+	for start := 0; start < len(data); start += _chachaBlockSize {
+		var (
+			end       = min(start+_chachaBlockSize, len(data))
+			keystream = chachaBlock(key, counter, nonce)
+		)
+		for i := start; i < end; i++ {
+			out[i] = data[i] ^ keystream[i-start]
+		}
+		counter++
+	}
+
+	return out, nil
+}
+
+// breakChachaNonceReuse recovers the plain texts of messages encrypted with
+// ChaCha20 under the same key and nonce (hence the same keystream), without
+// knowing the key.
+// Byte i of every cipher text was encrypted by XORing it with byte i of the
+// keystream, so the i-th bytes of all the cipher texts, taken together, are a
+// single-byte XOR cipher text that we can break by frequency analysis (as in
+// challenge 3). This is the same attack as the fixed-nonce CTR challenges.
+// The keystream is only recovered up to the length of the longest cipher
+// text, and its last bytes, shared by few cipher texts, are less reliable.
+// It returns the recovered plain texts and keystream, and honors withScorer.
+func breakChachaNonceReuse(
+	cipherTexts [][]byte,
+	opts ...attackOption,
+) ([][]byte, []byte, error) {
+
+	var maxLen int
+	for _, ct := range cipherTexts {
+		maxLen = max(maxLen, len(ct))
+	}
+	if maxLen == 0 {
+		return nil, nil, errors.New("no cipher text to break")
+	}
+
+	var (
+		keystream = make([]byte, maxLen)
+		column    = make([]byte, 0, len(cipherTexts))
+	)
+	for i := range keystream {
+		column = column[:0]
+		for _, ct := range cipherTexts {
+			if i < len(ct) {
+				column = append(column, ct[i])
+			}
+		}
+
+		// with a single cipher text left there is no statistics to speak
+		// of, but the scorer may still favor printable characters.
+		if candidates := singleByteXORCandidates(column, 1, opts...); len(candidates) > 0 {
+			keystream[i] = candidates[0].key
+		}
+	}
+
+	plainTexts := make([][]byte, len(cipherTexts))
+	for i, ct := range cipherTexts {
+		plainTexts[i] = make([]byte, len(ct))
+		for j := range ct {
+			plainTexts[i][j] = ct[j] ^ keystream[j]
+		}
+	}
+
+	return plainTexts, keystream, nil
+}
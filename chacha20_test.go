@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+// _rfc8439Key is the key used by the test vectors of RFC 8439: 00 01 ... 1f.
+var _rfc8439Key = []byte{
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+	0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+}
+
+func TestChachaBlock(t *testing.T) {
+	// RFC 8439, section 2.3.2.
+	var (
+		nonce = testutil.MustDecodeHex(t, "000000090000004a00000000")
+		want  = testutil.MustDecodeHex(t, "10f1e7e4d13b5915500fdd1fa32071c4"+
+			"c7d1f4c733c068030422aa9ac3d46c4e"+
+			"d2826446079faa0914c2d705d98b02a2"+
+			"b5129cd1de164eb9cbd083e8a2503c4e")
+	)
+
+	got := chachaBlock(_rfc8439Key, 1, nonce)
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("\nwant:\t%x\ngot:\t%x\n", want, got)
+	}
+}
+
+func TestChacha20XOR(t *testing.T) {
+	// RFC 8439, section 2.4.2.
+	var (
+		nonce     = testutil.MustDecodeHex(t, "000000000000004a00000000")
+		plainText = []byte("Ladies and Gentlemen of the class of '99: If I could offer you " +
+			"only one tip for the future, sunscreen would be it.")
+		want = testutil.MustDecodeHex(t, "6e2e359a2568f98041ba0728dd0d6981"+
+			"e97e7aec1d4360c20a27afccfd9fae0b"+
+			"f91b65c5524733ab8f593dabcd62b357"+
+			"1639d624e65152ab8f530c359f0861d8"+
+			"07ca0dbf500d6a6156a38e088a22b65e"+
+			"52bc514d16ccf806818ce91ab7793736"+
+			"5af90bbf74a35be6b40b8eedf2785e42"+
+			"874d")
+	)
+
+	cipherText, err := chacha20XOR(plainText, _rfc8439Key, nonce, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(cipherText, want) {
+		t.Errorf("\nwant:\t%x\ngot:\t%x\n", want, cipherText)
+	}
+
+	decrypted, err := chacha20XOR(cipherText, _rfc8439Key, nonce, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(decrypted, plainText) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, decrypted)
+	}
+}
+
+func TestChacha20XORErrors(t *testing.T) {
+	nonce := make([]byte, _chachaNonceSize)
+
+	if _, err := chacha20XOR(nil, make([]byte, 16), nonce, 0); err == nil {
+		t.Error("a 16 byte key was accepted")
+	}
+	if _, err := chacha20XOR(nil, _rfc8439Key, make([]byte, 8), 0); err == nil {
+		t.Error("an 8 byte nonce was accepted")
+	}
+
+	_, err := chacha20XOR(make([]byte, 2*_chachaBlockSize), _rfc8439Key, nonce, 1<<32-1)
+	if !errors.Is(err, errCounterOverflow) {
+		t.Errorf("want %v, got %v", errCounterOverflow, err)
+	}
+}
+
+func TestBreakChachaNonceReuse(t *testing.T) {
+	text, err := os.ReadFile("./files/1_7.golden")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		key        = testutil.RandomBytes(t, _chachaKeySize)
+		nonce      = make([]byte, _chachaNonceSize)
+		plainTexts = bytes.FieldsFunc(text, func(r rune) bool { return r == '\n' })
+		minLen     = len(plainTexts[0])
+	)
+
+	cipherTexts := make([][]byte, len(plainTexts))
+	for i, pt := range plainTexts {
+		minLen = min(minLen, len(pt))
+		cipherTexts[i], err = chacha20XOR(pt, key, nonce, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	recovered, keystream, err := breakChachaNonceReuse(cipherTexts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the keystream is reliable where all the cipher texts overlap, except
+	// maybe for the first byte: lines start with a capital letter, which
+	// throws off the English letter frequencies.
+	var (
+		want  = chachaBlock(key, 0, nonce)
+		n     = min(minLen, len(want))
+		wrong int
+	)
+	for i := range n {
+		if keystream[i] != want[i] {
+			wrong++
+		}
+	}
+	if wrong > 1 {
+		t.Errorf("%d of the first %d keystream bytes are wrong\nwant:\t%x\ngot:\t%x\n", wrong, n, want[:n], keystream[:n])
+	}
+	if !bytes.Equal(recovered[0][1:minLen], plainTexts[0][1:minLen]) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainTexts[0][1:minLen], recovered[0][1:minLen])
+	}
+}
+
+func BenchmarkBreakChachaNonceReuse(b *testing.B) {
+	text, err := os.ReadFile("./files/1_7.golden")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		key        = bytes.Repeat([]byte("YELLOW SUBMARINE"), 2)
+		nonce      = make([]byte, _chachaNonceSize)
+		plainTexts = bytes.FieldsFunc(text, func(r rune) bool { return r == '\n' })
+	)
+	cipherTexts := make([][]byte, len(plainTexts))
+	for i, pt := range plainTexts {
+		cipherTexts[i], err = chacha20XOR(pt, key, nonce, 0)
+		if err != nil {
+			b.Fatalf("unexpected error: %s", err)
+		}
+	}
+	b.ResetTimer()
+
+	for range b.N {
+		if _, _, err := breakChachaNonceReuse(cipherTexts); err != nil {
+			b.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// badChachaBlock is chachaBlock without the final addition of the initial
+// state (the "feed-forward"), and with caller provided constants.
+// It's an easy mistake to make, e.g., when "simplifying" an implementation or
+// when the constants are treated as a domain separator the caller can set:
+// the rounds look like they thoroughly mix the key with the other words, so
+// dropping the addition seems harmless. It's not: every operation of the
+// rounds is invertible, so without the feed-forward anyone who knows a block
+// of keystream can run the rounds backwards and read the key off the initial
+// state (see recoverBadChachaKey).
+func badChachaBlock(
+	constants [4]uint32,
+	key []byte,
+	counter uint32,
+	nonce []byte,
+) [_chachaBlockSize]byte {
+
+	state := chachaState(key, counter, nonce)
+	copy(state[:4], constants[:])
+	chachaRounds(&state)
+
+	var block [_chachaBlockSize]byte
+	for i := range state {
+		binary.LittleEndian.PutUint32(block[4*i:], state[i])
+	}
+	return block
+}
+
+// newBadChachaOracle returns an aesOracle that encrypts a plain text with a
+// ChaCha20 variant built on badChachaBlock, under a (randomly generated) key
+// that's the same for every call, and a random nonce for each call.
+// The constants are taken from the given domain string (16 bytes), which the
+// attacker may influence. It returns [nonce || cipher text].
+// It honors withCompression and withLengthBuckets.
+func newBadChachaOracle(domain string, opts ...oracleOption) (aesOracle, error) {
+	options, err := newOracleOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(domain) != 16 {
+		return nil, fmt.Errorf("invalid domain length %d; want 16", len(domain))
+	}
+
+	var constants [4]uint32
+	for i := range constants {
+		constants[i] = binary.LittleEndian.Uint32([]byte(domain[4*i:]))
+	}
+
+	key, err := randomBytesN(_chachaKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("generating random ChaCha20 key: %w", err)
+	}
+
+	recordGroundTruth("newBadChachaOracle", key, nil)
+
+	encOracle := func(plainText []byte) ([]byte, error) {
+		plainText, err := options.encode(plainText)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce, err := randomBytesN(_chachaNonceSize)
+		if err != nil {
+			return nil, fmt.Errorf("generating random nonce: %w", err)
+		}
+
+		cipherText := make([]byte, _chachaNonceSize+len(plainText))
+		copy(cipherText, nonce)
+
+		for start := 0; start < len(plainText); start += _chachaBlockSize {
+			var (
+				end       = min(start+_chachaBlockSize, len(plainText))
+				keystream = badChachaBlock(constants, key, uint32(start/_chachaBlockSize), nonce)
+			)
+			for i := start; i < end; i++ {
+				cipherText[_chachaNonceSize+i] = plainText[i] ^ keystream[i-start]
+			}
+		}
+
+		return cipherText, nil
+	}
+
+	return encOracle, nil
+}
+
+// recoverBadChachaKey recovers the key of an oracle returned by
+// newBadChachaOracle.
+// We ask the oracle to encrypt a block of zeros, so that the cipher text is
+// the keystream block itself, i.e., the state after the rounds. Running the
+// rounds backwards gives us the initial state, whose words 4 to 11 are the
+// key. Words 12 to 15 must be the counter (0) and the nonce the oracle sent
+// us, which lets us check the result. The constants don't matter: we don't
+// need to know them, we get them back too.
+func recoverBadChachaKey(oracle aesOracle) ([]byte, error) {
+	cipherText, err := oracle(make([]byte, _chachaBlockSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(cipherText) != _chachaNonceSize+_chachaBlockSize {
+		return nil, fmt.Errorf("unexpected cipher text length %d", len(cipherText))
+	}
+
+	var (
+		nonce     = cipherText[:_chachaNonceSize]
+		keystream = cipherText[_chachaNonceSize:]
+		state     [16]uint32
+	)
+	for i := range state {
+		state[i] = binary.LittleEndian.Uint32(keystream[4*i:])
+	}
+	chachaInverseRounds(&state)
+
+	var nonceWords [_chachaNonceSize]byte
+	for i := range 3 {
+		binary.LittleEndian.PutUint32(nonceWords[4*i:], state[13+i])
+	}
+	if state[12] != 0 || !bytes.Equal(nonceWords[:], nonce) {
+		return nil, errors.New("the inverted state doesn't hold the counter and nonce; is there a feed-forward?")
+	}
+
+	key := make([]byte, _chachaKeySize)
+	for i := range 8 {
+		binary.LittleEndian.PutUint32(key[4*i:], state[4+i])
+	}
+
+	return key, nil
+}
+
+// chachaInverseQuarterRound undoes chachaQuarterRound, running its steps
+// backwards: subtractions undo additions, right rotations undo left
+// rotations, and XORs undo themselves.
+func chachaInverseQuarterRound(state *[16]uint32, a, b, c, d int) {
+	state[b] = bits.RotateLeft32(state[b], -7) ^ state[c]
+	state[c] -= state[d]
+	state[d] = bits.RotateLeft32(state[d], -8) ^ state[a]
+	state[a] -= state[b]
+	state[b] = bits.RotateLeft32(state[b], -12) ^ state[c]
+	state[c] -= state[d]
+	state[d] = bits.RotateLeft32(state[d], -16) ^ state[a]
+	state[a] -= state[b]
+}
+
+// chachaInverseRounds undoes chachaRounds.
+func chachaInverseRounds(state *[16]uint32) {
+	for range 10 {
+		chachaInverseQuarterRound(state, 3, 4, 9, 14)
+		chachaInverseQuarterRound(state, 2, 7, 8, 13)
+		chachaInverseQuarterRound(state, 1, 6, 11, 12)
+		chachaInverseQuarterRound(state, 0, 5, 10, 15)
+
+		chachaInverseQuarterRound(state, 3, 7, 11, 15)
+		chachaInverseQuarterRound(state, 2, 6, 10, 14)
+		chachaInverseQuarterRound(state, 1, 5, 9, 13)
+		chachaInverseQuarterRound(state, 0, 4, 8, 12)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestChachaInverseRounds(t *testing.T) {
+	var state [16]uint32
+	for i := range state {
+		state[i] = uint32(i) * 0x9e3779b9
+	}
+
+	orig := state
+	chachaRounds(&state)
+	chachaInverseRounds(&state)
+	if state != orig {
+		t.Errorf("\nwant:\t%x\ngot:\t%x\n", orig, state)
+	}
+}
+
+func TestRecoverBadChachaKey(t *testing.T) {
+	oracle, err := newBadChachaOracle("attacker chosen!")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	key, err := recoverBadChachaKey(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the recovered key must decrypt another message: re-encrypting its plain
+	// text under the same nonce must give the same cipher text.
+	plainText := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip")
+	cipherText, err := oracle(plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		nonce     = cipherText[:_chachaNonceSize]
+		domain    = []byte("attacker chosen!")
+		constants [4]uint32
+		decrypted []byte
+	)
+	for i := range constants {
+		constants[i] = binary.LittleEndian.Uint32(domain[4*i:])
+	}
+	for start := 0; start < len(plainText); start += _chachaBlockSize {
+		var (
+			end       = min(start+_chachaBlockSize, len(plainText))
+			keystream = badChachaBlock(constants, key, uint32(start/_chachaBlockSize), nonce)
+		)
+		for i := start; i < end; i++ {
+			decrypted = append(decrypted, cipherText[_chachaNonceSize+i]^keystream[i-start])
+		}
+	}
+
+	if !bytes.Equal(decrypted, plainText) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, decrypted)
+	}
+}
+
+func TestRecoverChachaKeyWithFeedForward(t *testing.T) {
+	// against the real ChaCha20, the attack must notice it failed.
+	key := bytes.Repeat([]byte{0x42}, _chachaKeySize)
+	oracle := func(plainText []byte) ([]byte, error) {
+		nonce := make([]byte, _chachaNonceSize)
+		cipherText, err := chacha20XOR(plainText, key, nonce, 0)
+		return append(nonce, cipherText...), err
+	}
+
+	if _, err := recoverBadChachaKey(oracle); err == nil {
+		t.Error("the attack succeeded against ChaCha20 with feed-forward")
+	}
+}
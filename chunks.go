@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+)
+
+// toChunks splits data into chunks of size bytes. It returns an error if the
+// length of data is not a multiple of size.
+// The chunks are views of data, not copies.
+func toChunks(data []byte, size int) ([][]byte, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", size)
+	}
+	if len(data)%size != 0 {
+		const formatStr = "%w: data length (%d) is not a multiple of the chunk size (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(data), size)
+	}
+
+	return toChunksPartial(data, size), nil
+}
+
+// toChunksPartial splits data into chunks of size bytes. If the length of
+// data is not a multiple of size, the last chunk is shorter.
+// The chunks are views of data, not copies.
+// It panics if size is not positive.
+func toChunksPartial(data []byte, size int) [][]byte {
+	chunked := make([][]byte, 0, numBlocks(len(data), size))
+	for chunk := range chunks(data, size) {
+		chunked = append(chunked, chunk)
+	}
+	return chunked
+}
+
+// chunks returns an iterator over the chunks of size bytes of data. If the
+// length of data is not a multiple of size, the last chunk is shorter.
+// Unlike toChunksPartial, it doesn't allocate a slice to hold the chunks.
+// It's blockRange without the indexes: the chunks are views of data, and
+// appending to a chunk can't overwrite the next one.
+// It panics if size is not positive.
+func chunks(data []byte, size int) iter.Seq[[]byte] {
+	blocks := blockRange(data, size)
+	return func(yield func([]byte) bool) {
+		for _, block := range blocks {
+			if !yield(block) {
+				return
+			}
+		}
+	}
+}
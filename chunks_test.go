@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToChunks(t *testing.T) {
+	data := []byte("YELLOW SUBMARINE")
+
+	got, err := toChunks(data, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := [][]byte{[]byte("YELL"), []byte("OW S"), []byte("UBMA"), []byte("RINE")}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+
+	if _, err := toChunks(data, 5); err == nil {
+		t.Errorf("expected an error for a length that is not a multiple of the chunk size")
+	}
+}
+
+func TestToChunksPartial(t *testing.T) {
+	got := toChunksPartial([]byte("YELLOW SUBMARINE"), 5)
+
+	want := [][]byte{[]byte("YELLO"), []byte("W SUB"), []byte("MARIN"), []byte("E")}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+
+	if got := toChunksPartial(nil, 5); len(got) != 0 {
+		t.Errorf("want no chunks for empty data, got %q", got)
+	}
+}
+
+func TestChunksDontOverlap(t *testing.T) {
+	data := []byte("YELLOW SUBMARINE")
+
+	for chunk := range chunks(data, 8) {
+		// appending to a chunk must not overwrite the next one.
+		_ = append(chunk, '!')
+	}
+
+	if string(data) != "YELLOW SUBMARINE" {
+		t.Errorf("data was modified: %q", data)
+	}
+}
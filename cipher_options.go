@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// cipherOptions configures the AES encryption/decryption functions.
+type cipherOptions struct {
+	// stdlib makes the functions use the (hardware accelerated) modes of the
+	// standard library instead of the ones implemented in this package.
+	stdlib bool
+
+	// padding is the scheme used to pad the plain text before encrypting it.
+	// If nil, the plain text is encrypted as it is.
+	padding paddingScheme
+}
+
+// cipherOption defines a type that sets an option of the AES
+// encryption/decryption functions.
+type cipherOption func(*cipherOptions)
+
+// withStdlib makes the AES encryption/decryption functions use the standard
+// library's implementation of the cipher modes.
+// The implementations in this package are written to be studied, not to be
+// fast: use this option when you only need the result, e.g., in an oracle
+// targeted by an attack that makes thousands of queries.
+func withStdlib() cipherOption {
+	return func(o *cipherOptions) {
+		o.stdlib = true
+	}
+}
+
+// newCipherOptions returns the cipherOptions resulting from applying opts to
+// the default options.
+func newCipherOptions(opts []cipherOption) cipherOptions {
+	o := cipherOptions{padding: pkcs7Padding{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// encryptAesEcbStdlib is the standard library version of encryptAesEcb.
+// The standard library doesn't provide ECB mode (for good reasons), but we
+// can still avoid allocating a new slice for each block.
+// The plain text must be already padded.
+func encryptAesEcbStdlib(plainText, key []byte) ([]byte, error) {
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating AES cipher: %w", err)
+	}
+
+	cipherText := make([]byte, len(plainText))
+	for start := 0; start < len(plainText); start += aes.BlockSize {
+		aesCipher.Encrypt(cipherText[start:], plainText[start:])
+	}
+
+	return cipherText, nil
+}
+
+// decryptAesEcbStdlib is the standard library version of decryptAesEcb.
+func decryptAesEcbStdlib(cipherText, key []byte) ([]byte, error) {
+	if len(cipherText)%aes.BlockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the block size (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), aes.BlockSize)
+	}
+
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating AES cipher: %w", err)
+	}
+
+	plainText := make([]byte, len(cipherText))
+	for start := 0; start < len(cipherText); start += aes.BlockSize {
+		aesCipher.Decrypt(plainText[start:], cipherText[start:])
+	}
+
+	return plainText, nil
+}
+
+// encryptAesCbcStdlib is the standard library version of encryptAesCbc.
+// The plain text must be already padded.
+func encryptAesCbcStdlib(plainText, key, iv []byte) ([]byte, error) {
+	if len(iv) != aes.BlockSize {
+		const formatStr = "initialization vector length %d is not the block size %d"
+		return nil, fmt.Errorf(formatStr, len(iv), aes.BlockSize)
+	}
+
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating AES cipher: %w", err)
+	}
+
+	cipherText := make([]byte, len(plainText))
+	cipher.NewCBCEncrypter(aesCipher, iv).CryptBlocks(cipherText, plainText)
+
+	return cipherText, nil
+}
+
+// decryptAesCbcStdlib is the standard library version of decryptAesCbc.
+func decryptAesCbcStdlib(cipherText, key, iv []byte) ([]byte, error) {
+	if len(cipherText)%aes.BlockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the block size (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), aes.BlockSize)
+	}
+	if len(iv) != aes.BlockSize {
+		const formatStr = "initialization vector length %d is not the block size %d"
+		return nil, fmt.Errorf(formatStr, len(iv), aes.BlockSize)
+	}
+
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating AES cipher: %w", err)
+	}
+
+	plainText := make([]byte, len(cipherText))
+	cipher.NewCBCDecrypter(aesCipher, iv).CryptBlocks(plainText, cipherText)
+
+	return plainText, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStdlibMatchesEducational(t *testing.T) {
+	var (
+		plainText = bytes.Repeat([]byte("Lorem ipsum dolor sit amet "), 10)
+		key       = []byte("YELLOW SUBMARINE")
+		iv        = []byte("RED SUBMARINE!!!")
+	)
+
+	ecb, err := encryptAesEcb(plainText, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ecbStd, err := encryptAesEcb(plainText, key, withStdlib())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(ecb, ecbStd) {
+		t.Errorf("ECB cipher texts differ:\n%x\n%x", ecb, ecbStd)
+	}
+
+	cbc, err := encryptAesCbc(plainText, key, iv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cbcStd, err := encryptAesCbc(plainText, key, iv, withStdlib())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(cbc, cbcStd) {
+		t.Errorf("CBC cipher texts differ:\n%x\n%x", cbc, cbcStd)
+	}
+
+	ecbDec, err := decryptAesEcb(ecb, key, withStdlib())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(delPadPkcs7(ecbDec), plainText) {
+		t.Errorf("ECB: decrypted plain text differs from the original")
+	}
+
+	cbcDec, err := decryptAesCbc(cbc, key, iv, withStdlib())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(delPadPkcs7(cbcDec), plainText) {
+		t.Errorf("CBC: decrypted plain text differs from the original")
+	}
+}
+
+func BenchmarkEncryptAesEcb(b *testing.B) {
+	var (
+		plainText = make([]byte, 1<<20)
+		key       = []byte("YELLOW SUBMARINE")
+	)
+
+	b.Run("educational", func(b *testing.B) {
+		b.SetBytes(int64(len(plainText)))
+		for range b.N {
+			if _, err := encryptAesEcb(plainText, key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stdlib", func(b *testing.B) {
+		b.SetBytes(int64(len(plainText)))
+		for range b.N {
+			if _, err := encryptAesEcb(plainText, key, withStdlib()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncryptAesCbc(b *testing.B) {
+	var (
+		plainText = make([]byte, 1<<20)
+		key       = []byte("YELLOW SUBMARINE")
+		iv        = make([]byte, len(key))
+	)
+
+	b.Run("educational", func(b *testing.B) {
+		b.SetBytes(int64(len(plainText)))
+		for range b.N {
+			if _, err := encryptAesCbc(plainText, key, iv); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stdlib", func(b *testing.B) {
+		b.SetBytes(int64(len(plainText)))
+		for range b.N {
+			if _, err := encryptAesCbc(plainText, key, iv, withStdlib()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// _autoMinWordFraction is the fraction of English words (see wordFraction) a
+// decryption needs for crack auto to accept it.
+const _autoMinWordFraction = 0.5
+
+// errNoAttack is returned by crack auto when none of the attacks applies.
+var errNoAttack = errors.New("no applicable attack")
+
+// runCrackAuto implements the "crack auto" command: it figures out what it's
+// up against, either by probing an oracle or by analyzing a cipher text, and
+// runs the attack that applies, reporting which one it chose and why.
+func runCrackAuto(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs         = flag.NewFlagSet("auto", flag.ContinueOnError)
+		oracleURL  = fs.String("oracle-url", "", "URL of the remote encryption oracle")
+		oracleCmd  = fs.String("oracle-cmd", "", "command running the encryption oracle (see serve)")
+		in         = fs.String("in", "", "cipher text file, if there's no oracle (default stdin)")
+		encoding   = fs.String("encoding", "base64", "cipher text encoding: raw, base64 or hex")
+		maxKeySize = fs.Int("max-key-size", 40, "largest repeating XOR key size to try")
+		asJSON     = fs.Bool("json", false, "print the result as JSON")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("auto: %w", err)
+	}
+
+	var (
+		res attackResult
+		err error
+	)
+	if *oracleURL != "" || *oracleCmd != "" {
+		res, err = crackOracleAuto(*oracleURL, *oracleCmd)
+	} else {
+		var cipherText []byte
+		cipherText, err = readCipherText(*in, *encoding, stdin)
+		if err != nil {
+			return fmt.Errorf("auto: %w", err)
+		}
+		res, err = crackCipherTextAuto(cipherText, *maxKeySize)
+	}
+	if err != nil {
+		return fmt.Errorf("auto: %w", err)
+	}
+
+	return res.write(stdout, *asJSON)
+}
+
+// crackOracleAuto probes the oracle served at url or run by cmd, and runs the
+// attack recovering the secret it appends to our input, if there's one.
+func crackOracleAuto(url, cmd string) (attackResult, error) {
+	remote, stop, err := dialOracle(url, cmd)
+	if err != nil {
+		return attackResult{}, err
+	}
+	defer stop()
+
+	var (
+		oracle, calls = countOracleCalls(remote)
+		start         = time.Now()
+		res           = attackResult{Attack: "ecb-suffix"}
+		secret        []byte
+	)
+
+	profile, err := probeOracle(oracle)
+	switch {
+	case errors.Is(err, errIrregularOracle):
+		// the only irregularity we know how to deal with.
+		res.Reason = fmt.Sprintf("assuming a random prefix, since probing failed: %s", err)
+		secret, _, err = decryptRandomPrefixOracleSecret(oracle)
+
+	case err != nil:
+		return attackResult{}, fmt.Errorf("probing oracle: %w", err)
+
+	case profile.family == modeFamilyStream:
+		const formatStr = "%w: the oracle uses a stream cipher, and encrypting chosen plain texts reveals nothing about its %d extra bytes"
+		return attackResult{}, fmt.Errorf(formatStr, errNoAttack, profile.affixLen)
+
+	case !profile.ecb:
+		const formatStr = "%w: the oracle uses a %d-byte block cipher, but not in ECB mode"
+		return attackResult{}, fmt.Errorf(formatStr, errNoAttack, profile.blockSize)
+
+	case profile.suffixLen == 0:
+		return attackResult{}, fmt.Errorf("%w: the oracle uses ECB, but it doesn't append a secret", errNoAttack)
+
+	case profile.prefixLen == 0:
+		const formatStr = "%d-byte blocks in ECB mode, with a %d-byte suffix"
+		res.Reason = fmt.Sprintf(formatStr, profile.blockSize, profile.suffixLen)
+		secret, err = decryptOracleSecret(oracle, withBlockSize(profile.blockSize))
+
+	default:
+		const formatStr = "%d-byte blocks in ECB mode, with a %d-byte prefix and a %d-byte suffix"
+		res.Reason = fmt.Sprintf(formatStr, profile.blockSize, profile.prefixLen, profile.suffixLen)
+		secret, _, err = decryptRandomPrefixOracleSecret(oracle)
+	}
+	if err != nil {
+		return attackResult{}, fmt.Errorf("%s (%s): %w", res.Attack, res.Reason, err)
+	}
+
+	res.PlainText = unpadRecovered(secret)
+	res.OracleCalls = calls()
+	res.Duration = time.Since(start)
+
+	return res, nil
+}
+
+// crackCipherTextAuto finds out how the given cipher text was encrypted, and
+// decrypts it if that's one of the schemes we can break without an oracle.
+// Decryptions are only accepted if they are made of English words.
+func crackCipherTextAuto(cipherText []byte, maxKeySize int) (attackResult, error) {
+	start := time.Now()
+
+	if score, err := detectAesEcbScore(cipherText); err == nil && score.duplicates > 0 && score.falsePositive < 1e-6 {
+		const formatStr = "%w: %d of the %d blocks are repeated, so it looks encrypted with ECB, which we can't break without an oracle"
+		return attackResult{}, fmt.Errorf(formatStr, errNoAttack, score.duplicates, score.blocks)
+	}
+
+	if candidates := singleByteXORCandidates(cipherText, 1); len(candidates) > 0 {
+		best := candidates[0]
+		if words := wordFraction(best.plainText); words >= _autoMinWordFraction {
+			return attackResult{
+				Attack:    "xor-single",
+				Reason:    fmt.Sprintf("single-byte XOR with key %q decrypts to English (%.0f%% known words)", best.key, 100*words),
+				Key:       []byte{best.key},
+				PlainText: best.plainText,
+				Score:     best.score,
+				Duration:  time.Since(start),
+			}, nil
+		}
+	}
+
+	distances, err := keySizeDistances(cipherText, maxKeySize, 0)
+	if err != nil {
+		return attackResult{}, err
+	}
+	if len(distances) > 0 {
+		plainText, key, err := breakRepeatingKeyXOR(cipherText, maxKeySize)
+		if err != nil {
+			return attackResult{}, err
+		}
+
+		if words := wordFraction([]byte(plainText)); words >= _autoMinWordFraction {
+			best := slices.MinFunc(distances, func(a, b keySizeDistance) int {
+				return cmp.Compare(a.distance, b.distance)
+			})
+
+			const formatStr = "key size %d stands out (normalized Hamming distance %.2f, median %.2f), and decrypts to English (%.0f%% known words)"
+			return attackResult{
+				Attack:    "xor-repeating",
+				Reason:    fmt.Sprintf(formatStr, best.keySize, best.distance, medianDistance(distances), 100*words),
+				Key:       []byte(key),
+				PlainText: []byte(plainText),
+				Duration:  time.Since(start),
+			}, nil
+		}
+	}
+
+	return attackResult{}, fmt.Errorf("%w: neither single-byte nor repeating-key XOR decrypts to English", errNoAttack)
+}
+
+// medianDistance returns the median of the given distances.
+func medianDistance(distances []keySizeDistance) float64 {
+	values := make([]float64, len(distances))
+	for i, d := range distances {
+		values[i] = d.distance
+	}
+	slices.Sort(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestCrackAutoCipherText(t *testing.T) {
+	ecb, err := encryptAesEcb(bytes.Repeat([]byte("sixteen byte blk"), 8), testutil.RandomKey(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		cipherText string
+		wantAttack string
+		wantText   string
+		wantErr    error
+	}{
+		{
+			name:       "single-byte XOR",
+			args:       []string{"-encoding", "hex"},
+			cipherText: "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736",
+			wantAttack: "xor-single",
+			wantText:   "Cooking MC's like a pound of bacon",
+		},
+		{
+			name:       "repeating-key XOR",
+			args:       []string{"-in", "./files/1_6.txt"},
+			wantAttack: "xor-repeating",
+			wantText:   "Terminator X: Bring the noise",
+		},
+		{
+			name:       "ECB",
+			args:       []string{"-encoding", "hex"},
+			cipherText: hex.EncodeToString(ecb),
+			wantErr:    errNoAttack,
+		},
+		{
+			name:    "AES CBC",
+			args:    []string{"-in", "./files/2_10.txt"},
+			wantErr: errNoAttack,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				args = append([]string{"crack", "auto", "-json"}, test.args...)
+				out  bytes.Buffer
+			)
+			err := run(args, strings.NewReader(test.cipherText), &out)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("want %v, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var res attackResult
+			if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if res.Attack != test.wantAttack || res.Reason == "" {
+				t.Errorf("want attack %q with a reason, got %q (%s)", test.wantAttack, res.Attack, res.Reason)
+			}
+			if !strings.Contains(string(res.Key), test.wantText) && !strings.Contains(string(res.PlainText), test.wantText) {
+				t.Errorf("result does not contain %q:\n%s", test.wantText, res.PlainText)
+			}
+		})
+	}
+}
+
+func TestCrackAutoOracle(t *testing.T) {
+	const secret = "YELLOW SUBMARINE, RED SUNSHINE"
+
+	ecb, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	randomPrefix, err := randomPrefixEcbOracle(staticSecret(secret), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		oracle     aesOracle
+		wantReason string
+		wantErr    error
+	}{
+		{name: "ECB suffix", oracle: ecb, wantReason: "30-byte suffix"},
+		{name: "fixed prefix", oracle: fixedAffixOracle(t, "a prefix", secret, false), wantReason: "8-byte prefix"},
+		{name: "random prefix", oracle: randomPrefix, wantReason: "random prefix"},
+		{name: "CBC", oracle: fixedAffixOracle(t, "", secret, true), wantErr: errNoAttack},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(oracleHandler(test.oracle))
+			defer srv.Close()
+
+			var (
+				args = []string{"crack", "auto", "-oracle-url", srv.URL}
+				out  bytes.Buffer
+			)
+			err := run(args, nil, &out)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("want %v, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for _, want := range []string{"attack: ecb-suffix", test.wantReason, secret} {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
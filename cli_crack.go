@@ -0,0 +1,378 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// _crackCommands maps the name of each crack subcommand to its
+// implementation.
+var _crackCommands = map[string]command{
+	"auto": {
+		summary: "probe an oracle or analyze a cipher text, and run the attack that applies",
+		run:     runCrackAuto,
+	},
+	"xor-single": {
+		summary: "break single-byte XOR (challenge 3)",
+		run:     runCrackXORSingle,
+	},
+	"xor-repeating": {
+		summary: "break repeating-key XOR (challenge 6)",
+		run:     runCrackXORRepeating,
+	},
+	"ecb-passphrase": {
+		summary: "recover an ECB key naively derived from a weak passphrase, with a word list",
+		run:     runCrackECBPassphrase,
+	},
+	"ecb-suffix": {
+		summary: "recover the secret appended by a remote ECB oracle (challenge 12)",
+		run:     runCrackECBSuffix,
+	},
+}
+
+// runCrack implements the crack command, which runs the attack named by the
+// first of the given arguments.
+func runCrack(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(crackUsage())
+	}
+
+	cmd, ok := _crackCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown attack %q\n%s", args[0], crackUsage())
+	}
+
+	return cmd.run(args[1:], stdin, stdout)
+}
+
+// crackUsage returns the list of the available attacks.
+func crackUsage() string {
+	names := make([]string, 0, len(_crackCommands))
+	for name := range _crackCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("usage: cryptopals crack <attack> [flags]\nattacks:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-14s %s\n", name, _crackCommands[name].summary)
+	}
+
+	return b.String()
+}
+
+// runCrackXORSingle implements the "crack xor-single" command.
+func runCrackXORSingle(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs       = flag.NewFlagSet("xor-single", flag.ContinueOnError)
+		in       = fs.String("in", "", "cipher text file (default stdin)")
+		encoding = fs.String("encoding", "hex", "cipher text encoding: raw, base64 or hex")
+		top      = fs.Int("top", 1, "print the given number of best candidates")
+		asJSON   = fs.Bool("json", false, "print the result as JSON")
+		record   = fs.String("record", "", "append the run's metadata to the given results store")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("xor-single: %w", err)
+	}
+	if *top < 1 {
+		return errors.New("xor-single: -top must be at least 1")
+	}
+
+	cipherText, err := readCipherText(*in, *encoding, stdin)
+	if err != nil {
+		return fmt.Errorf("xor-single: %w", err)
+	}
+
+	var (
+		start      = time.Now()
+		candidates = singleByteXORCandidates(cipherText, *top)
+		elapsed    = time.Since(start)
+	)
+	if len(candidates) == 0 {
+		err = errors.New("no candidate looks like text")
+	}
+	if err := recordRun(*record, newRunRecord("xor-single", start, 0, err), err); err != nil {
+		return fmt.Errorf("xor-single: %w", err)
+	}
+
+	for i, c := range candidates {
+		if i > 0 && !*asJSON {
+			fmt.Fprintln(stdout)
+		}
+
+		res := attackResult{
+			Attack:    "xor-single",
+			Key:       []byte{c.key},
+			PlainText: c.plainText,
+			Score:     c.score,
+			Duration:  elapsed,
+		}
+		if err := res.write(stdout, *asJSON); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runCrackXORRepeating implements the "crack xor-repeating" command.
+func runCrackXORRepeating(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs         = flag.NewFlagSet("xor-repeating", flag.ContinueOnError)
+		in         = fs.String("in", "", "cipher text file (default stdin)")
+		encoding   = fs.String("encoding", "base64", "cipher text encoding: raw, base64 or hex")
+		maxKeySize = fs.Int("max-key-size", 40, "largest key size to try")
+		asJSON     = fs.Bool("json", false, "print the result as JSON")
+		record     = fs.String("record", "", "append the run's metadata to the given results store")
+		keySizes   = fs.Bool("key-sizes", false, "plot the Hamming distance of each key size before the result")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+	if *keySizes && *asJSON {
+		return errors.New("xor-repeating: -key-sizes and -json are mutually exclusive")
+	}
+	if *maxKeySize < 2 {
+		return fmt.Errorf("xor-repeating: invalid -max-key-size %d; must be at least 2", *maxKeySize)
+	}
+
+	cipherText, err := readCipherText(*in, *encoding, stdin)
+	if err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+
+	if *keySizes {
+		distances, err := keySizeDistances(cipherText, *maxKeySize, 0)
+		if err != nil {
+			return fmt.Errorf("xor-repeating: %w", err)
+		}
+		if err := plotKeySizeDistances(stdout, distances, bestKeySize(distances)); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	start := time.Now()
+	plainText, key, err := breakRepeatingKeyXOR(cipherText, *maxKeySize)
+	duration := time.Since(start)
+	if err := recordRun(*record, newRunRecord("xor-repeating", start, 0, err), err); err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+
+	res := attackResult{
+		Attack:    "xor-repeating",
+		Key:       []byte(key),
+		PlainText: []byte(plainText),
+		Duration:  duration,
+	}
+
+	return res.write(stdout, *asJSON)
+}
+
+// runCrackECBPassphrase implements the "crack ecb-passphrase" command.
+func runCrackECBPassphrase(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs       = flag.NewFlagSet("ecb-passphrase", flag.ContinueOnError)
+		in       = fs.String("in", "", "cipher text file (default stdin)")
+		encoding = fs.String("encoding", "base64", "cipher text encoding: raw, base64 or hex")
+		wordList = fs.String("wordlist", "", "file of candidate words, separated by white space (default the embedded English words)")
+		maxWords = fs.Int("words", 1, "maximum number of words per passphrase")
+		asJSON   = fs.Bool("json", false, "print the result as JSON")
+		record   = fs.String("record", "", "append the run's metadata to the given results store")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("ecb-passphrase: %w", err)
+	}
+
+	cipherText, err := readCipherText(*in, *encoding, stdin)
+	if err != nil {
+		return fmt.Errorf("ecb-passphrase: %w", err)
+	}
+
+	words := _englishWordsFile
+	if *wordList != "" {
+		list, err := os.ReadFile(*wordList)
+		if err != nil {
+			return fmt.Errorf("ecb-passphrase: %w", err)
+		}
+		words = string(list)
+	}
+
+	start := time.Now()
+	match, plainText, err := crackPassphraseKey(cipherText, strings.Fields(words), *maxWords)
+	duration := time.Since(start)
+	if err := recordRun(*record, newRunRecord("ecb-passphrase", start, 0, err), err); err != nil {
+		return fmt.Errorf("ecb-passphrase: %w", err)
+	}
+
+	res := attackResult{
+		Attack:        "ecb-passphrase",
+		Key:           match.key,
+		Passphrase:    match.passphrase,
+		KeyDerivation: match.derivation.String(),
+		PlainText:     plainText,
+		Duration:      duration,
+	}
+
+	return res.write(stdout, *asJSON)
+}
+
+// runCrackECBSuffix implements the "crack ecb-suffix" command.
+func runCrackECBSuffix(args []string, _ io.Reader, stdout io.Writer) error {
+	var (
+		fs        = flag.NewFlagSet("ecb-suffix", flag.ContinueOnError)
+		oracleURL = fs.String("oracle-url", "", "URL of the remote encryption oracle")
+		oracleCmd = fs.String("oracle-cmd", "", "command running the encryption oracle (see serve)")
+		asJSON    = fs.Bool("json", false, "print the result as JSON")
+		visualize = fs.Bool("visualize", false, "draw the attack's progress on stderr")
+		record    = fs.String("record", "", "append the run's metadata to the given results store")
+		inFlight  = fs.Int("parallelism", 1, "maximum oracle queries in flight at the same time; more hide the latency of a remote oracle")
+		dryRun    = fs.Bool("dry-run", false, "probe the oracle and estimate the attack's queries and duration, without running it")
+		report    = fs.String("report", "", "write a Markdown report of the attack to the given file")
+		until     = fs.String("until", "", "stop as soon as the secret recovered so far matches this regular expression")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+	if *inFlight < 1 {
+		return errors.New("ecb-suffix: -parallelism must be at least 1")
+	}
+	if *visualize && *inFlight > 1 {
+		return errors.New("ecb-suffix: -visualize requires -parallelism 1")
+	}
+	if *report != "" && *inFlight > 1 {
+		return errors.New("ecb-suffix: -report requires -parallelism 1")
+	}
+
+	remote, stop, err := dialOracle(*oracleURL, *oracleCmd)
+	if err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+	defer stop()
+
+	if *dryRun {
+		plan, err := planOracleSecretAttack(remote, withParallelism(*inFlight))
+		if err != nil {
+			return fmt.Errorf("ecb-suffix: %w", err)
+		}
+		return plan.write(stdout, *asJSON)
+	}
+
+	var (
+		reporters []progressReporter
+		rep       attackReport
+	)
+	if *visualize {
+		reporters = append(reporters, ansiVisualizer(os.Stderr))
+	}
+	if *report != "" {
+		reporters = append(reporters, rep.reporter())
+	}
+	opts := []attackOption{withProgress(multiProgress(reporters...))}
+
+	// stopped is set if the attack stopped before recovering the whole
+	// secret, which then has no padding.
+	var stopped bool
+	if *until != "" {
+		re, err := regexp.Compile(*until)
+		if err != nil {
+			return fmt.Errorf("ecb-suffix: -until: %w", err)
+		}
+		opts = append(opts, withVerifier(func(recovered []byte) (bool, error) {
+			stopped = re.Match(recovered)
+			return stopped, nil
+		}))
+	}
+
+	var (
+		oracle, calls = countOracleCalls(remote)
+		start         = time.Now()
+		secret        []byte
+	)
+	if *inFlight > 1 {
+		// the scheduler backs off if the oracle can't keep up with that
+		// many queries.
+		scheduled := newOracleScheduler(*inFlight).wrap(oracle)
+		secret, err = decryptOracleSecretPipelined(scheduled, append(opts, withParallelism(*inFlight))...)
+	} else {
+		secret, err = decryptOracleSecret(oracle, opts...)
+	}
+	duration := time.Since(start)
+	if err := recordRun(*record, newRunRecord("ecb-suffix", start, calls(), err), err); err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+
+	if !stopped {
+		secret = unpadRecovered(secret)
+	}
+	res := attackResult{
+		Attack:      "ecb-suffix",
+		PlainText:   secret,
+		OracleCalls: calls(),
+		Duration:    duration,
+	}
+
+	if *report != "" && err == nil {
+		var md strings.Builder
+		if err := rep.writeMarkdown(&md, res); err != nil {
+			return fmt.Errorf("ecb-suffix: %w", err)
+		}
+		if err := os.WriteFile(*report, []byte(md.String()), 0o644); err != nil {
+			return fmt.Errorf("ecb-suffix: writing report: %w", err)
+		}
+	}
+
+	return res.write(stdout, *asJSON)
+}
+
+// dialOracle returns the oracle served at url or run by cmd, exactly one of
+// which must be set, and a function releasing it.
+func dialOracle(url, cmd string) (aesOracle, func() error, error) {
+	switch {
+	case url != "" && cmd != "":
+		return nil, nil, errors.New("--oracle-url and --oracle-cmd are mutually exclusive")
+	case url != "":
+		return httpOracle(nil, url), func() error { return nil }, nil
+	case cmd != "":
+		cmdLine := strings.Fields(cmd)
+		if len(cmdLine) == 0 {
+			return nil, nil, errors.New("empty --oracle-cmd")
+		}
+		return subprocessOracle(cmdLine[0], cmdLine[1:]...)
+	default:
+		return nil, nil, errors.New("missing --oracle-url or --oracle-cmd")
+	}
+}
+
+// unpadRecovered removes the PKCS#7 padding from a secret recovered from an
+// oracle. The oracle is untrusted, so if the secret isn't validly padded it's
+// returned as it is.
+func unpadRecovered(secret []byte) []byte {
+	unpadded, _, err := unpadPkcs7(secret, 0)
+	if err != nil {
+		return secret
+	}
+	return unpadded
+}
+
+// readCipherText reads the cipher text from the file at path (or stdin if
+// path is empty) and decodes it from the given encoding.
+func readCipherText(path, encoding string, stdin io.Reader) ([]byte, error) {
+	encoded, err := readInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCipherText(encoded, encoding)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCrackXORSingle(t *testing.T) {
+	const cipherText = "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
+
+	var out bytes.Buffer
+	err := run([]string{"crack", "xor-single"}, strings.NewReader(cipherText), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "Cooking MC's like a pound of bacon"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+}
+
+func TestCrackXORSingleTop(t *testing.T) {
+	const cipherText = "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
+
+	var out bytes.Buffer
+	err := run([]string{"crack", "xor-single", "-top", "3", "-json"}, strings.NewReader(cipherText), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		dec     = json.NewDecoder(&out)
+		results []attackResult
+	)
+	for dec.More() {
+		var res attackResult
+		if err := dec.Decode(&res); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		results = append(results, res)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("want 3 results, got %d", len(results))
+	}
+	if got := string(results[0].PlainText); got != "Cooking MC's like a pound of bacon" {
+		t.Errorf("unexpected best candidate %q", got)
+	}
+}
+
+func TestCrackXORRepeating(t *testing.T) {
+	var (
+		args = []string{"crack", "xor-repeating", "-in", "./files/1_6.txt"}
+		out  bytes.Buffer
+	)
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "Terminator X: Bring the noise"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+}
+
+func TestCrackXORRepeatingNoKeySize(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{"max key size too small", []string{"-max-key-size", "1"}, "hello world, this is long enough", "invalid -max-key-size 1"},
+		{"input too short", nil, "hey", "no key size of at most 40 fits twice in 3 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"crack", "xor-repeating", "-encoding", "raw"}, tt.args...)
+			err := run(args, strings.NewReader(tt.input), &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("want error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCrackXORRepeatingKeySizes(t *testing.T) {
+	var (
+		args = []string{"crack", "xor-repeating", "-in", "./files/1_6.txt", "-key-sizes"}
+		out  bytes.Buffer
+	)
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, want := range []string{"29    2.7", "<- chosen", "Terminator X: Bring the noise"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	args = append(args, "-json")
+	if err := run(args, nil, &out); err == nil {
+		t.Error("want an error with both -key-sizes and -json")
+	}
+}
+
+func TestCrackECBPassphrase(t *testing.T) {
+	wordList := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordList, []byte("red yellow\nsubmarine boat\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		args = []string{"crack", "ecb-passphrase", "-in", "./files/1_7.txt", "-wordlist", wordList, "-words", "2"}
+		out  bytes.Buffer
+	)
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, want := range []string{`passphrase: "YELLOW SUBMARINE" (spaces)`, "Play that funky music"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCrackECBPassphraseDerived(t *testing.T) {
+	var (
+		encArgs    = []string{"enc", "-mode", "ecb", "-insecure-ecb", "-passphrase", "MUSIC", "-kdf", "md5", "-encoding", "base64"}
+		cipherText bytes.Buffer
+	)
+	err := run(encArgs, strings.NewReader("Play that funky music white boy"), &cipherText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"crack", "ecb-passphrase", "-json"}, &cipherText, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var res attackResult
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if res.Passphrase != "MUSIC" || res.KeyDerivation != "md5" {
+		t.Errorf("want passphrase %q (md5), got %q (%s)", "MUSIC", res.Passphrase, res.KeyDerivation)
+	}
+	if got := string(res.PlainText); got != "Play that funky music white boy" {
+		t.Errorf("unexpected plain text %q", got)
+	}
+}
+
+func TestCrackECBSuffix(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(oracleHandler(o))
+	defer srv.Close()
+
+	for _, parallelism := range []string{"1", "16"} {
+		var (
+			args = []string{"crack", "ecb-suffix", "-oracle-url", srv.URL, "-parallelism", parallelism}
+			out  bytes.Buffer
+		)
+		if err := run(args, nil, &out); err != nil {
+			t.Fatalf("parallelism %s: unexpected error: %s", parallelism, err)
+		}
+
+		if !strings.Contains(out.String(), secret) {
+			t.Errorf("parallelism %s: output does not contain %q:\n%s", parallelism, secret, out.String())
+		}
+	}
+}
+
+func TestCrackECBSuffixDryRun(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oracle, calls := countOracleCalls(o)
+	srv := httptest.NewServer(oracleHandler(oracle))
+	defer srv.Close()
+
+	var (
+		args = []string{"crack", "ecb-suffix", "-oracle-url", srv.URL, "-dry-run", "-json"}
+		out  bytes.Buffer
+	)
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var plan attackPlan
+	if err := json.Unmarshal(out.Bytes(), &plan); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if plan.SuffixLen != len(secret) || plan.ExpectedCalls == 0 || plan.Latency <= 0 {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	// only the probing queries were made.
+	if calls() != plan.ProbeCalls {
+		t.Errorf("want %d oracle calls, got %d", plan.ProbeCalls, calls())
+	}
+}
+
+func TestCrackJSON(t *testing.T) {
+	const cipherText = "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
+
+	var (
+		args = []string{"crack", "xor-single", "-json"}
+		out  bytes.Buffer
+	)
+	if err := run(args, strings.NewReader(cipherText), &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var res attackResult
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("decoding JSON output: %s", err)
+	}
+
+	if string(res.Key) != "X" {
+		t.Errorf("want key %q, got %q", "X", res.Key)
+	}
+	if res.Attack != "xor-single" {
+		t.Errorf("want attack %q, got %q", "xor-single", res.Attack)
+	}
+}
+
+func TestCrackECBSuffixReport(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(oracleHandler(o))
+	defer srv.Close()
+
+	var (
+		report = filepath.Join(t.TempDir(), "report.md")
+		args   = []string{"crack", "ecb-suffix", "-oracle-url", srv.URL, "-report", report}
+	)
+	if err := run(args, nil, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	md, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, want := range []string{"# Attack report: ecb-suffix", "blocks of 16 bytes", "a secret of 48 bytes", secret} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("report does not contain %q:\n%s", want, md)
+		}
+	}
+
+	args = append(args, "-parallelism", "2")
+	if err := run(args, nil, &bytes.Buffer{}); err == nil {
+		t.Error("want error for -report with -parallelism 2, got nil")
+	}
+}
+
+func TestCrackECBSuffixUntil(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(oracleHandler(o))
+	defer srv.Close()
+
+	var (
+		report = filepath.Join(t.TempDir(), "report.md")
+		args   = []string{"crack", "ecb-suffix", "-oracle-url", srv.URL, "-until", "RED S[A-Z]+", "-report", report}
+		out    bytes.Buffer
+	)
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := "plain text:\nYELLOW SUBMARINE+RED SU\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+
+	md, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := "stopped after 23 bytes"; !strings.Contains(string(md), want) {
+		t.Errorf("report does not contain %q:\n%s", want, md)
+	}
+
+	args = []string{"crack", "ecb-suffix", "-oracle-url", srv.URL, "-until", "("}
+	if err := run(args, nil, &bytes.Buffer{}); err == nil {
+		t.Error("want error for invalid -until, got nil")
+	}
+}
+
+func TestDialOracleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		url, cmd string
+		want     string
+	}{
+		{"none", "", "", "missing --oracle-url or --oracle-cmd"},
+		{"both", "http://localhost", "oracle", "mutually exclusive"},
+		{"blank command", "", " \t", "empty --oracle-cmd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := dialOracle(tt.url, tt.cmd)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("want error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	for _, attack := range []string{"ecb-suffix", "auto"} {
+		if err := run([]string{"crack", attack, "-oracle-cmd", " "}, nil, &bytes.Buffer{}); err == nil {
+			t.Errorf("crack %s: want error for a blank -oracle-cmd, got nil", attack)
+		}
+	}
+}
+
+func TestUnpadRecovered(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret, want string
+	}{
+		{"padded", "Rollin' in my 5.0\x01", "Rollin' in my 5.0"},
+		{"padding longer than the secret", "Yo\x10", "Yo\x10"},
+		{"zero padding", "Yo\x00", "Yo\x00"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unpadRecovered([]byte(tt.secret)); string(got) != tt.want {
+				t.Errorf("\nwant:\t%q\ngot:\t%q\n", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// cryptFlags holds the flags shared by the enc and dec commands.
+type cryptFlags struct {
+	mode        string
+	key         string
+	passphrase  string
+	kdf         string
+	encoding    string
+	in          string
+	out         string
+	insecureECB bool
+}
+
+// newCryptFlagSet returns a flag set for the enc and dec commands, which
+// stores the parsed flags in cf.
+func newCryptFlagSet(name string, cf *cryptFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.StringVar(&cf.mode, "mode", "cbc", "cipher mode: ecb or cbc")
+	fs.StringVar(&cf.key, "key", "", "hex encoded AES-128 key")
+	fs.StringVar(&cf.passphrase, "passphrase", "", "derive the key from a passphrase instead (insecure, see -kdf)")
+	fs.StringVar(&cf.kdf, "kdf", "sha256", "how to derive the key from the passphrase: spaces, zeros, pkcs7, repeat, md5, sha1 or sha256")
+	fs.StringVar(&cf.encoding, "encoding", "raw", "cipher text encoding: raw, base64 or hex")
+	fs.StringVar(&cf.in, "in", "", "input file (default stdin)")
+	fs.StringVar(&cf.out, "out", "", "output file (default stdout)")
+	fs.BoolVar(&cf.insecureECB, "insecure-ecb", false, "allow ECB mode")
+
+	return fs
+}
+
+// runEnc implements the enc command: it encrypts its input with AES in the
+// chosen mode. In CBC mode, a random IV is generated and prepended to the
+// cipher text.
+func runEnc(args []string, stdin io.Reader, stdout io.Writer) error {
+	var cf cryptFlags
+	if err := newCryptFlagSet("enc", &cf).Parse(args); err != nil {
+		return fmt.Errorf("enc: %w", err)
+	}
+
+	key, err := cf.parseKey()
+	if err != nil {
+		return fmt.Errorf("enc: %w", err)
+	}
+	defer secureZero(key)
+
+	plainText, err := cf.readInput(stdin)
+	if err != nil {
+		return fmt.Errorf("enc: %w", err)
+	}
+
+	var cipherText []byte
+	switch cf.mode {
+	case "ecb":
+		cipherText, err = encryptAesEcb(plainText, key)
+
+	case "cbc":
+		var iv []byte
+		iv, err = newIV(aes.BlockSize)
+		if err != nil {
+			break
+		}
+		cipherText, err = encryptAesCbc(plainText, key, iv)
+		cipherText = append(iv, cipherText...)
+	}
+	if err != nil {
+		return fmt.Errorf("enc: %w", err)
+	}
+
+	encoded, err := encodeCipherText(cipherText, cf.encoding)
+	if err != nil {
+		return fmt.Errorf("enc: %w", err)
+	}
+
+	return cf.writeOutput(stdout, encoded)
+}
+
+// runDec implements the dec command: it decrypts its input with AES in the
+// chosen mode. In CBC mode, the IV is read from the first block of the cipher
+// text.
+func runDec(args []string, stdin io.Reader, stdout io.Writer) error {
+	var cf cryptFlags
+	if err := newCryptFlagSet("dec", &cf).Parse(args); err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+
+	key, err := cf.parseKey()
+	if err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+	defer secureZero(key)
+
+	encoded, err := cf.readInput(stdin)
+	if err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+
+	cipherText, err := decodeCipherText(encoded, cf.encoding)
+	if err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+
+	var plainText []byte
+	switch cf.mode {
+	case "ecb":
+		plainText, err = decryptAesEcb(cipherText, key)
+
+	case "cbc":
+		// the IV plus at least one block, since the plain text is padded.
+		if len(cipherText) < 2*aes.BlockSize {
+			return errors.New("dec: cipher text is shorter than the IV and one block")
+		}
+		iv := cipherText[:aes.BlockSize]
+		plainText, err = decryptAesCbc(cipherText[aes.BlockSize:], key, iv)
+	}
+	if err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+
+	plainText, err = unpadPkcs7Exact(plainText, aes.BlockSize)
+	if err != nil {
+		return fmt.Errorf("dec: %w", err)
+	}
+
+	return cf.writeOutput(stdout, plainText)
+}
+
+// parseKey validates the flags and returns the decoded AES key.
+func (cf *cryptFlags) parseKey() ([]byte, error) {
+	switch cf.mode {
+	case "ecb":
+		if !cf.insecureECB {
+			return nil, errors.New("ECB mode is insecure; pass --insecure-ecb to use it anyway")
+		}
+	case "cbc":
+	default:
+		return nil, fmt.Errorf("unsupported mode %q", cf.mode)
+	}
+
+	switch {
+	case cf.key != "" && cf.passphrase != "":
+		return nil, errors.New("--key and --passphrase are mutually exclusive")
+	case cf.passphrase != "":
+		return cf.deriveKey()
+	case cf.key == "":
+		return nil, errors.New("missing --key")
+	}
+
+	key, err := hex.DecodeString(cf.key)
+	if err != nil {
+		return nil, fmt.Errorf("malformed hex key: %w", err)
+	}
+
+	if len(key) != aes.BlockSize {
+		const formatStr = "invalid AES-128 key length: %d bytes (want %d)"
+		return nil, fmt.Errorf(formatStr, len(key), aes.BlockSize)
+	}
+
+	return key, nil
+}
+
+// deriveKey returns the AES-128 key derived from the passphrase. None of the
+// derivations is a real key derivation function: they are attack targets (see
+// crackPassphraseKey).
+func (cf *cryptFlags) deriveKey() ([]byte, error) {
+	derivation, err := parseKeyDerivation(cf.kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	key := deriveKey(cf.passphrase, derivation)
+	if key == nil {
+		const formatStr = "can't derive a key with %s from a passphrase longer than %d bytes"
+		return nil, fmt.Errorf(formatStr, derivation, aes.BlockSize)
+	}
+
+	return key, nil
+}
+
+// readInput reads the whole input file, or stdin if no file was given.
+func (cf *cryptFlags) readInput(stdin io.Reader) ([]byte, error) {
+	return readInput(cf.in, stdin)
+}
+
+// writeOutput writes data to the output file, or to stdout if no file was
+// given.
+func (cf *cryptFlags) writeOutput(stdout io.Writer, data []byte) error {
+	if cf.out == "" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(cf.out, data, 0o644)
+}
+
+// readInput reads the whole file at path, or stdin if path is empty.
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// encodeCipherText encodes the cipher text with the given encoding.
+func encodeCipherText(cipherText []byte, encoding string) ([]byte, error) {
+	if encoding == "raw" {
+		return cipherText, nil
+	}
+
+	c, ok := _codecs[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	return []byte(c.encode(cipherText) + "\n"), nil
+}
+
+// decodeCipherText decodes the cipher text from the given encoding.
+// Whitespace (e.g., line breaks) is ignored for the text encodings.
+func decodeCipherText(encoded []byte, encoding string) ([]byte, error) {
+	if encoding == "raw" {
+		return encoded, nil
+	}
+
+	c, ok := _codecs[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	return c.decode(stripSpaces(encoded))
+}
+
+// stripSpaces returns data as a string with all whitespace removed.
+func stripSpaces(data []byte) string {
+	return string(bytes.Join(bytes.Fields(data), nil))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncDec(t *testing.T) {
+	const (
+		plainText = "Lorem ipsum dolor sit amet consectetur adipiscin"
+		key       = "59454c4c4f57205355424d4152494e45" // YELLOW SUBMARINE
+	)
+
+	for _, mode := range []string{"ecb", "cbc"} {
+		for _, encoding := range []string{"raw", "base64", "hex"} {
+			var (
+				flags = []string{
+					"-mode", mode,
+					"-key", key,
+					"-encoding", encoding,
+					"-insecure-ecb",
+				}
+				cipherText bytes.Buffer
+				decrypted  bytes.Buffer
+			)
+
+			encArgs := append([]string{"enc"}, flags...)
+			if err := run(encArgs, strings.NewReader(plainText), &cipherText); err != nil {
+				t.Fatalf("%s/%s: unexpected error: %s", mode, encoding, err)
+			}
+
+			decArgs := append([]string{"dec"}, flags...)
+			if err := run(decArgs, &cipherText, &decrypted); err != nil {
+				t.Fatalf("%s/%s: unexpected error: %s", mode, encoding, err)
+			}
+
+			if decrypted.String() != plainText {
+				const formatStr = "%s/%s:\nwant:\t%q\ngot:\t%q\n"
+				t.Errorf(formatStr, mode, encoding, plainText, decrypted.String())
+			}
+		}
+	}
+}
+
+func TestEncFiles(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		in  = filepath.Join(dir, "plain.txt")
+		out = filepath.Join(dir, "cipher.bin")
+	)
+	if err := os.WriteFile(in, []byte("YELLOW SUBMARINE"), 0o644); err != nil {
+		t.Fatalf("writing input file: %s", err)
+	}
+
+	args := []string{
+		"enc",
+		"-key", "59454c4c4f57205355424d4152494e45",
+		"-in", in,
+		"-out", out,
+	}
+	if err := run(args, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cipherText, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("reading output file: %s", err)
+	}
+
+	// IV + 1 block of plain text + 1 block of padding.
+	if len(cipherText) != 48 {
+		t.Errorf("want 48 bytes of cipher text, got %d", len(cipherText))
+	}
+}
+
+func TestEncRejectsECB(t *testing.T) {
+	args := []string{"enc", "-mode", "ecb", "-key", "59454c4c4f57205355424d4152494e45"}
+
+	err := run(args, strings.NewReader("YELLOW SUBMARINE"), &bytes.Buffer{})
+	if err == nil {
+		t.Errorf("expected an error when using ECB without --insecure-ecb")
+	}
+}
+
+func TestEncDecPassphrase(t *testing.T) {
+	const plainText = "Play that funky music white boy"
+
+	var cipherText bytes.Buffer
+	encArgs := []string{"enc", "-passphrase", "Yellow", "-kdf", "sha1", "-encoding", "hex"}
+	if err := run(encArgs, strings.NewReader(plainText), &cipherText); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var decrypted bytes.Buffer
+	decArgs := []string{"dec", "-key", hex.EncodeToString(deriveKey("Yellow", deriveSHA1)), "-encoding", "hex"}
+	if err := run(decArgs, &cipherText, &decrypted); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := decrypted.String(); got != plainText {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, got)
+	}
+}
+
+func TestEncPassphraseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "key and passphrase", args: []string{"-key", "59454c4c4f57205355424d4152494e45", "-passphrase", "yellow"}},
+		{name: "unknown derivation", args: []string{"-passphrase", "yellow", "-kdf", "pbkdf2"}},
+		{name: "passphrase too long", args: []string{"-passphrase", "yellow submarines", "-kdf", "spaces"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"enc"}, test.args...)
+			if err := run(args, strings.NewReader("YELLOW SUBMARINE"), &bytes.Buffer{}); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestDecCbcTooShort(t *testing.T) {
+	args := []string{"dec", "-key", "59454c4c4f57205355424d4152494e45"}
+
+	// only the IV, and the IV plus an incomplete block.
+	for _, n := range []int{16, 31} {
+		err := run(args, bytes.NewReader(make([]byte, n)), &bytes.Buffer{})
+		if err == nil {
+			t.Errorf("%d bytes: want error, got nil", n)
+		}
+	}
+}
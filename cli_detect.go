@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ecbDetection is a line of output of the detect-ecb command.
+type ecbDetection struct {
+	// Line is the 1-based number of the line holding the cipher text.
+	Line          int     `json:"line"`
+	Duplicates    int     `json:"duplicates"`
+	Blocks        int     `json:"blocks"`
+	FalsePositive float64 `json:"falsePositive"`
+}
+
+// runDetectECB implements the detect-ecb command, which reads a file of cipher
+// texts, one per line, and prints the ones that look encrypted with AES ECB,
+// most likely first.
+func runDetectECB(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs          = flag.NewFlagSet("detect-ecb", flag.ContinueOnError)
+		encoding    = fs.String("encoding", "hex", "cipher texts' encoding: base64 or hex")
+		parallelism = fs.Int("parallelism", 0, "cipher texts to score in parallel (default GOMAXPROCS)")
+		asJSON      = fs.Bool("json", false, "print the results as JSON, one object per line")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("detect-ecb: %w", err)
+	}
+	if fs.NArg() > 1 {
+		return errors.New("detect-ecb: too many files; usage: detect-ecb [flags] [file]")
+	}
+
+	c, ok := _codecs[*encoding]
+	if !ok {
+		return fmt.Errorf("detect-ecb: unsupported encoding %q", *encoding)
+	}
+
+	input := stdin
+	if path := fs.Arg(0); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("detect-ecb: %w", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	// the corpus is streamed, and only the candidates are kept in memory, so
+	// that we can scan files much larger than the available memory.
+	var (
+		candidates []ecbCandidate
+		mu         sync.Mutex
+	)
+	err := scanCorpus(context.Background(), input, c, *parallelism, func(line corpusLine) error {
+		score, err := detectAesEcbScore(line.data)
+		if err != nil || score.duplicates == 0 {
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		candidates = append(candidates, ecbCandidate{line: line.num - 1, score: score})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("detect-ecb: %w", err)
+	}
+	rankEcbCandidates(candidates)
+	if len(candidates) == 0 && !*asJSON {
+		_, err := fmt.Fprintln(stdout, "no cipher text has repeated blocks")
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	for _, c := range candidates {
+		d := ecbDetection{
+			Line:          c.line + 1,
+			Duplicates:    c.score.duplicates,
+			Blocks:        c.score.blocks,
+			FalsePositive: c.score.falsePositive,
+		}
+		if *asJSON {
+			err = enc.Encode(d)
+		} else {
+			const formatStr = "line %d: %d duplicate blocks out of %d (false positive probability %.3g)\n"
+			_, err = fmt.Fprintf(stdout, formatStr, d.Line, d.Duplicates, d.Blocks, d.FalsePositive)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
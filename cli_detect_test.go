@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDetectECB(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"detect-ecb", "./files/1_8.txt"}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "line 133: 3 duplicate blocks out of 10"
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("output does not start with %q:\n%s", want, out.String())
+	}
+}
+
+func TestDetectECBJSON(t *testing.T) {
+	// a line of ECB (two equal blocks) between two lines of random looking
+	// data, read from stdin.
+	input := strings.Join([]string{
+		"00112233445566778899aabbccddeeff0123456789abcdef0123456789abcdef",
+		strings.Repeat("59454c4c4f57205355424d4152494e45", 2),
+		"",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := run([]string{"detect-ecb", "-json"}, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var d ecbDetection
+	if err := json.Unmarshal(out.Bytes(), &d); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Line != 2 || d.Duplicates != 1 || d.Blocks != 2 {
+		t.Errorf("unexpected detection %+v", d)
+	}
+}
+
+func TestDetectECBBadInput(t *testing.T) {
+	err := run([]string{"detect-ecb"}, strings.NewReader("not hex\n"), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("want an error about line 1, got %v", err)
+	}
+}
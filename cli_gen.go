@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// _genCommands maps the name of each gen subcommand to its implementation.
+var _genCommands = map[string]command{
+	"xor-repeating": {
+		summary: "a text encrypted with repeating-key XOR (challenge 6)",
+		run:     runGenXORRepeating,
+	},
+	"ecb-suffix": {
+		summary: "a secret for the ecb-suffix oracle of the serve command (challenge 12)",
+		run:     runGenECBSuffix,
+	},
+	"ctr-fixed-nonce": {
+		summary: "texts encrypted with AES-CTR under the same nonce (challenges 19 and 20)",
+		run:     runGenCTRFixedNonce,
+	},
+}
+
+// runGen implements the gen command, which generates a fresh variant of the
+// exercise named by the first of the given arguments: its data file, and a
+// file with its answers (an artifact file, see writeArtifacts) to check
+// solutions against.
+func runGen(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(genUsage())
+	}
+
+	cmd, ok := _genCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown exercise %q\n%s", args[0], genUsage())
+	}
+
+	return cmd.run(args[1:], stdin, stdout)
+}
+
+// genUsage returns the list of the exercises that can be generated.
+func genUsage() string {
+	names := make([]string, 0, len(_genCommands))
+	for name := range _genCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("usage: cryptopals gen <exercise> [flags]\nexercises:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-16s %s\n", name, _genCommands[name].summary)
+	}
+
+	return b.String()
+}
+
+// genFlags are the flags shared by the gen commands.
+type genFlags struct {
+	out     string
+	answers string
+}
+
+// newGenFlagSet returns a FlagSet for the gen command with the given name,
+// with the shared flags bound to gf.
+func newGenFlagSet(name string, gf *genFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&gf.out, "out", "", "data file of the exercise (default stdout)")
+	fs.StringVar(&gf.answers, "answers", "", "answers file of the exercise (required)")
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// write writes the data and the answers of ex to the files set by the flags.
+// The data is written to stdout if no data file is set.
+func (gf *genFlags) write(ex exercise, data []byte, stdout io.Writer) error {
+	if err := saveArtifacts(gf.answers, ex.answers...); err != nil {
+		return err
+	}
+
+	if gf.out == "" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(gf.out, data, 0o644)
+}
+
+// runGenXORRepeating implements the "gen xor-repeating" command.
+func runGenXORRepeating(args []string, _ io.Reader, stdout io.Writer) error {
+	var (
+		gf     genFlags
+		fs     = newGenFlagSet("xor-repeating", &gf)
+		keyLen = fs.Int("key-len", 16, "length of the key, in bytes")
+		words  = fs.Int("words", 200, "number of words of the plain text")
+	)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+	if gf.answers == "" {
+		return errors.New("xor-repeating: -answers is required")
+	}
+
+	ex, err := genXORRepeatingExercise(*keyLen, *words)
+	if err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+
+	// base64, 60 characters per line, like the file of challenge 6.
+	data := wrapLines(base64.StdEncoding.EncodeToString(ex.cipherTexts[0]), 60)
+	if err := gf.write(ex, []byte(data), stdout); err != nil {
+		return fmt.Errorf("xor-repeating: %w", err)
+	}
+	return nil
+}
+
+// runGenECBSuffix implements the "gen ecb-suffix" command. The exercise has no
+// data file: it prints how to serve the oracle, as hard as the difficulty
+// flags say.
+func runGenECBSuffix(args []string, _ io.Reader, stdout io.Writer) error {
+	var (
+		gf        genFlags
+		fs        = newGenFlagSet("ecb-suffix", &gf)
+		words     = fs.Int("words", 20, "number of words of the secret")
+		maxPrefix = fs.Int("max-prefix", 0, "largest random prefix the oracle prepends to its input (0: none)")
+		budget    = fs.Int64("budget", 0, "queries the oracle answers (0: no limit)")
+		noise     = fs.Float64("noise", 0, "probability that a query fails, or that its cipher text is truncated")
+	)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+	if gf.answers == "" {
+		return errors.New("ecb-suffix: -answers is required")
+	}
+
+	ex, err := genECBSuffixExercise(*words)
+	if err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+
+	d := difficulty{
+		secretLen: len(ex.answers[0].data),
+		maxPrefix: *maxPrefix,
+		budget:    *budget,
+		noise:     *noise,
+	}
+	if err := d.validate(); err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+
+	var usage strings.Builder
+	usage.WriteString("cryptopals serve -oracle ")
+	if d.maxPrefix > 0 {
+		fmt.Fprintf(&usage, "random-prefix -max-prefix %d", d.maxPrefix)
+	} else {
+		usage.WriteString("ecb-suffix")
+	}
+	if d.budget > 0 {
+		fmt.Fprintf(&usage, " -budget %d", d.budget)
+	}
+	if d.noise > 0 {
+		fmt.Fprintf(&usage, " -noise %g", d.noise)
+	}
+	fmt.Fprintf(&usage, " -answers %s\n", gf.answers)
+
+	if err := gf.write(ex, []byte(usage.String()), stdout); err != nil {
+		return fmt.Errorf("ecb-suffix: %w", err)
+	}
+	return nil
+}
+
+// runGenCTRFixedNonce implements the "gen ctr-fixed-nonce" command.
+func runGenCTRFixedNonce(args []string, _ io.Reader, stdout io.Writer) error {
+	var (
+		gf    genFlags
+		fs    = newGenFlagSet("ctr-fixed-nonce", &gf)
+		lines = fs.Int("lines", 40, "number of cipher texts")
+		words = fs.Int("words", 10, "largest number of words of a plain text")
+	)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("ctr-fixed-nonce: %w", err)
+	}
+	if gf.answers == "" {
+		return errors.New("ctr-fixed-nonce: -answers is required")
+	}
+
+	ex, err := genCTRFixedNonceExercise(*lines, *words)
+	if err != nil {
+		return fmt.Errorf("ctr-fixed-nonce: %w", err)
+	}
+
+	// base64, a cipher text per line, like the file of challenge 20.
+	var data strings.Builder
+	for _, ct := range ex.cipherTexts {
+		data.WriteString(base64.StdEncoding.EncodeToString(ct) + "\n")
+	}
+	if err := gf.write(ex, []byte(data.String()), stdout); err != nil {
+		return fmt.Errorf("ctr-fixed-nonce: %w", err)
+	}
+	return nil
+}
+
+// wrapLines splits s into lines of width characters, each ending with a
+// newline.
+func wrapLines(s string, width int) string {
+	var b strings.Builder
+	for start := 0; start < len(s); start += width {
+		b.WriteString(s[start:min(start+width, len(s))])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
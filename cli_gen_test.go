@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestGenXORRepeating(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		data    = filepath.Join(dir, "challenge.txt")
+		answers = filepath.Join(dir, "answers.cpa")
+	)
+
+	args := []string{"gen", "xor-repeating", "-key-len", "5", "-words", "400", "-out", data, "-answers", answers}
+	if err := run(args, nil, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	artifacts, err := loadArtifacts(answers)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	plainText, err := answerSecret(artifacts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"crack", "xor-repeating", "-in", data}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), string(plainText[:40])) {
+		t.Errorf("output does not contain %q:\n%s", plainText[:40], out.String())
+	}
+}
+
+func TestGenECBSuffix(t *testing.T) {
+	answers := filepath.Join(t.TempDir(), "answers.cpa")
+
+	var out bytes.Buffer
+	if err := run([]string{"gen", "ecb-suffix", "-words", "5", "-answers", answers}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := "serve -oracle ecb-suffix -answers " + answers; !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+
+	artifacts, err := loadArtifacts(answers)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	secret, err := answerSecret(artifacts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// an empty plain text: the oracle encrypts the secret alone.
+	var reply bytes.Buffer
+	if err := runServe([]string{"-answers", answers}, strings.NewReader("\n"), &reply); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cipherText := testutil.MustDecodeHex(t, strings.TrimSpace(reply.String()))
+	if want := (len(secret)/16 + 1) * 16; len(cipherText) != want {
+		t.Errorf("want a %d-byte cipher text, got %d bytes", want, len(cipherText))
+	}
+}
+
+func TestGenECBSuffixDifficulty(t *testing.T) {
+	answers := filepath.Join(t.TempDir(), "answers.cpa")
+
+	args := []string{"gen", "ecb-suffix", "-max-prefix", "16", "-budget", "2", "-noise", "0.05", "-answers", answers}
+	var out bytes.Buffer
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := "serve -oracle random-prefix -max-prefix 16 -budget 2 -noise 0.05 -answers " + answers
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out.String())
+	}
+
+	// the oracle refuses the queries over its budget.
+	var reply bytes.Buffer
+	if err := runServe([]string{"-answers", answers, "-budget", "2"}, strings.NewReader("\n\n\n"), &reply); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(reply.String()), "\n")
+	if len(lines) != 3 || strings.HasPrefix(lines[1], _oracleErrorPrefix) || !strings.HasPrefix(lines[2], _oracleErrorPrefix) {
+		t.Errorf("want 2 cipher texts and an error, got:\n%s", reply.String())
+	}
+}
+
+func TestGenCTRFixedNonce(t *testing.T) {
+	answers := filepath.Join(t.TempDir(), "answers.cpa")
+
+	var out bytes.Buffer
+	if err := run([]string{"gen", "ctr-fixed-nonce", "-lines", "5", "-answers", answers}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 5 {
+		t.Errorf("want 5 lines, got %d:\n%s", lines, out.String())
+	}
+}
+
+func TestGenErrors(t *testing.T) {
+	answers := filepath.Join(t.TempDir(), "answers.cpa")
+
+	for _, args := range [][]string{
+		{"gen"},
+		{"gen", "padding-oracle", "-answers", answers},
+		{"gen", "xor-repeating"},
+		{"gen", "xor-repeating", "-key-len", "0", "-answers", answers},
+		{"gen", "ecb-suffix", "-bogus"},
+		{"gen", "ecb-suffix", "-noise", "1", "-answers", answers},
+		{"gen", "ecb-suffix", "-budget", "-1", "-answers", answers},
+	} {
+		if err := run(args, nil, &bytes.Buffer{}); err == nil {
+			t.Errorf("%v: want error, got nil", args)
+		}
+	}
+
+	if err := runServe([]string{"-secret", "s", "-answers", answers}, strings.NewReader(""), &bytes.Buffer{}); err == nil {
+		t.Error("want error for -secret and -answers, got nil")
+	}
+	if err := runServe([]string{"-noise", "-0.1"}, strings.NewReader(""), &bytes.Buffer{}); err == nil {
+		t.Error("want error for invalid -noise, got nil")
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	if got, want := wrapLines("abcdefg", 3), "abc\ndef\ng\n"; got != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+}
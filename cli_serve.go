@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runServe implements the serve command, which runs one of the package's
+// oracles over stdin and stdout, using the line protocol of subprocessOracle.
+// This lets attacks written in other languages target our oracles, and ours
+// target the command itself (e.g., crack ecb-suffix -oracle-cmd).
+func runServe(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs        = flag.NewFlagSet("serve", flag.ContinueOnError)
+		kind      = fs.String("oracle", "ecb-suffix", "oracle to serve: ecb-suffix or random-prefix")
+		secret    = fs.String("secret", "", "secret appended by the oracle (default challenge 12's)")
+		answers   = fs.String("answers", "", "take the secret from the answers file of an exercise (see gen)")
+		maxPrefix = fs.Int("max-prefix", 32, "largest random prefix of the random-prefix oracle")
+		compress  = fs.String("compress", "none", "compress plain texts before encrypting them: none, flate or gzip")
+		bucket    = fs.Int("bucket", 0, "pad plain texts to a multiple of this many bytes to hide their length (0: don't)")
+		budget    = fs.Int64("budget", 0, "queries answered before refusing any more (0: no limit)")
+		noise     = fs.Float64("noise", 0, "probability that a query fails, or that its cipher text is truncated")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	var sp secretProvider = _challenge12Secret
+	switch {
+	case *secret != "" && *answers != "":
+		return errors.New("serve: -secret and -answers are mutually exclusive")
+	case *secret != "":
+		sp = staticSecret(*secret)
+	case *answers != "":
+		artifacts, err := loadArtifacts(*answers)
+		if err != nil {
+			return fmt.Errorf("serve: %w", err)
+		}
+		s, err := answerSecret(artifacts)
+		if err != nil {
+			return fmt.Errorf("serve: %w", err)
+		}
+		sp = staticSecret(s)
+	}
+
+	c, err := parseCompression(*compress)
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+	opts := []oracleOption{withCompression(c), withLengthBuckets(*bucket)}
+
+	if *budget < 0 {
+		return errors.New("serve: -budget can't be negative")
+	}
+	if *noise < 0 || *noise >= 1 {
+		return errors.New("serve: -noise must be in [0, 1)")
+	}
+	// the secret and the prefix are up to the oracle.
+	d := difficulty{budget: *budget, noise: *noise}
+
+	var oracle aesOracle
+	switch *kind {
+	case "ecb-suffix":
+		oracle, err = ecbEncryptionOracle(sp, opts...)
+	case "random-prefix":
+		if *maxPrefix < 0 {
+			return errors.New("serve: -max-prefix can't be negative")
+		}
+		oracle, err = randomPrefixEcbOracle(sp, *maxPrefix, opts...)
+	default:
+		return fmt.Errorf("serve: unknown oracle %q", *kind)
+	}
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return serveOracle(d.degrade(oracle), stdin, stdout)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// runSets implements the sets command, which runs the implemented challenges
+// of the given sets (all of them by default) in order, checks their outputs
+// against their golden files and prints a checklist per set.
+func runSets(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("sets", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("sets: %w", err)
+	}
+
+	sets := _sets
+	if fs.NArg() > 0 {
+		sets = nil
+		for _, arg := range fs.Args() {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("sets: invalid set number %q", arg)
+			}
+			s, ok := findSet(n)
+			if !ok {
+				return fmt.Errorf("sets: set %d has no implemented challenges", n)
+			}
+			sets = append(sets, s)
+		}
+	}
+
+	var failed, total int
+	for i, s := range sets {
+		if i > 0 {
+			if _, err := fmt.Fprintln(stdout); err != nil {
+				return err
+			}
+		}
+
+		results := runSet(s)
+		n, err := writeChecklist(stdout, s, results)
+		if err != nil {
+			return err
+		}
+		failed += n
+		total += len(results)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("sets: %d of %d challenges failed", failed, total)
+	}
+	return nil
+}
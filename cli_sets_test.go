@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetsCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"sets", "2"}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out.String())
+	}
+
+	for _, want := range []string{"Set 2: Block crypto", "[x] 14. Byte-at-a-time ECB decryption (Harder)", "7/7 challenges passed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Set 1") {
+		t.Errorf("output contains set 1, which was not asked for:\n%s", out.String())
+	}
+}
+
+func TestSetsCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"not a number", []string{"sets", "two"}, `invalid set number "two"`},
+		{"unknown set", []string{"sets", "8"}, "set 8 has no implemented challenges"},
+		{"unknown flag", []string{"sets", "-v"}, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args, nil, &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("want error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
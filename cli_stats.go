@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// runStats implements the stats command, which summarizes the runs kept in a
+// results store (see the -record flag of the crack commands), so that attacks
+// can be compared with each other and with their past selves.
+func runStats(args []string, _ io.Reader, stdout io.Writer) error {
+	var (
+		fs     = flag.NewFlagSet("stats", flag.ContinueOnError)
+		attack = fs.String("attack", "", "only summarize the runs of the given attack")
+		since  = fs.Duration("since", 0, "only summarize the runs started within the given time (e.g., 24h)")
+		asJSON = fs.Bool("json", false, "print the statistics as JSON, one object per attack")
+	)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	if fs.NArg() != 1 {
+		return errors.New("stats: usage: stats [flags] <results store>")
+	}
+
+	records, err := loadRunRecords(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+
+	var (
+		filtered []runRecord
+		cutoff   = time.Now().Add(-*since)
+	)
+	for _, r := range records {
+		if *attack != "" && r.Attack != *attack {
+			continue
+		}
+		if *since > 0 && r.Time.Before(cutoff) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	if len(filtered) == 0 {
+		return fmt.Errorf("stats: %w", errNoRuns)
+	}
+
+	stats := summarizeRuns(filtered)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		for _, s := range stats {
+			if err := enc.Encode(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ATTACK\tRUNS\tOK\tMEAN\tMIN\tMAX\tORACLE CALLS\tLAST RUN")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%.0f\t%s\n",
+			s.Attack, s.Runs, s.Successes,
+			s.MeanDuration.Round(time.Microsecond),
+			s.MinDuration.Round(time.Microsecond),
+			s.MaxDuration.Round(time.Microsecond),
+			s.MeanOracleCalls,
+			s.Last.Format(time.DateTime),
+		)
+	}
+	return tw.Flush()
+}
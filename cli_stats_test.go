@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatsAfterRecordedRuns(t *testing.T) {
+	const cipherText = "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"
+
+	store := filepath.Join(t.TempDir(), "runs.jsonl")
+	for range 3 {
+		args := []string{"crack", "xor-single", "-record", store}
+		if err := run(args, strings.NewReader(cipherText), &bytes.Buffer{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	args := []string{"crack", "xor-repeating", "-in", "./files/1_6.txt", "-record", store}
+	if err := run(args, nil, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"stats", "-json", store}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		dec   = json.NewDecoder(&out)
+		stats []attackStats
+	)
+	for dec.More() {
+		var s attackStats
+		if err := dec.Decode(&s); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("want 2 attacks, got %d", len(stats))
+	}
+	if s := stats[0]; s.Attack != "xor-repeating" || s.Runs != 1 || s.Successes != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s := stats[1]; s.Attack != "xor-single" || s.Runs != 3 || s.Successes != 3 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	out.Reset()
+	if err := run([]string{"stats", "-attack", "xor-single", store}, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(out.String(), "xor-repeating") || !strings.Contains(out.String(), "xor-single") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	err := run([]string{"stats", "-attack", "ecb-suffix", store}, nil, &out)
+	if !errors.Is(err, errNoRuns) {
+		t.Errorf("want %v, got %v", errNoRuns, err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// codec defines a text encoding of binary data.
+type codec struct {
+	encode func([]byte) string
+	decode func(string) ([]byte, error)
+}
+
+// _codecs maps the name of each supported text encoding to its codec.
+var _codecs = map[string]codec{
+	"hex": {
+		encode: hex.EncodeToString,
+		decode: hex.DecodeString,
+	},
+	"base64": {
+		encode: base64.StdEncoding.EncodeToString,
+		decode: base64.StdEncoding.DecodeString,
+	},
+}
+
+// withCodec adapts fn, which works on binary data (e.g., an encryption
+// function with its key bound, or an aesOracle), to work on data encoded with
+// the given codec: the returned function decodes its input, passes it to fn,
+// and encodes fn's output.
+//
+//	encrypt := withCodec(_codecs["hex"], func(pt []byte) ([]byte, error) {
+//		return encryptAesEcb(pt, key)
+//	})
+//	cipherText, err := encrypt("68656c6c6f")
+func withCodec(
+	c codec,
+	fn func([]byte) ([]byte, error),
+) func(string) (string, error) {
+
+	return func(encoded string) (string, error) {
+		in, err := c.decode(encoded)
+		if err != nil {
+			return "", fmt.Errorf("decoding input: %w", err)
+		}
+
+		out, err := fn(in)
+		if err != nil {
+			return "", err
+		}
+
+		return c.encode(out), nil
+	}
+}
+
+// withHex is withCodec for hex encoded data.
+func withHex(fn func([]byte) ([]byte, error)) func(string) (string, error) {
+	return withCodec(_codecs["hex"], fn)
+}
+
+// withBase64 is withCodec for base64 encoded data.
+func withBase64(fn func([]byte) ([]byte, error)) func(string) (string, error) {
+	return withCodec(_codecs["base64"], fn)
+}
+
+// encodedOracle is the inverse of withCodec for oracles: it turns an oracle
+// that takes and returns data encoded with the given codec (e.g., one
+// implemented by a web service) into an aesOracle the attacks can use.
+func encodedOracle(c codec, oracle func(string) (string, error)) aesOracle {
+	return func(plainText []byte) ([]byte, error) {
+		encoded, err := oracle(c.encode(plainText))
+		if err != nil {
+			return nil, err
+		}
+
+		cipherText, err := c.decode(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding oracle's output: %w", err)
+		}
+
+		return cipherText, nil
+	}
+}
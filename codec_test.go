@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWithCodec(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+
+	encrypt := withHex(func(plainText []byte) ([]byte, error) {
+		return encryptAesEcb(plainText, key)
+	})
+	decrypt := withHex(func(cipherText []byte) ([]byte, error) {
+		plainText, err := decryptAesEcb(cipherText, key)
+		return delPadPkcs7(plainText), err
+	})
+
+	// "hello, world"
+	const plainText = "68656c6c6f2c20776f726c64"
+
+	cipherText, err := encrypt(plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := decrypt(cipherText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != plainText {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, got)
+	}
+
+	if _, err := encrypt("not hex"); err == nil {
+		t.Errorf("expected an error for malformed input")
+	}
+}
+
+func TestWithBase64(t *testing.T) {
+	identity := withBase64(func(data []byte) ([]byte, error) { return data, nil })
+
+	const encoded = "SGVsbG8gV29ybGQ="
+	got, err := identity(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != encoded {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", encoded, got)
+	}
+}
+
+func TestEncodedOracle(t *testing.T) {
+	o, err := ecbEncryptionOracle(staticSecret("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// an oracle speaking base64, turned back into a binary one.
+	roundTrip := encodedOracle(_codecs["base64"], withBase64(o))
+
+	secret, err := decryptOracleSecret(roundTrip)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := delPadPkcs7(secret); !bytes.Equal(got, []byte("YELLOW SUBMARINE")) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "YELLOW SUBMARINE", got)
+	}
+}
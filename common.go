@@ -2,8 +2,11 @@ package main
 
 import (
 	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
-	mrand "math/rand/v2"
+	"fmt"
+	"io"
+	"runtime"
 )
 
 // aesWorker defines a type that performs an AES encryption/decryption
@@ -12,14 +15,32 @@ type aesWorker func([]byte) []byte
 
 // aesOracle defines a type that encrypts/decrypts a given plain/cipher text
 // using AES.
+// Oracles must be safe for concurrent use by multiple goroutines, so that the
+// attacks can query them in parallel. They must not modify their input, and
+// they must return a cipher/plain text that doesn't share memory with their
+// input or their own state.
 type aesOracle func([]byte) ([]byte, error)
 
+var (
+	// errNotBlockAligned is returned when data that must be made of whole
+	// blocks (e.g., a cipher text or an IV) isn't.
+	errNotBlockAligned = errors.New("data is not block aligned")
+
+	// errLengthMismatch is returned when two inputs that must have the same
+	// length don't.
+	errLengthMismatch = errors.New("inputs have different lengths")
+
+	// errInvalidPadding is returned when data doesn't end with valid PKCS#7
+	// padding.
+	errInvalidPadding = errors.New("invalid PKCS#7 padding")
+)
+
 // xorBlocks takes two byte slices, b1 and b2, and returns a new byte slice
 // containing the result of a byte-wise XOR operation between corresponding
 // elements of b1 and b2
 func xorBlocks(b1, b2 []byte) ([]byte, error) {
 	if len(b1) != len(b2) {
-		return nil, errors.New("input blocks are of different lengths")
+		return nil, fmt.Errorf("%w: input blocks are %d and %d bytes long", errLengthMismatch, len(b1), len(b2))
 	}
 
 	xored := make([]byte, len(b1))
@@ -32,13 +53,53 @@ func xorBlocks(b1, b2 []byte) ([]byte, error) {
 
 // randomBytes generates returns a slice of size min <= x <= max (chosen
 // randomly) filled with random bytes.
+//
+// Deprecated: use randomBytesN for a fixed number of bytes, and
+// randomBytesRange for a random one.
 func randomBytes(min, max int) ([]byte, error) {
-	var (
-		nBytes = mrand.IntN(max-min+1) + min
-		buf    = make([]byte, nBytes)
-	)
-	if _, err := crand.Read(buf); err != nil {
-		return nil, err
+	return randomBytesRange(min, max)
+}
+
+// randomBytesN returns n bytes read from crypto/rand.
+func randomBytesN(n int) ([]byte, error) {
+	return readRandomBytes(crand.Reader, n)
+}
+
+// randomBytesRange returns a slice of size min <= x <= max (chosen randomly)
+// filled with bytes read from crypto/rand.
+func randomBytesRange(min, max int) ([]byte, error) {
+	return randomBytesRangeFrom(crand.Reader, min, max)
+}
+
+// randomBytesRangeFrom is like randomBytesRange, but it reads both the size of
+// the slice and its bytes from r. With a deterministic r (e.g., the source of
+// newInsecureKeyGenerator, or a bytes.Reader), it always returns the same
+// bytes, which is handy in tests.
+func randomBytesRangeFrom(r io.Reader, min, max int) ([]byte, error) {
+	if min < 0 || min > max {
+		return nil, fmt.Errorf("invalid range [%d, %d]", min, max)
+	}
+
+	var sizeBuf [8]byte
+	if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+		return nil, fmt.Errorf("reading random size: %w", err)
+	}
+	// the modulo makes the smaller sizes slightly more likely, which doesn't
+	// matter for the small ranges we use.
+	size := min + int(binary.LittleEndian.Uint64(sizeBuf[:])%uint64(max-min+1))
+
+	return readRandomBytes(r, size)
+}
+
+// readRandomBytes returns n bytes read from r.
+func readRandomBytes(r io.Reader, n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid number of bytes %d", n)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("reading random bytes: %w", err)
 	}
 
 	return buf, nil
@@ -58,6 +119,9 @@ func delPadPkcs7String(data string) string {
 // By always adding an additional block of padding, the decrypted message
 // clearly indicates the presence of padding bytes, which can be correctly
 // removed.
+// It doesn't validate the padding, which makes it suitable for the output of
+// the attacks (e.g., a recovered secret that ends with a single byte of
+// padding). Use unpadPkcs7 to validate it.
 func delPadPkcs7(data []byte) []byte {
 	if len(data) == 0 {
 		return data
@@ -65,3 +129,50 @@ func delPadPkcs7(data []byte) []byte {
 	pad := int(data[len(data)-1])
 	return data[:len(data)-pad]
 }
+
+// concatInto writes the concatenation of parts into dst, reusing its
+// underlying array if it's large enough, and returns the resulting slice.
+// It lets loops that build many plain texts reuse the same buffer instead of
+// allocating a new one each time. dst must not overlap with any of the parts.
+func concatInto(dst []byte, parts ...[]byte) []byte {
+	var size int
+	for _, p := range parts {
+		size += len(p)
+	}
+
+	if cap(dst) < size {
+		dst = make([]byte, 0, size)
+	}
+	dst = dst[:0]
+
+	for _, p := range parts {
+		dst = append(dst, p...)
+	}
+
+	return dst
+}
+
+// cloneBytes returns a copy of b whose capacity equals its length, so that
+// appending to the copy never writes into memory shared with anything else.
+// It returns nil if b is nil.
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	c := make([]byte, len(b))
+	copy(c, b)
+
+	return c
+}
+
+// secureZero overwrites b with zeros. Use it to wipe key material once it's no
+// longer needed, so that it doesn't linger in memory.
+// It always writes every byte, and runtime.KeepAlive prevents the compiler
+// from optimizing the writes away because b is not used afterwards.
+func secureZero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
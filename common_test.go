@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestConcatInto(t *testing.T) {
+	buf := make([]byte, 0, 32)
+
+	got := concatInto(buf, []byte("YELLOW"), []byte(" "), []byte("SUBMARINE"))
+	if string(got) != "YELLOW SUBMARINE" {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "YELLOW SUBMARINE", got)
+	}
+	if &got[0] != &buf[:1][0] {
+		t.Errorf("buffer with enough capacity was not reused")
+	}
+
+	got = concatInto(buf, bytes.Repeat([]byte("A"), 40))
+	if len(got) != 40 {
+		t.Errorf("want 40 bytes, got %d", len(got))
+	}
+}
+
+func TestCloneBytes(t *testing.T) {
+	orig := make([]byte, 4, 16)
+	copy(orig, "ABCD")
+
+	c := cloneBytes(orig)
+	if cap(c) != len(c) {
+		t.Errorf("want capacity %d, got %d", len(c), cap(c))
+	}
+
+	c[0] = 'X'
+	if orig[0] != 'A' {
+		t.Errorf("clone shares memory with the original")
+	}
+
+	if cloneBytes(nil) != nil {
+		t.Errorf("want nil clone of a nil slice")
+	}
+}
+
+func TestSecureZero(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	secureZero(key)
+
+	if !bytes.Equal(key, make([]byte, 16)) {
+		t.Errorf("key was not zeroed: %q", key)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	var (
+		key        = []byte("YELLOW SUBMARINE")
+		misaligned = make([]byte, 17)
+	)
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "ECB decryption",
+			err:  second(decryptAesEcb(misaligned, key)),
+			want: errNotBlockAligned,
+		},
+		{
+			name: "ECB decryption, stdlib",
+			err:  second(decryptAesEcb(misaligned, key, withStdlib())),
+			want: errNotBlockAligned,
+		},
+		{
+			name: "CBC decryption",
+			err:  second(decryptAesCbc(misaligned, key, make([]byte, 16))),
+			want: errNotBlockAligned,
+		},
+		{
+			name: "CBC IV",
+			err:  second(encryptAesCbc(nil, key, make([]byte, 5))),
+			want: errNotBlockAligned,
+		},
+		{
+			name: "chunks",
+			err:  second(toChunks(misaligned, 16)),
+			want: errNotBlockAligned,
+		},
+		{
+			name: "xor",
+			err:  second(xorHexStrings("aabb", "aa")),
+			want: errLengthMismatch,
+		},
+		{
+			name: "hamming distance",
+			err:  second(hammingDistance([]byte("a"), []byte("ab"))),
+			want: errLengthMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: want %q error, got %v", tt.name, tt.want, tt.err)
+		}
+	}
+}
+
+func TestErrorsAreWrapped(t *testing.T) {
+	decode := withHex(func(data []byte) ([]byte, error) { return data, nil })
+
+	_, err := decode("zz")
+
+	var hexErr hex.InvalidByteError
+	if !errors.As(err, &hexErr) {
+		t.Errorf("want a hex.InvalidByteError in the chain, got %v", err)
+	}
+}
+
+// second returns its second argument, which lets tests use the error returned
+// by a function call in an expression.
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func TestRandomBytes(t *testing.T) {
+	b, err := randomBytesN(24)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(b) != 24 {
+		t.Errorf("want 24 bytes, got %d", len(b))
+	}
+
+	for range 100 {
+		b, err := randomBytesRange(5, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(b) < 5 || len(b) > 10 {
+			t.Fatalf("want 5 to 10 bytes, got %d", len(b))
+		}
+	}
+
+	if _, err := randomBytesRange(10, 5); err == nil {
+		t.Errorf("expected an error for an empty range")
+	}
+}
+
+func TestRandomBytesRangeFrom(t *testing.T) {
+	// the size is read first: 3 (little endian), so 2 + 3%4 = 5 bytes.
+	source := slices.Concat([]byte{3, 0, 0, 0, 0, 0, 0, 0}, []byte("abcdefgh"))
+
+	got, err := randomBytesRangeFrom(bytes.NewReader(source), 2, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got) != "abcde" {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "abcde", got)
+	}
+
+	if _, err := randomBytesRangeFrom(bytes.NewReader(nil), 2, 5); err == nil {
+		t.Errorf("expected an error for an exhausted source")
+	}
+}
+
+// hammerOracle queries the oracle from many goroutines at once and checks that
+// every answer passes check. Run it with -race to catch shared state.
+func hammerOracle(t *testing.T, oracle aesOracle, check func(in, out []byte) error) {
+	t.Helper()
+
+	const (
+		goroutines = 16
+		queries    = 50
+	)
+
+	var g errgroup.Group
+	for n := range goroutines {
+		g.Go(func() error {
+			for q := range queries {
+				in := []byte(fmt.Sprintf("user%d.%d@bar.com", n, q))
+				out, err := oracle(in)
+				if err != nil {
+					return err
+				}
+				if err := check(in, out); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestOraclesAreConcurrencySafe(t *testing.T) {
+	secret := []byte("YELLOW SUBMARINE")
+
+	ecbOracle, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	prefixOracle, err := randomPrefixEcbOracle(staticSecret(secret), 32)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the oracles must not share the secret with its provider.
+	secret[0] = 'X'
+
+	t.Run("ecbEncryptionOracle", func(t *testing.T) {
+		want, err := ecbOracle(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		hammerOracle(t, ecbOracle, checkSuffixLen(len(secret)))
+
+		got, err := ecbOracle(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("the oracle's answer changed after concurrent use")
+		}
+	})
+
+	t.Run("randomPrefixEcbOracle", func(t *testing.T) {
+		hammerOracle(t, prefixOracle, func(_, out []byte) error {
+			if len(out)%16 != 0 {
+				return fmt.Errorf("cipher text of %d bytes is not block aligned", len(out))
+			}
+			return nil
+		})
+	})
+
+	t.Run("profile oracles", func(t *testing.T) {
+		svc, err := newProfileService()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		encrypt, isAdmin, err := newProfileOracles(svc)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		hammerOracle(t, encrypt, func(_, out []byte) error {
+			admin, err := isAdmin(out)
+			if err != nil {
+				return err
+			}
+			if admin {
+				return errors.New("a user profile was reported as admin")
+			}
+			return nil
+		})
+	})
+
+	t.Run("hardened profile oracles", func(t *testing.T) {
+		encrypt, isAdmin, err := newHardenedProfileOracles()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		hammerOracle(t, encrypt, func(_, out []byte) error {
+			admin, err := isAdmin(out)
+			if err != nil {
+				return err
+			}
+			if admin {
+				return errors.New("a user profile was reported as admin")
+			}
+			return nil
+		})
+	})
+
+	t.Run("wrappers", func(t *testing.T) {
+		oracle, calls := countOracleCalls(jitteryOracle(ecbOracle, 0))
+		hammerOracle(t, oracle, checkSuffixLen(len(secret)))
+		if got := calls(); got != 16*50 {
+			t.Errorf("want %d calls, got %d", 16*50, got)
+		}
+	})
+}
+
+// checkSuffixLen returns a check for hammerOracle that verifies the length of
+// the cipher text returned by an oracle appending a secret of secretLen bytes
+// to its input and padding the result.
+func checkSuffixLen(secretLen int) func(in, out []byte) error {
+	return func(in, out []byte) error {
+		want := (len(in)+secretLen)/16*16 + 16
+		if len(out) != want {
+			return fmt.Errorf("got %d bytes of cipher text, want %d", len(out), want)
+		}
+		return nil
+	}
+}
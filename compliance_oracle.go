@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// compliancePolicy defines a type that checks whether a plain text is
+// acceptable, and returns the index of the first offending byte, or -1 if
+// there is none.
+type compliancePolicy func(plainText []byte) int
+
+// asciiPolicy accepts plain texts made only of ASCII bytes (i.e., < 0x80).
+func asciiPolicy(plainText []byte) int {
+	for i, b := range plainText {
+		if b >= utf8.RuneSelf {
+			return i
+		}
+	}
+	return -1
+}
+
+// utf8Policy accepts plain texts that are valid UTF-8.
+func utf8Policy(plainText []byte) int {
+	for i := 0; i < len(plainText); {
+		r, size := utf8.DecodeRune(plainText[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// printablePolicy accepts plain texts made only of printable ASCII characters
+// (from ' ' to '~').
+func printablePolicy(plainText []byte) int {
+	for i, b := range plainText {
+		if b < ' ' || b > '~' {
+			return i
+		}
+	}
+	return -1
+}
+
+// complianceError is returned by a complianceOracle when the decrypted plain
+// text violates its policy. Like a careless server would, it reveals the
+// whole plain text.
+type complianceError struct {
+	plainText []byte
+
+	// pos is the index of the first offending byte of plainText.
+	pos int
+}
+
+func (e *complianceError) Error() string {
+	const formatStr = "plain text has an invalid byte (%#x) at position %d: %q"
+	return fmt.Sprintf(formatStr, e.plainText[e.pos], e.pos, e.plainText)
+}
+
+// complianceOracle defines a type that decrypts a cipher text and reports
+// whether its plain text complies with a policy. If it doesn't, the error is a
+// *complianceError.
+type complianceOracle func(cipherText []byte) error
+
+// complianceOptions configures newComplianceOracles.
+type complianceOptions struct {
+	keyAsIV bool
+}
+
+// complianceOption defines a type that sets an option of
+// newComplianceOracles.
+type complianceOption func(*complianceOptions)
+
+// withKeyAsIV makes the oracles use the key as the IV, instead of a random IV
+// for each plain text. This is the mistake challenge 27 of set 4 exploits.
+func withKeyAsIV() complianceOption {
+	return func(o *complianceOptions) {
+		o.keyAsIV = true
+	}
+}
+
+// newComplianceOracles returns two oracles sharing the same (randomly
+// generated) key:
+//   - an aesOracle that encrypts a plain text with AES CBC.
+//   - a complianceOracle that decrypts a cipher text produced by the first
+//     oracle, and rejects it if its plain text violates the given policy.
+//
+// By default each plain text is encrypted under a random IV, and the
+// encryption oracle returns [IV || cipher text]. With withKeyAsIV, it returns
+// only the cipher text.
+func newComplianceOracles(
+	policy compliancePolicy,
+	opts ...complianceOption,
+) (aesOracle, complianceOracle, error) {
+
+	var options complianceOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	recordGroundTruth("newComplianceOracles", key, nil)
+
+	encryptionOracle := func(plainText []byte) ([]byte, error) {
+		if options.keyAsIV {
+			return encryptAesCbc(plainText, key, key, withStdlib())
+		}
+
+		iv, err := newIV(aes.BlockSize)
+		if err != nil {
+			return nil, fmt.Errorf("generating random IV: %w", err)
+		}
+
+		cipherText, err := encryptAesCbc(plainText, key, iv, withStdlib())
+		if err != nil {
+			return nil, err
+		}
+		return concatInto(nil, iv, cipherText), nil
+	}
+
+	isCompliant := func(cipherText []byte) error {
+		iv := key
+		if !options.keyAsIV {
+			if len(cipherText) < aes.BlockSize {
+				return errors.New("cipher text is shorter than the IV")
+			}
+			iv, cipherText = cipherText[:aes.BlockSize], cipherText[aes.BlockSize:]
+		}
+
+		plainText, err := decryptAesCbc(cipherText, key, iv, withStdlib())
+		if err != nil {
+			return err
+		}
+
+		plainText, err = unpadPkcs7Exact(plainText, aes.BlockSize)
+		if err != nil {
+			return err
+		}
+
+		if pos := policy(plainText); pos >= 0 {
+			return &complianceError{plainText: plainText, pos: pos}
+		}
+		return nil
+	}
+
+	return encryptionOracle, isCompliant, nil
+}
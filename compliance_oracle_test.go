@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+)
+
+func TestCompliancePolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy compliancePolicy
+		data   string
+		want   int
+	}{
+		{"ASCII ok", asciiPolicy, "ICE ICE BABY\n", -1},
+		{"ASCII bad", asciiPolicy, "caf\xc3\xa9", 3},
+		{"UTF-8 ok", utf8Policy, "caf\xc3\xa9", -1},
+		{"UTF-8 bad", utf8Policy, "caf\xc3", 3},
+		{"printable ok", printablePolicy, "ICE ICE BABY", -1},
+		{"printable bad", printablePolicy, "ICE ICE BABY\n", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy([]byte(tt.data)); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestComplianceOracles(t *testing.T) {
+	encrypt, isCompliant, err := newComplianceOracles(asciiPolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	plainText := []byte("comment1=cooking%20MCs;userdata=foo")
+	cipherText, err := encrypt(plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := isCompliant(cipherText); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// flipping the high bit of a byte of the IV flips the same bit of the
+	// first byte of the plain text.
+	cipherText[0] ^= 0x80
+	var complErr *complianceError
+	if err := isCompliant(cipherText); !errors.As(err, &complErr) {
+		t.Fatalf("want a *complianceError, got %v", err)
+	}
+	if complErr.pos != 0 || !bytes.Equal(complErr.plainText[1:], plainText[1:]) {
+		t.Errorf("unexpected offending plain text %q at %d", complErr.plainText, complErr.pos)
+	}
+}
+
+func TestComplianceOraclesKeyAsIV(t *testing.T) {
+	encrypt, isCompliant, err := newComplianceOracles(asciiPolicy, withKeyAsIV())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cipherText, err := encrypt(bytes.Repeat([]byte("YELLOW SUBMARINE"), 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// challenge 27: send [C1 || 0 || C1 || C2...] and the plain text leaks
+	// P1' = P1 and P3' = P1 ^ IV = P1 ^ key. The original blocks that follow
+	// keep the padding valid.
+	const bs = aes.BlockSize
+	var (
+		c1     = cipherText[:bs]
+		forged = concatInto(nil, c1, make([]byte, bs), c1, cipherText[bs:])
+	)
+
+	var complErr *complianceError
+	if err := isCompliant(forged); !errors.As(err, &complErr) {
+		t.Fatalf("want a *complianceError, got %v", err)
+	}
+
+	key, err := xorBlocks(complErr.plainText[:bs], complErr.plainText[2*bs:3*bs])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	decrypted, err := decryptAesCbc(cipherText, key, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.HasPrefix(decrypted, []byte("YELLOW SUBMARINE")) {
+		t.Errorf("recovered key doesn't decrypt the cipher text: %q", decrypted)
+	}
+}
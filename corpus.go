@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"runtime"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// _maxCorpusLineLen is the longest line of a corpus we accept. It bounds the
+// memory used to read a corpus, regardless of its size.
+const _maxCorpusLineLen = 1 << 20
+
+// corpusLine is a decoded line of a corpus of encoded cipher texts.
+type corpusLine struct {
+	// num is the 1-based number of the line in the corpus.
+	num  int
+	data []byte
+}
+
+// corpusLines returns an iterator over the lines of r, each decoded with the
+// given codec. Empty lines are skipped.
+// Lines are read one at a time, so memory use doesn't depend on the size of
+// the corpus. If a line can't be read or decoded, the iterator yields the
+// error and stops.
+func corpusLines(r io.Reader, c codec) iter.Seq2[corpusLine, error] {
+	return func(yield func(corpusLine, error) bool) {
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 0, 64*1024), _maxCorpusLineLen)
+
+		var num int
+		for s.Scan() {
+			num++
+
+			line := bytes.TrimSpace(s.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			data, err := c.decode(string(line))
+			if err != nil {
+				yield(corpusLine{num: num}, fmt.Errorf("line %d: %w", num, err))
+				return
+			}
+			if !yield(corpusLine{num: num, data: data}, nil) {
+				return
+			}
+		}
+
+		if err := s.Err(); err != nil {
+			yield(corpusLine{num: num + 1}, fmt.Errorf("line %d: %w", num+1, err))
+		}
+	}
+}
+
+// scanCorpus reads the lines of r, decoded with the given codec, and passes
+// them to fn from workers goroutines (GOMAXPROCS if <= 0). fn must be safe for
+// concurrent use, and lines can be processed out of order.
+// At most a few lines per worker are in memory at any time: the reader waits
+// for the workers when they fall behind.
+// It stops at the first error, either reading the corpus or returned by fn,
+// and returns it.
+func scanCorpus(
+	ctx context.Context,
+	r io.Reader,
+	c codec,
+	workers int,
+	fn func(corpusLine) error,
+) error {
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		g, gCtx = errgroup.WithContext(ctx)
+		lines   = make(chan corpusLine, workers)
+	)
+
+	g.Go(func() error {
+		defer close(lines)
+
+		for line, err := range corpusLines(r, c) {
+			if err != nil {
+				return err
+			}
+
+			select {
+			case lines <- line:
+			case <-gCtx.Done():
+				return gCtx.Err()
+			}
+		}
+		return nil
+	})
+
+	for range workers {
+		g.Go(func() error {
+			for line := range lines {
+				if err := fn(line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestCorpusLines(t *testing.T) {
+	f, err := os.Open("./files/1_8.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	want := testutil.MustLoadHexLines(t, "./files/1_8.txt")
+
+	var n int
+	for line, err := range corpusLines(f, _codecs["hex"]) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if line.num != n+1 || !bytes.Equal(line.data, want[n]) {
+			t.Fatalf("line %d doesn't match the file", n+1)
+		}
+		n++
+	}
+	if n != len(want) {
+		t.Errorf("want %d lines, got %d", len(want), n)
+	}
+}
+
+func TestCorpusLinesErrors(t *testing.T) {
+	tests := map[string]io.Reader{
+		"line 2":    strings.NewReader("00ff\n\nzz\n"),
+		"too long":  strings.NewReader(strings.Repeat("00", _maxCorpusLineLen)),
+		"truncated": strings.NewReader("0"),
+	}
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			var lastErr error
+			for _, err := range corpusLines(r, _codecs["hex"]) {
+				lastErr = err
+			}
+			if lastErr == nil {
+				t.Errorf("want an error, got none")
+			}
+		})
+	}
+}
+
+// repeatedLines is an io.Reader producing the same line n times without
+// holding them in memory.
+type repeatedLines struct {
+	line []byte
+	n    int
+	buf  []byte
+}
+
+func (r *repeatedLines) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.n == 0 {
+			return 0, io.EOF
+		}
+		r.n--
+		r.buf = r.line
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestScanCorpus(t *testing.T) {
+	const nLines = 100_000
+
+	var (
+		r     = &repeatedLines{line: []byte(strings.Repeat("59454c4c4f57205355424d4152494e45", 2) + "\n"), n: nLines}
+		lines atomic.Int64
+		sum   atomic.Int64
+	)
+	err := scanCorpus(context.Background(), r, _codecs["hex"], 8, func(line corpusLine) error {
+		lines.Add(1)
+		sum.Add(int64(line.num))
+		if len(line.data) != 32 {
+			return errors.New("wrong line length")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if lines.Load() != nLines || sum.Load() != nLines*(nLines+1)/2 {
+		t.Errorf("want %d lines, got %d", nLines, lines.Load())
+	}
+}
+
+func TestScanCorpusStopsOnError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var (
+		r     = &repeatedLines{line: []byte("00ff\n"), n: 1_000_000}
+		calls atomic.Int64
+	)
+	err := scanCorpus(context.Background(), r, _codecs["hex"], 4, func(line corpusLine) error {
+		calls.Add(1)
+		if line.num == 10 {
+			return errBoom
+		}
+		return nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("want %v, got %v", errBoom, err)
+	}
+	if calls.Load() > 1000 {
+		t.Errorf("the error didn't stop the scan: %d lines processed", calls.Load())
+	}
+}
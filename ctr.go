@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+)
+
+// _ctrNonceSize is the size of the nonce of encryptAesCtr. The other half of
+// the counter block is the block counter.
+const _ctrNonceSize = 8
+
+// encryptAesCtr encrypts (or decrypts, it's the same operation) data with AES
+// in CTR mode, with the counter blocks of challenge 18: the 8-byte nonce,
+// followed by the 64-bit little endian count of blocks so far.
+// CTR turns AES into a stream cipher: it XORs data with the encryption of the
+// successive counter blocks, so it needs no padding, and the cipher text is as
+// long as the plain text. Like any stream cipher, encrypting two messages with
+// the same key and nonce reveals the XOR of their plain texts (see
+// breakChachaNonceReuse).
+func encryptAesCtr(data, key, nonce []byte) ([]byte, error) {
+	if len(nonce) != _ctrNonceSize {
+		return nil, fmt.Errorf("invalid CTR nonce size %d; want %d", len(nonce), _ctrNonceSize)
+	}
+
+	encrypter, err := aesEncrypter(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out          = make([]byte, len(data))
+		counterBlock = make([]byte, aes.BlockSize)
+	)
+	copy(counterBlock, nonce)
+	for b, block := range blockRange(data, aes.BlockSize) {
+		binary.LittleEndian.PutUint64(counterBlock[_ctrNonceSize:], uint64(b))
+
+		var (
+			start     = b * aes.BlockSize
+			keystream = encrypter(counterBlock)
+		)
+		for i, char := range block {
+			out[start+i] = char ^ keystream[i]
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncryptAesCtr(t *testing.T) {
+	// challenge 18 of set 3.
+	cipherText, err := base64.StdEncoding.DecodeString(
+		"L77na/nrFsKvynd6HzOoG7GHTLXsTVu9qvY/2syLXzhPweyyMTJULu/6/kXX0KSvoOLSFQ==",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var (
+		key   = []byte("YELLOW SUBMARINE")
+		nonce = make([]byte, _ctrNonceSize)
+		want  = "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby "
+	)
+
+	plainText, err := encryptAesCtr(cipherText, key, nonce)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(plainText) != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, plainText)
+	}
+
+	reEncrypted, err := encryptAesCtr(plainText, key, nonce)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(reEncrypted, cipherText) {
+		t.Errorf("\nwant:\t%x\ngot:\t%x\n", cipherText, reEncrypted)
+	}
+}
+
+func TestEncryptAesCtrInvalidNonce(t *testing.T) {
+	if _, err := encryptAesCtr([]byte("data"), []byte("YELLOW SUBMARINE"), make([]byte, 12)); err == nil {
+		t.Error("want error for invalid nonce size, got nil")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// DER tags of the universal types we support.
+const (
+	_derTagInteger     = 0x02
+	_derTagOctetString = 0x04
+	_derTagNull        = 0x05
+	_derTagOID         = 0x06
+	_derTagSequence    = 0x30
+)
+
+// errMalformedDER is returned when parsing data that isn't valid DER.
+var errMalformedDER = errors.New("malformed DER")
+
+// derElement is a DER encoded element: a tag, and the bytes of its value.
+// For constructed elements (e.g., a SEQUENCE), the value holds the encoding
+// of the children.
+type derElement struct {
+	tag   byte
+	value []byte
+}
+
+// derParser parses DER encoded data.
+// By default it's strict: it only accepts the one valid DER encoding of an
+// element, as a signature verifier must. In lenient mode it accepts some
+// of the BER-isms and sloppiness that real parsers got wrong, which is what
+// signature forgeries (e.g., Bleichenbacher's e=3 attack) exploit:
+//   - lengths in long form, or with leading zeros, when the short form would
+//     do.
+//   - INTEGERs with redundant leading 0x00 or 0xff bytes.
+//   - trailing bytes after the top level element.
+type derParser struct {
+	lenient bool
+}
+
+// parse parses a single top level element from data. In strict mode, data
+// must hold nothing else.
+func (p derParser) parse(data []byte) (derElement, error) {
+	e, rest, err := p.next(data)
+	if err != nil {
+		return derElement{}, err
+	}
+	if len(rest) > 0 && !p.lenient {
+		return derElement{}, fmt.Errorf("%w: %d trailing bytes", errMalformedDER, len(rest))
+	}
+	return e, nil
+}
+
+// next parses the element at the beginning of data, and returns it along with
+// the bytes that follow it.
+func (p derParser) next(data []byte) (derElement, []byte, error) {
+	if len(data) < 2 {
+		return derElement{}, nil, fmt.Errorf("%w: truncated element", errMalformedDER)
+	}
+
+	tag := data[0]
+	if tag&0x1f == 0x1f {
+		return derElement{}, nil, fmt.Errorf("%w: multi-byte tags are not supported", errMalformedDER)
+	}
+
+	var (
+		length   = int(data[1])
+		valueOff = 2
+	)
+	if length&0x80 != 0 {
+		nBytes := length & 0x7f
+		if nBytes == 0 {
+			return derElement{}, nil, fmt.Errorf("%w: indefinite length", errMalformedDER)
+		}
+		if nBytes > 4 || len(data) < 2+nBytes {
+			return derElement{}, nil, fmt.Errorf("%w: invalid length of length %d", errMalformedDER, nBytes)
+		}
+
+		length = 0
+		for _, b := range data[2 : 2+nBytes] {
+			length = length<<8 | int(b)
+		}
+		valueOff += nBytes
+
+		if !p.lenient && (data[2] == 0 || length < 0x80) {
+			return derElement{}, nil, fmt.Errorf("%w: non-minimal length", errMalformedDER)
+		}
+	}
+
+	if length > len(data)-valueOff {
+		return derElement{}, nil, fmt.Errorf("%w: length %d exceeds the data", errMalformedDER, length)
+	}
+
+	var (
+		end = valueOff + length
+		e   = derElement{tag: tag, value: data[valueOff:end:end]}
+	)
+	return e, data[end:], nil
+}
+
+// children parses the value of a constructed element (e.g., a SEQUENCE) into
+// its children.
+func (p derParser) children(e derElement) ([]derElement, error) {
+	if e.tag&0x20 == 0 {
+		return nil, fmt.Errorf("%w: tag %#x is not constructed", errMalformedDER, e.tag)
+	}
+
+	var (
+		children []derElement
+		data     = e.value
+	)
+	for len(data) > 0 {
+		child, rest, err := p.next(data)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		data = rest
+	}
+
+	return children, nil
+}
+
+// integer parses the value of an INTEGER element.
+func (p derParser) integer(e derElement) (*big.Int, error) {
+	if e.tag != _derTagInteger {
+		return nil, fmt.Errorf("%w: tag %#x is not an INTEGER", errMalformedDER, e.tag)
+	}
+
+	v := e.value
+	if len(v) == 0 {
+		return nil, fmt.Errorf("%w: empty INTEGER", errMalformedDER)
+	}
+	if !p.lenient && len(v) > 1 && (v[0] == 0x00 && v[1]&0x80 == 0 || v[0] == 0xff && v[1]&0x80 != 0) {
+		return nil, fmt.Errorf("%w: non-minimal INTEGER", errMalformedDER)
+	}
+
+	n := new(big.Int).SetBytes(v)
+	if v[0]&0x80 != 0 {
+		// two's complement: subtract 2^(8*len).
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(v))))
+	}
+	return n, nil
+}
+
+// appendDER appends the DER encoding of an element with the given tag and
+// value to dst, using the minimal length encoding.
+func appendDER(dst []byte, tag byte, value []byte) []byte {
+	dst = append(dst, tag)
+
+	n := len(value)
+	switch {
+	case n < 0x80:
+		dst = append(dst, byte(n))
+	case n <= 0xff:
+		dst = append(dst, 0x81, byte(n))
+	case n <= 0xffff:
+		dst = append(dst, 0x82, byte(n>>8), byte(n))
+	case n <= 0xffffff:
+		dst = append(dst, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	default:
+		dst = append(dst, 0x84, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+
+	return append(dst, value...)
+}
+
+// derSequence returns the DER encoding of a SEQUENCE of the given DER encoded
+// elements.
+func derSequence(elements ...[]byte) []byte {
+	var value []byte
+	for _, e := range elements {
+		value = append(value, e...)
+	}
+	return appendDER(nil, _derTagSequence, value)
+}
+
+// derInteger returns the DER encoding of an INTEGER, in minimal two's
+// complement.
+func derInteger(n *big.Int) []byte {
+	var value []byte
+	switch n.Sign() {
+	case 0:
+		value = []byte{0}
+
+	case 1:
+		value = n.Bytes()
+		if value[0]&0x80 != 0 {
+			value = append([]byte{0}, value...)
+		}
+
+	default:
+		// the two's complement of a negative n of k bytes is 2^(8k) + n,
+		// with k the smallest such that the top bit is set.
+		k := (new(big.Int).Not(n).BitLen())/8 + 1
+		value = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(8*k)), n).Bytes()
+	}
+
+	return appendDER(nil, _derTagInteger, value)
+}
+
+// derOctetString returns the DER encoding of an OCTET STRING.
+func derOctetString(b []byte) []byte {
+	return appendDER(nil, _derTagOctetString, b)
+}
+
+// derNull returns the DER encoding of NULL.
+func derNull() []byte {
+	return []byte{_derTagNull, 0}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestDERSignatureRoundTrip(t *testing.T) {
+	r, _ := new(big.Int).SetString("8b7a1b0e9c7f4a3e62d05d2b9c3f0e11aa", 16)
+	s := big.NewInt(-12345)
+
+	encoded := derSequence(derInteger(r), derInteger(s))
+
+	// encoding/asn1 must agree with us on the encoding.
+	want, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("\nwant:\t%x\ngot:\t%x\n", want, encoded)
+	}
+
+	var p derParser
+	seq, err := p.parse(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	children, err := p.children(seq)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := p.integer(seq); !errors.Is(err, errMalformedDER) {
+		t.Errorf("SEQUENCE as INTEGER: want %v, got %v", errMalformedDER, err)
+	}
+	if len(children) != 2 {
+		t.Fatalf("want 2 children, got %d", len(children))
+	}
+
+	for i, want := range []*big.Int{r, s} {
+		got, err := p.integer(children[i])
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("\nwant:\t%s\ngot:\t%s\n", want, got)
+		}
+	}
+}
+
+func TestDERLongLength(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 255, 256, 70000} {
+		value := bytes.Repeat([]byte{0xaa}, n)
+		encoded := derOctetString(value)
+
+		want, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !bytes.Equal(encoded, want) {
+			t.Fatalf("length %d:\nwant:\t%x\ngot:\t%x\n", n, want[:8], encoded[:min(8, len(encoded))])
+		}
+
+		e, err := derParser{}.parse(encoded)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if e.tag != _derTagOctetString || !bytes.Equal(e.value, value) {
+			t.Errorf("length %d: wrong element parsed", n)
+		}
+	}
+}
+
+func TestDERStrictness(t *testing.T) {
+	tests := map[string]struct {
+		data []byte
+		// lenientOK is whether the lenient parser accepts data.
+		lenientOK bool
+	}{
+		"truncated":            {[]byte{0x04, 0x05, 1, 2}, false},
+		"indefinite length":    {[]byte{0x30, 0x80, 0x00, 0x00}, false},
+		"multi-byte tag":       {[]byte{0x1f, 0x81, 0x00, 0x00}, false},
+		"long form short len":  {[]byte{0x04, 0x81, 0x01, 0xaa}, true},
+		"length leading zero":  {append([]byte{0x04, 0x82, 0x00, 0x80}, make([]byte, 0x80)...), true},
+		"trailing garbage":     {[]byte{0x05, 0x00, 0xde, 0xad}, true},
+		"integer leading zero": {[]byte{0x02, 0x02, 0x00, 0x01}, true},
+		"integer leading 0xff": {[]byte{0x02, 0x02, 0xff, 0x80}, true},
+		"empty integer":        {[]byte{0x02, 0x00}, false},
+		"length of length 5":   {[]byte{0x04, 0x85, 0, 0, 0, 0, 1, 0xaa}, false},
+		"child exceeds parent": {[]byte{0x30, 0x03, 0x02, 0x05, 0x01}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := parseDERInteger(derParser{}, test.data); !errors.Is(err, errMalformedDER) {
+				t.Errorf("strict: want %v, got %v", errMalformedDER, err)
+			}
+
+			err := parseDERInteger(derParser{lenient: true}, test.data)
+			if test.lenientOK && err != nil {
+				t.Errorf("lenient: unexpected error: %s", err)
+			}
+			if !test.lenientOK && !errors.Is(err, errMalformedDER) {
+				t.Errorf("lenient: want %v, got %v", errMalformedDER, err)
+			}
+		})
+	}
+}
+
+// parseDERInteger parses data with p, and then parses its INTEGERs, if any.
+func parseDERInteger(p derParser, data []byte) error {
+	e, err := p.parse(data)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.tag&0x20 != 0:
+		children, err := p.children(e)
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			if c.tag == _derTagInteger {
+				if _, err := p.integer(c); err != nil {
+					return err
+				}
+			}
+		}
+	case e.tag == _derTagInteger:
+		_, err := p.integer(e)
+		return err
+	}
+
+	return nil
+}
+
+func FuzzDERParse(f *testing.F) {
+	f.Add(derSequence(derInteger(big.NewInt(-129)), derOctetString([]byte("sig")), derNull()))
+	f.Add([]byte{0x04, 0x81, 0x01, 0xaa})
+	f.Add([]byte{0x30, 0x80, 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var (
+			strict  derParser
+			lenient = derParser{lenient: true}
+		)
+
+		e, err := strict.parse(data)
+		if err != nil {
+			if !errors.Is(err, errMalformedDER) {
+				t.Fatalf("want %v, got %v", errMalformedDER, err)
+			}
+			lenient.parse(data)
+			return
+		}
+
+		// strict DER has exactly one encoding, so re-encoding what we parsed
+		// must give back the input.
+		if got := appendDER(nil, e.tag, e.value); !bytes.Equal(got, data) {
+			t.Fatalf("\nwant:\t%x\ngot:\t%x\n", data, got)
+		}
+
+		// whatever the strict parser accepts, the lenient one accepts too.
+		le, err := lenient.parse(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if le.tag != e.tag || !bytes.Equal(le.value, e.value) {
+			t.Fatal("strict and lenient parsers disagree")
+		}
+
+		if e.tag == _derTagInteger {
+			n, err := strict.integer(e)
+			if err != nil {
+				return
+			}
+			if got := derInteger(n); !bytes.Equal(got, data) {
+				t.Fatalf("\nwant:\t%x\ngot:\t%x\n", data, got)
+			}
+		}
+		if e.tag&0x20 != 0 {
+			strict.children(e)
+		}
+	})
+}
+
+func FuzzDERInteger(f *testing.F) {
+	for _, seed := range []int64{0, 1, -1, 127, 128, -128, -129, 255, 256, -256} {
+		f.Add(big.NewInt(seed).Bytes(), seed < 0)
+	}
+
+	f.Fuzz(func(t *testing.T, magnitude []byte, negative bool) {
+		want := new(big.Int).SetBytes(magnitude)
+		if negative {
+			want.Neg(want)
+		}
+
+		encoded := derInteger(want)
+		if asn1Encoded, err := asn1.Marshal(want); err != nil || !bytes.Equal(encoded, asn1Encoded) {
+			t.Fatalf("\nwant:\t%x\ngot:\t%x\n", asn1Encoded, encoded)
+		}
+
+		var p derParser
+		e, err := p.parse(encoded)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		got, err := p.integer(e)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Fatalf("\nwant:\t%s\ngot:\t%s\n", want, got)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// ecbCodebook maps blocks of cipher text to the blocks of plain text they
+// encrypt, under a single (unknown) ECB key.
+// Since ECB encrypts equal plain text blocks to equal cipher text blocks,
+// every block we learn the decryption of can be recognized, and "decrypted",
+// in any other cipher text encrypted with the same key. This generalizes the
+// cut-and-paste attack of challenge 13: rather than moving blocks around, we
+// read them.
+type ecbCodebook struct {
+	blockSize int
+
+	// entries maps a cipher text block to its plain text block.
+	entries map[string][]byte
+}
+
+// newECBCodebook returns an empty codebook for a cipher with the given block
+// size.
+func newECBCodebook(blockSize int) *ecbCodebook {
+	return &ecbCodebook{
+		blockSize: blockSize,
+		entries:   make(map[string][]byte),
+	}
+}
+
+// len returns the number of blocks in the codebook.
+func (cb *ecbCodebook) len() int {
+	return len(cb.entries)
+}
+
+// add records that cipherBlock is the encryption of plainBlock.
+func (cb *ecbCodebook) add(cipherBlock, plainBlock []byte) error {
+	if len(cipherBlock) != cb.blockSize || len(plainBlock) != cb.blockSize {
+		const formatStr = "blocks must be %d bytes long, got %d and %d"
+		return fmt.Errorf(formatStr, cb.blockSize, len(cipherBlock), len(plainBlock))
+	}
+
+	cb.entries[string(cipherBlock)] = cloneBytes(plainBlock)
+	return nil
+}
+
+// learn adds all the blocks of a known (plain text, cipher text) pair to the
+// codebook. The plain text must be the one encrypted, i.e., padded if the
+// encryption pads. If the plain text is shorter than the cipher text (e.g.,
+// the padding is unknown), the blocks it doesn't cover are skipped.
+func (cb *ecbCodebook) learn(plainText, cipherText []byte) error {
+	if len(cipherText)%cb.blockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the block size (%d)"
+		return fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), cb.blockSize)
+	}
+
+	for start := 0; start+cb.blockSize <= min(len(plainText), len(cipherText)); start += cb.blockSize {
+		end := start + cb.blockSize
+		if err := cb.add(cipherText[start:end], plainText[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// learnChosen uses an encryption oracle to add the given plain text blocks to
+// the codebook. The oracle must encrypt the plain text after a prefix of
+// prefixLen bytes (e.g., the "email=" of challenge 13's profiles), which we
+// align to a block boundary with filler bytes before the chosen blocks.
+// The chosen blocks must survive whatever encoding the oracle applies to its
+// input unchanged.
+func (cb *ecbCodebook) learnChosen(
+	encryptionOracle aesOracle,
+	prefixLen int,
+	blocks [][]byte,
+) error {
+
+	var (
+		start     = alignUp(prefixLen, cb.blockSize)
+		fillerLen = start - prefixLen
+		plainText = make([]byte, fillerLen, fillerLen+len(blocks)*cb.blockSize)
+	)
+	for i := range plainText {
+		plainText[i] = 'A'
+	}
+	for _, block := range blocks {
+		if len(block) != cb.blockSize {
+			const formatStr = "chosen blocks must be %d bytes long, got %d"
+			return fmt.Errorf(formatStr, cb.blockSize, len(block))
+		}
+		plainText = append(plainText, block...)
+	}
+
+	cipherText, err := encryptionOracle(plainText)
+	if err != nil {
+		return err
+	}
+	if len(cipherText) < start+len(blocks)*cb.blockSize {
+		return fmt.Errorf("cipher text is too short: %d bytes", len(cipherText))
+	}
+
+	for i, block := range blocks {
+		if err := cb.add(blockAt(cipherText[start:], i, cb.blockSize), block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decrypt decrypts the blocks of cipherText found in the codebook. It returns
+// the plain text, where the blocks not in the codebook are left zeroed, and
+// the indexes of those blocks.
+func (cb *ecbCodebook) decrypt(cipherText []byte) ([]byte, []int, error) {
+	if len(cipherText)%cb.blockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a multiple of the block size (%d)"
+		return nil, nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), cb.blockSize)
+	}
+
+	var (
+		plainText = make([]byte, len(cipherText))
+		unknown   []int
+	)
+	for blockIdx, block := range blockRange(cipherText, cb.blockSize) {
+		if plainBlock, ok := cb.entries[string(block)]; ok {
+			copy(blockAt(plainText, blockIdx, cb.blockSize), plainBlock)
+		} else {
+			unknown = append(unknown, blockIdx)
+		}
+	}
+
+	return plainText, unknown, nil
+}
+
+// blockCount is the number of occurrences of a cipher text block.
+type blockCount struct {
+	block []byte
+	count int
+}
+
+// repeatedBlocks counts how many times each block appears in the given ECB
+// cipher texts, and returns the blocks appearing more than once, most
+// frequent first (ties are sorted by first appearance).
+// With many cipher texts of similarly structured plain texts (like challenge
+// 13's profiles), the most frequent blocks are the encryptions of the fixed
+// parts of the structure, which is often enough to guess their plain text and
+// add them to a codebook.
+func repeatedBlocks(cipherTexts [][]byte, blockSize int) []blockCount {
+	var (
+		counts = make(map[string]int)
+		order  []string
+	)
+	for _, cipherText := range cipherTexts {
+		for block := range chunks(cipherText, blockSize) {
+			if counts[string(block)] == 0 {
+				order = append(order, string(block))
+			}
+			counts[string(block)]++
+		}
+	}
+
+	var repeated []blockCount
+	for _, block := range order {
+		if counts[block] > 1 {
+			repeated = append(repeated, blockCount{block: []byte(block), count: counts[block]})
+		}
+	}
+	slices.SortStableFunc(repeated, func(a, b blockCount) int {
+		return cmp.Compare(b.count, a.count)
+	})
+
+	return repeated
+}
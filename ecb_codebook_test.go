@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"slices"
+	"testing"
+)
+
+func TestECBCodebook(t *testing.T) {
+	svc, err := newProfileService(withFixedUID(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	encryptionOracle, _, err := newProfileOracles(svc)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cb := newECBCodebook(aes.BlockSize)
+
+	// known plain text: we know what our own profile looks like.
+	const email = "alice@example.com"
+	profile, err := svc.profileFor(email)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cipherText, err := encryptionOracle([]byte(email))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := cb.learn(padPkcs7([]byte(profile), aes.BlockSize), cipherText); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// chosen plain text: the email follows "email=".
+	chosen := []byte("bobbobbobbobbobb")
+	if err := cb.learnChosen(encryptionOracle, len("email="), [][]byte{chosen}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// someone else's profile with the same email decrypts entirely.
+	victim, err := encryptionOracle([]byte(email))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	plainText, unknown, err := cb.decrypt(victim)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(unknown) != 0 {
+		t.Errorf("unknown blocks: %v", unknown)
+	}
+	if got := delPadPkcs7(plainText); string(got) != profile {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", profile, got)
+	}
+
+	// this one only contains the chosen block, as block 1.
+	victim, err = encryptionOracle([]byte("xxxxxxxxxxbobbobbobbobbobb@evil.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	plainText, unknown, err = cb.decrypt(victim)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(plainText[aes.BlockSize:2*aes.BlockSize], chosen) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", chosen, plainText[aes.BlockSize:2*aes.BlockSize])
+	}
+	if slices.Contains(unknown, 1) || !slices.Contains(unknown, 0) {
+		t.Errorf("unexpected unknown blocks: %v", unknown)
+	}
+}
+
+func TestRepeatedBlocks(t *testing.T) {
+	var (
+		a = bytes.Repeat([]byte{'a'}, 4)
+		b = bytes.Repeat([]byte{'b'}, 4)
+		c = bytes.Repeat([]byte{'c'}, 4)
+	)
+	cipherTexts := [][]byte{
+		slices.Concat(a, b, c),
+		slices.Concat(b, b),
+		slices.Concat(c, a),
+	}
+
+	got := repeatedBlocks(cipherTexts, 4)
+
+	want := []blockCount{{block: b, count: 3}, {block: a, count: 2}, {block: c, count: 2}}
+	if len(got) != len(want) {
+		t.Fatalf("want %d repeated blocks, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i].block, want[i].block) || got[i].count != want[i].count {
+			t.Errorf("block %d:\nwant:\t%q x%d\ngot:\t%q x%d\n", i, want[i].block, want[i].count, got[i].block, got[i].count)
+		}
+	}
+}
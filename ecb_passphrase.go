@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/alesforz/cryptopals/internal/parallel"
+)
+
+// errNoPassphrase is returned by crackPassphraseKey when none of the keys it
+// derives from the word list decrypts the cipher text to a plausible plain
+// text.
+var errNoPassphrase = errors.New("no passphrase key decrypts the cipher text")
+
+const (
+	// _maxPassphraseWords is the largest number of words in the passphrases
+	// tried by crackPassphraseKey. The keyspace grows with the power of the
+	// number of words: 3 words of an 850 words list are already 7 billion
+	// keys.
+	_maxPassphraseWords = 3
+
+	// _passphraseSampleBlocks is the number of blocks crackPassphraseKey
+	// decrypts to score a candidate key. A few blocks are enough to tell text
+	// from noise, and the cost of trying a key doesn't depend on the length of
+	// the cipher text.
+	_passphraseSampleBlocks = 4
+)
+
+// passphraseCase is a way to capitalize a passphrase.
+type passphraseCase int
+
+const (
+	passphraseAsIs  passphraseCase = iota // as in the word list
+	passphraseUpper                       // "YELLOW SUBMARINE"
+	passphraseTitle                       // "Yellow Submarine"
+)
+
+// _nPassphraseCases is the number of passphraseCase values.
+const _nPassphraseCases = 3
+
+// capitalize returns phrase capitalized as set by letters. Title case only
+// affects ASCII letters.
+func (letters passphraseCase) capitalize(phrase string) string {
+	switch letters {
+	case passphraseUpper:
+		return strings.ToUpper(phrase)
+	case passphraseTitle:
+		title := []byte(phrase)
+		for i, c := range title {
+			if (i == 0 || title[i-1] == ' ') && c >= 'a' && c <= 'z' {
+				title[i] = c - 'a' + 'A'
+			}
+		}
+		return string(title)
+	}
+	return phrase
+}
+
+// passphraseKeys is the keyspace of the AES-128 keys derived from the phrases
+// of 1 up to maxWords words of a word list, separated by a space, in every
+// passphraseCase and with every keyDerivation. That's how a human turns a
+// passphrase into a key, when nobody told them about key derivation functions.
+// Key returns nil for the phrases a derivation doesn't apply to.
+type passphraseKeys struct {
+	words    []string
+	maxWords int
+}
+
+// Size implements brute.Keyspace.
+func (pk passphraseKeys) Size() uint64 {
+	var (
+		phrases uint64
+		n       = uint64(1)
+	)
+	for range pk.maxWords {
+		n *= uint64(len(pk.words))
+		phrases += n
+	}
+	return phrases * _nPassphraseCases * _nKeyDerivations
+}
+
+// Key implements brute.Keyspace.
+func (pk passphraseKeys) Key(i uint64) []byte {
+	return deriveKey(pk.passphrase(i))
+}
+
+// passphrase returns the i-th passphrase of the keyspace, and the derivation
+// turning it into the i-th key.
+func (pk passphraseKeys) passphrase(i uint64) (string, keyDerivation) {
+	var (
+		derivation = keyDerivation(i % _nKeyDerivations)
+		letters    = passphraseCase(i / _nKeyDerivations % _nPassphraseCases)
+		phrase     = i / (_nKeyDerivations * _nPassphraseCases)
+		nWords     = uint64(len(pk.words))
+	)
+
+	// the phrases of 1 word come first, then the ones of 2 words, and so on.
+	var (
+		wordCount = 1
+		phrases   = nWords
+	)
+	for phrase >= phrases {
+		phrase -= phrases
+		phrases *= nWords
+		wordCount++
+	}
+
+	words := make([]string, wordCount)
+	for pos := wordCount - 1; pos >= 0; pos-- {
+		words[pos] = pk.words[phrase%nWords]
+		phrase /= nWords
+	}
+
+	return letters.capitalize(strings.Join(words, " ")), derivation
+}
+
+// passphraseMatch is a passphrase found by crackPassphraseKey, with the
+// derivation turning it into the key.
+type passphraseMatch struct {
+	passphrase string
+	derivation keyDerivation
+	key        []byte
+}
+
+// passphraseCandidate is a key tried by crackPassphraseKey, with the score of
+// the plain text it decrypts to.
+type passphraseCandidate struct {
+	key   []byte
+	index uint64
+	score float64
+}
+
+// crackPassphraseKey recovers the AES-128 key of a cipher text encrypted in ECB
+// mode with PKCS#7 padding, knowing that the key is derived from a low-entropy
+// passphrase (e.g., "YELLOW SUBMARINE"): phrases of up to maxWords words of
+// the given word list, capitalized and derived as described by
+// passphraseKeys.
+// It decrypts the last block of the cipher text with every key, discards the
+// keys that don't yield valid padding, and rates the first few blocks
+// decrypted with the others. It returns the passphrase of the best rated key
+// and the plain text it decrypts to, or errNoPassphrase if the scorer
+// rejected them all.
+// Keys are tried in parallel, one goroutine per available CPU unless set
+// otherwise with withParallelism. It also honors withScorer.
+func crackPassphraseKey(
+	cipherText []byte,
+	words []string,
+	maxWords int,
+	opts ...attackOption,
+) (passphraseMatch, []byte, error) {
+
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return passphraseMatch{}, nil, err
+	}
+
+	if len(cipherText) == 0 || len(cipherText)%aes.BlockSize != 0 {
+		const formatStr = "%w: cipher text's length (%d) is not a positive multiple of the block size (%d)"
+		return passphraseMatch{}, nil, fmt.Errorf(formatStr, errNotBlockAligned, len(cipherText), aes.BlockSize)
+	}
+	if maxWords < 1 || maxWords > _maxPassphraseWords {
+		const formatStr = "invalid number of words per passphrase: %d (must be between 1 and %d)"
+		return passphraseMatch{}, nil, fmt.Errorf(formatStr, maxWords, _maxPassphraseWords)
+	}
+
+	keyspace := passphraseKeys{maxWords: maxWords}
+	for _, w := range words {
+		if w != "" {
+			keyspace.words = append(keyspace.words, w)
+		}
+	}
+	if len(keyspace.words) == 0 {
+		return passphraseMatch{}, nil, errors.New("empty word list")
+	}
+
+	var (
+		size    = keyspace.Size()
+		workers = int(min(uint64(options.parallelism), size))
+		sample  = cipherText[:min(len(cipherText), _passphraseSampleBlocks*aes.BlockSize)]
+		last    = cipherText[len(cipherText)-aes.BlockSize:]
+	)
+	tryKeys := func(_ context.Context, worker int) (passphraseCandidate, error) {
+		var (
+			best      = passphraseCandidate{score: math.Inf(-1)}
+			lastPlain = make([]byte, aes.BlockSize)
+			plainText = make([]byte, len(sample))
+		)
+		for i := uint64(worker); i < size; i += uint64(workers) {
+			key := keyspace.Key(i)
+			if key == nil {
+				continue
+			}
+
+			// keys are 16 bytes long, so this can't fail.
+			aesCipher, _ := aes.NewCipher(key)
+			aesCipher.Decrypt(lastPlain, last)
+			if _, _, err := unpadPkcs7(lastPlain, aes.BlockSize); err != nil {
+				continue
+			}
+
+			for b, block := range blockRange(sample, aes.BlockSize) {
+				aesCipher.Decrypt(blockAt(plainText, b, aes.BlockSize), block)
+			}
+			scored := plainText
+			if len(sample) == len(cipherText) {
+				scored, _, _ = unpadPkcs7(plainText, aes.BlockSize)
+			}
+
+			if score := options.scorer(scored); score > best.score {
+				best = passphraseCandidate{key: key, index: i, score: score}
+			}
+		}
+		return best, nil
+	}
+	pickBest := func(best passphraseCandidate, _ int, c passphraseCandidate) passphraseCandidate {
+		if c.score > best.score || (c.score == best.score && c.index < best.index) {
+			return c
+		}
+		return best
+	}
+
+	best, err := parallel.MapReduce(
+		context.Background(),
+		workers,
+		workers,
+		tryKeys,
+		pickBest,
+		passphraseCandidate{score: math.Inf(-1)},
+	)
+	if err != nil {
+		return passphraseMatch{}, nil, err
+	}
+	if best.key == nil {
+		const formatStr = "%w: tried %d keys from %d words"
+		return passphraseMatch{}, nil, fmt.Errorf(formatStr, errNoPassphrase, size, len(keyspace.words))
+	}
+
+	plainText, err := decryptAesEcb(cipherText, best.key)
+	if err != nil {
+		return passphraseMatch{}, nil, err
+	}
+	plainText, err = unpadPkcs7Exact(plainText, aes.BlockSize)
+	if err != nil {
+		return passphraseMatch{}, nil, err
+	}
+
+	passphrase, derivation := keyspace.passphrase(best.index)
+	match := passphraseMatch{passphrase: passphrase, derivation: derivation, key: best.key}
+
+	return match, plainText, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestCrackPassphraseKeyChallenge7(t *testing.T) {
+	cipherText := testutil.MustLoadBase64(t, "./files/1_7.txt")
+
+	words := []string{"blue", "yellow", "green", "submarine", "boat", "train"}
+	match, plainText, err := crackPassphraseKey(cipherText, words, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "YELLOW SUBMARINE"
+	if string(match.key) != want || match.passphrase != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q (passphrase %q)\n", want, match.key, match.passphrase)
+	}
+	testutil.Golden(t, "./files/1_7.golden", plainText)
+}
+
+func TestCrackPassphraseKey(t *testing.T) {
+	var (
+		plainText = []byte("Rollin' in my 5.0, with my rag-top down so my hair can blow")
+		words     = strings.Fields(_englishWordsFile)
+	)
+
+	tests := []struct {
+		passphrase string
+		derivation keyDerivation
+	}{
+		{passphrase: "house", derivation: deriveSpaces},
+		{passphrase: "WINDOW", derivation: deriveZeros},
+		{passphrase: "Money", derivation: derivePkcs7},
+		{passphrase: "music", derivation: deriveRepeat},
+		{passphrase: "WATER", derivation: deriveMD5},
+		{passphrase: "yellow", derivation: deriveSHA1},
+		{passphrase: "Window", derivation: deriveSHA256},
+	}
+
+	for _, test := range tests {
+		t.Run(test.derivation.String(), func(t *testing.T) {
+			key := deriveKey(test.passphrase, test.derivation)
+			cipherText, err := encryptAesEcb(plainText, key)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			match, got, err := crackPassphraseKey(cipherText, words, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if match.passphrase != test.passphrase || match.derivation != test.derivation {
+				const formatStr = "\nwant:\t%q (%s)\ngot:\t%q (%s)\n"
+				t.Errorf(formatStr, test.passphrase, test.derivation, match.passphrase, match.derivation)
+			}
+			if !bytes.Equal(match.key, key) {
+				t.Errorf("\nwant:\t%q\ngot:\t%q\n", key, match.key)
+			}
+			if !bytes.Equal(got, plainText) {
+				t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, got)
+			}
+		})
+	}
+}
+
+func TestCrackPassphraseKeyNotFound(t *testing.T) {
+	cipherText, err := encryptAesEcb([]byte("a key not in the word list"), testutil.RandomKey(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	words := strings.Fields(_englishWordsFile)
+	if _, _, err := crackPassphraseKey(cipherText, words, 1); !errors.Is(err, errNoPassphrase) {
+		t.Errorf("want %v, got %v", errNoPassphrase, err)
+	}
+}
+
+func TestCrackPassphraseKeyInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		cipherText []byte
+		words      []string
+		maxWords   int
+	}{
+		{name: "empty cipher text", words: []string{"yellow"}, maxWords: 1},
+		{name: "not block aligned", cipherText: make([]byte, 17), words: []string{"yellow"}, maxWords: 1},
+		{name: "no words", cipherText: make([]byte, 16), maxWords: 1},
+		{name: "zero words per passphrase", cipherText: make([]byte, 16), words: []string{"yellow"}},
+		{name: "too many words per passphrase", cipherText: make([]byte, 16), words: []string{"yellow"}, maxWords: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := crackPassphraseKey(test.cipherText, test.words, test.maxWords); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestPassphraseKeys(t *testing.T) {
+	keyspace := passphraseKeys{words: []string{"yellow", "submarine"}, maxWords: 2}
+
+	const want = 6 * _nPassphraseCases * _nKeyDerivations
+	if got := keyspace.Size(); got != want {
+		t.Fatalf("want %d keys, got %d", want, got)
+	}
+
+	seen := make(map[string]bool)
+	for i := range keyspace.Size() {
+		if key := keyspace.Key(i); key != nil {
+			seen[string(key)] = true
+		}
+	}
+	for _, key := range []string{
+		"yellow          ",
+		"SUBMARINE\x00\x00\x00\x00\x00\x00\x00",
+		"Yellow\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a",
+		"yellowyellowyell",
+		"YELLOW SUBMARINE",
+		"Yellow Yellow   ",
+		string(deriveKey("Submarine Yellow", deriveSHA1)),
+		string(deriveKey("submarine submarine", deriveMD5)),
+	} {
+		if !seen[key] {
+			t.Errorf("key %q not in the keyspace", key)
+		}
+	}
+	if seen["submarine submarine"[:16]] {
+		t.Error("keyspace has a truncated passphrase")
+	}
+}
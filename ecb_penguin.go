@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ecbPenguin encrypts the pixel data of the BMP or PPM image at the given path
+// with AES in ECB and in CBC mode (same random key), and writes the results
+// next to the original image as <name>.ecb.<ext> and <name>.cbc.<ext>.
+// Headers are left untouched, so the encrypted images are still viewable: the
+// ECB one will show the outline of the original picture because equal blocks
+// of pixels encrypt to equal blocks of cipher text, whereas the CBC one will
+// look like random noise.
+// It returns the paths of the ECB and CBC images.
+func ecbPenguin(path string) (string, string, error) {
+	img, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("reading image: %w", err)
+	}
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return "", "", fmt.Errorf("generating random AES key: %w", err)
+	}
+	defer secureZero(key)
+
+	iv, err := newIV(aes.BlockSize)
+	if err != nil {
+		return "", "", fmt.Errorf("generating random IV: %w", err)
+	}
+
+	ecbImg, err := encryptImage(img, func(pixels []byte) ([]byte, error) {
+		return encryptAesEcb(pixels, key)
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("encrypting image with AES ECB: %w", err)
+	}
+
+	cbcImg, err := encryptImage(img, func(pixels []byte) ([]byte, error) {
+		return encryptAesCbc(pixels, key, iv)
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("encrypting image with AES CBC: %w", err)
+	}
+
+	var (
+		ext     = filepath.Ext(path)
+		base    = strings.TrimSuffix(path, ext)
+		ecbPath = base + ".ecb" + ext
+		cbcPath = base + ".cbc" + ext
+	)
+	if err := os.WriteFile(ecbPath, ecbImg, 0o644); err != nil {
+		return "", "", fmt.Errorf("writing ECB image: %w", err)
+	}
+	if err := os.WriteFile(cbcPath, cbcImg, 0o644); err != nil {
+		return "", "", fmt.Errorf("writing CBC image: %w", err)
+	}
+
+	return ecbPath, cbcPath, nil
+}
+
+// encryptImage encrypts the pixel data of the given BMP or PPM image using the
+// encrypt function, and returns a new image with the original header followed
+// by the encrypted pixels.
+// Since the encryption pads the pixel data, the cipher text is truncated to
+// the length of the original pixel data, so that the image's dimensions still
+// match its header. This means that the encrypted image can't be decrypted,
+// but we only care about looking at it.
+func encryptImage(
+	img []byte,
+	encrypt func([]byte) ([]byte, error),
+) ([]byte, error) {
+
+	header, pixels, err := splitImage(img)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encrypt(pixels)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(img))
+	copy(out, header)
+	copy(out[len(header):], encrypted[:len(pixels)])
+
+	return out, nil
+}
+
+// splitImage splits an uncompressed BMP or binary PPM (P6) image into its
+// header and its pixel data.
+func splitImage(img []byte) ([]byte, []byte, error) {
+	switch {
+	case bytes.HasPrefix(img, []byte("BM")):
+		return splitBMP(img)
+	case bytes.HasPrefix(img, []byte("P6")):
+		return splitPPM(img)
+	default:
+		return nil, nil, errors.New("unsupported image format; want BMP or PPM (P6)")
+	}
+}
+
+// splitBMP splits an uncompressed BMP image into its header and its pixel data.
+func splitBMP(img []byte) ([]byte, []byte, error) {
+	// the file header is 14 bytes long, and it's followed by the info header
+	// whose first 4 bytes store its own size. The compression method is at
+	// offset 30 of the file for all the info header versions we care about.
+	const (
+		pixelsOffsetPos = 10
+		compressionPos  = 30
+		minHeaderLen    = compressionPos + 4
+	)
+	if len(img) < minHeaderLen {
+		return nil, nil, errors.New("BMP image is too short")
+	}
+
+	const (
+		biRGB       = 0
+		biBitFields = 3
+	)
+	compression := binary.LittleEndian.Uint32(img[compressionPos:])
+	if compression != biRGB && compression != biBitFields {
+		const formatStr = "unsupported BMP compression method %d; image must be uncompressed"
+		return nil, nil, fmt.Errorf(formatStr, compression)
+	}
+
+	pixelsOffset := int(binary.LittleEndian.Uint32(img[pixelsOffsetPos:]))
+	if pixelsOffset < minHeaderLen || pixelsOffset > len(img) {
+		return nil, nil, fmt.Errorf("invalid BMP pixel data offset %d", pixelsOffset)
+	}
+
+	return img[:pixelsOffset], img[pixelsOffset:], nil
+}
+
+// splitPPM splits a binary PPM (P6) image into its header and its pixel data.
+// The header is made of 4 whitespace separated tokens (magic number, width,
+// height, and max color value), possibly interleaved with '#' comments, and
+// is terminated by a single whitespace character.
+func splitPPM(img []byte) ([]byte, []byte, error) {
+	const nTokens = 4
+
+	var (
+		pos    int
+		tokens int
+	)
+	for tokens < nTokens {
+		// skip whitespace and comments before the next token.
+		for pos < len(img) && (isPPMSpace(img[pos]) || img[pos] == '#') {
+			if img[pos] == '#' {
+				for pos < len(img) && img[pos] != '\n' {
+					pos++
+				}
+				continue
+			}
+			pos++
+		}
+
+		if pos == len(img) {
+			return nil, nil, errors.New("truncated PPM header")
+		}
+
+		for pos < len(img) && !isPPMSpace(img[pos]) {
+			pos++
+		}
+		tokens++
+	}
+
+	// the single whitespace terminating the header.
+	if pos == len(img) {
+		return nil, nil, errors.New("PPM image has no pixel data")
+	}
+	pos++
+
+	return img[:pos], img[pos:], nil
+}
+
+// isPPMSpace reports whether b is a whitespace character in a PPM header.
+func isPPMSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
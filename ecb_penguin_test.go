@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestECBPenguin(t *testing.T) {
+	const (
+		width  = 32
+		height = 32
+	)
+	header := []byte(fmt.Sprintf("P6\n# a striped image\n%d %d\n255\n", width, height))
+
+	// horizontal black and white stripes, 4 rows each.
+	pixels := make([]byte, width*height*3)
+	for row := range height {
+		if (row/4)%2 == 0 {
+			continue
+		}
+		start := row * width * 3
+		for i := start; i < start+width*3; i++ {
+			pixels[i] = 0xff
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "stripes.ppm")
+	if err := os.WriteFile(path, append(header, pixels...), 0o644); err != nil {
+		t.Fatalf("writing image: %s", err)
+	}
+
+	ecbPath, cbcPath, err := ecbPenguin(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, p := range []string{ecbPath, cbcPath} {
+		img, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("reading encrypted image: %s", err)
+		}
+
+		gotHeader, gotPixels, err := splitImage(img)
+		if err != nil {
+			t.Fatalf("splitting encrypted image: %s", err)
+		}
+		if !bytes.Equal(gotHeader, header) {
+			t.Errorf("%s: header changed:\nwant:\t%q\ngot:\t%q\n", p, header, gotHeader)
+		}
+
+		isECB := isEncryptedAesEcb(gotPixels)
+		if p == ecbPath && !isECB {
+			t.Errorf("ECB image has no repeated blocks")
+		}
+		if p == cbcPath && isECB {
+			t.Errorf("CBC image has repeated blocks")
+		}
+	}
+}
+
+func TestSplitBMP(t *testing.T) {
+	const pixelsOffset = 54
+
+	img := make([]byte, pixelsOffset+48)
+	copy(img, "BM")
+	binary.LittleEndian.PutUint32(img[10:], pixelsOffset)
+
+	header, pixels, err := splitImage(img)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(header) != pixelsOffset || len(pixels) != 48 {
+		const formatStr = "want header of %d bytes and pixels of 48 bytes, got %d and %d"
+		t.Errorf(formatStr, pixelsOffset, len(header), len(pixels))
+	}
+
+	// RLE8 compression.
+	binary.LittleEndian.PutUint32(img[30:], 1)
+	if _, _, err := splitImage(img); err == nil {
+		t.Errorf("expected an error for a compressed BMP image")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alesforz/cryptopals/internal/brute"
+	"github.com/alesforz/cryptopals/internal/parallel"
+)
+
+// decryptOracleSecretPipelined is a variant of decryptOracleSecret for slow
+// (e.g., remote) oracles, where the latency of each query, rather than our
+// own computation, dominates the attack's running time.
+// Recovering a byte of the secret depends on the bytes before it, but many
+// of the queries don't:
+//   - the target blocks of a block of the secret, i.e., that block in the
+//     encryptions of the blockSize possible fillers, are the same for each of
+//     its bytes. They are all fetched concurrently before its first byte.
+//   - the 256 guesses for a byte are independent of each other. They are sent
+//     concurrently, and the outstanding ones are dropped as soon as one of them
+//     matches.
+//
+// This hides most of the latency, at the cost of a few wasted queries per
+// byte: up to the number of guesses in flight when the match comes back.
+// Only the target blocks of the current block are kept, rather than the
+// cipher texts of the fillers, so the attack's memory doesn't grow with the
+// secret; that takes blockSize queries per block instead of blockSize in
+// total.
+// It honors withBlockSize, withMaxOracleCalls, withMaxSecretLen, withVerifier
+// (like decryptOracleSecret) and withParallelism, which sets how many queries
+// are in flight at the same time. The oracle must be safe for concurrent use.
+func decryptOracleSecretPipelined(
+	encryptionOracle aesOracle,
+	opts ...attackOption,
+) ([]byte, error) {
+
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	encryptionOracle = options.limitOracle(encryptionOracle)
+
+	var (
+		blockSize = options.blockSize
+		ctx       = context.Background()
+	)
+
+	// the cipher text of the secret alone tells us how many blocks to
+	// decrypt, and the ones with fillers can't be shorter.
+	encryptedSecret, err := secretCipherText(encryptionOracle, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSecretCipherText(encryptedSecret, options); err != nil {
+		return nil, err
+	}
+
+	fillers := make([][]byte, blockSize)
+	for size := range fillers {
+		fillers[size] = bytes.Repeat([]byte{'A'}, size)
+	}
+
+	var (
+		nBlocks = len(encryptedSecret) / blockSize
+		secret  = make([]byte, 0, len(encryptedSecret))
+
+		// the target blocks of the current block, one per filler, reused
+		// across blocks.
+		targets = make([]byte, blockSize*blockSize)
+	)
+	for blockIdx := range nBlocks {
+		start, end := blockBounds(blockIdx, blockSize)
+		err := fetchTargetBlocks(ctx, encryptionOracle, fillers, blockIdx, options.parallelism, targets)
+		if err != nil {
+			return secret, fmt.Errorf("fetching target blocks: %w", err)
+		}
+
+		for size := blockSize - 1; size >= 0; size-- {
+			var (
+				targetBlock = blockAt(targets, size, blockSize)
+
+				// each guess needs its own plain text, as they are in flight
+				// concurrently; they only differ in their last byte.
+				prefix = concatInto(nil, fillers[size], secret)
+			)
+
+			guess := func(char byte) (bool, error) {
+				forged := concatInto(nil, prefix, []byte{char})
+
+				cipherText, err := queryOracle(encryptionOracle, forged, end)
+				if err != nil {
+					const formatStr = "trying byte %d (%c): %w"
+					return false, fmt.Errorf(formatStr, char, char, err)
+				}
+
+				return bytes.Equal(cipherText[start:end], targetBlock), nil
+			}
+
+			searchOpts := brute.Options{Workers: options.parallelism}
+			char, err := brute.Search(ctx, brute.Bytes{}, guess, searchOpts)
+			if errors.Is(err, brute.ErrNotFound) {
+				// we reached the padding (see decryptOracleSecret).
+				return secret, nil
+			}
+			if err != nil {
+				return secret, err
+			}
+
+			secret = append(secret, char)
+
+			done, err := options.verify(secret)
+			if err != nil {
+				return secret, err
+			}
+			if done {
+				return secret, nil
+			}
+		}
+	}
+
+	return secret, nil
+}
+
+// fetchTargetBlocks queries the oracle with each of the fillers concurrently,
+// and copies block blockIdx of each cipher text into dst: the target block of
+// the filler of size bytes is blockAt(dst, size, blockSize), where blockSize is
+// the number of fillers. dst must hold blockSize blocks.
+// The cipher texts themselves are dropped as soon as their block is copied.
+func fetchTargetBlocks(
+	ctx context.Context,
+	oracle aesOracle,
+	fillers [][]byte,
+	blockIdx, parallelism int,
+	dst []byte,
+) error {
+
+	var (
+		blockSize  = len(fillers)
+		start, end = blockBounds(blockIdx, blockSize)
+	)
+	_, err := parallel.Map(ctx, blockSize, parallelism,
+		func(_ context.Context, size int) (struct{}, error) {
+			cipherText, err := queryOracle(oracle, fillers[size], end)
+			if err != nil {
+				return struct{}{}, err
+			}
+			// each call writes to its own block of dst.
+			copy(blockAt(dst, size, blockSize), cipherText[start:end])
+			return struct{}{}, nil
+		},
+	)
+	return err
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDecryptOracleSecretPipelined(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, parallelism := range []int{8, 64} {
+		// noise makes the guesses complete out of order.
+		noisy := jitteryOracle(flakyOracle(o, 0.05), 50*time.Microsecond)
+
+		decryptedSecret, err := decryptOracleSecretPipelined(noisy, withParallelism(parallelism))
+		if err != nil {
+			t.Fatalf("parallelism %d: unexpected error: %s", parallelism, err)
+		}
+		if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, []byte(secret)) {
+			t.Errorf("parallelism %d:\nwant:\t%q\ngot:\t%q\n", parallelism, secret, got)
+		}
+	}
+}
+
+func TestDecryptOracleSecretPipelinedRemote(t *testing.T) {
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := _challenge12Secret.secret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(oracleHandler(o))
+	defer srv.Close()
+
+	decryptedSecret, err := decryptOracleSecretPipelined(httpOracle(srv.Client(), srv.URL), withParallelism(16))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, want) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+}
+
+func TestDecryptOracleSecretPipelinedMaxCalls(t *testing.T) {
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = decryptOracleSecretPipelined(o, withMaxOracleCalls(100), withParallelism(8))
+	if !errors.Is(err, errMaxOracleCalls) {
+		t.Errorf("want %v, got %v", errMaxOracleCalls, err)
+	}
+}
+
+// BenchmarkDecryptOracleSecretLatency compares the sequential and pipelined
+// attacks against an oracle that takes a millisecond to answer, like a
+// remote one would.
+func BenchmarkDecryptOracleSecretLatency(b *testing.B) {
+	o, err := ecbEncryptionOracle(staticSecret("YELLOW SUBMARINE"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	slow := func(plainText []byte) ([]byte, error) {
+		time.Sleep(time.Millisecond)
+		return o(plainText)
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		for range b.N {
+			if _, err := decryptOracleSecret(slow); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pipelined", func(b *testing.B) {
+		for range b.N {
+			if _, err := decryptOracleSecretPipelined(slow, withParallelism(32)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkDecryptOracleSecretPipelinedLargeSecret measures the pipelined
+// attack on a secret of many blocks, which used to keep a whole cipher text
+// per filler for the duration of the attack.
+func BenchmarkDecryptOracleSecretPipelinedLargeSecret(b *testing.B) {
+	o, err := ecbEncryptionOracle(staticSecret(randomEnglishBytes(1024)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := decryptOracleSecretPipelined(o, withParallelism(8)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFetchTargetBlocks(t *testing.T) {
+	o, err := ecbEncryptionOracle(staticSecret("YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const blockSize = 16
+	fillers := make([][]byte, blockSize)
+	for size := range fillers {
+		fillers[size] = bytes.Repeat([]byte{'A'}, size)
+	}
+
+	dst := make([]byte, blockSize*blockSize)
+	const blockIdx = 2
+	if err := fetchTargetBlocks(context.Background(), o, fillers, blockIdx, 4, dst); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for size, filler := range fillers {
+		cipherText, err := o(filler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := blockAt(cipherText, blockIdx, blockSize), blockAt(dst, size, blockSize); !bytes.Equal(got, want) {
+			t.Errorf("filler of %d bytes:\nwant:\t%x\ngot:\t%x\n", size, want, got)
+		}
+	}
+}
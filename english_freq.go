@@ -1,5 +1,7 @@
 package main
 
+import "unicode"
+
 // taken from
 // https://www3.nd.edu/~busiforc/handouts/cryptography/letterfrequencies.html
 var _englishLetterFrequencies = [26]float64{
@@ -18,3 +20,64 @@ var _englishLetterFrequencies = [26]float64{
 }
 
 const _spaceFrequency = 0.1918182
+
+// _digitFrequency is the frequency of each of the digits 0-9.
+const _digitFrequency = 0.0005
+
+// _whitespaceFrequency is the frequency of each of '\n', '\r' and '\t'.
+const _whitespaceFrequency = 0.003
+
+// _punctuationFrequencies holds the frequencies of the most common punctuation
+// characters. Any other character scores 0.
+var _punctuationFrequencies = map[rune]float64{
+	'.':  0.0065,
+	',':  0.0061,
+	'\'': 0.0024,
+	'"':  0.0027,
+	'-':  0.0015,
+	'?':  0.0006,
+	'!':  0.0006,
+	';':  0.0003,
+	':':  0.0003,
+	'(':  0.0002,
+	')':  0.0002,
+}
+
+// _nonTextPenalty is the score of a character that's very unlikely to be in
+// text, like a control character or an invalid UTF-8 byte. It's negative so
+// that a few such characters outweigh many plausible ones.
+const _nonTextPenalty = -0.5
+
+// _asciiScores holds the score of each ASCII character, as computeTextScore
+// would rate it: uppercase letters score as their lowercase counterparts,
+// control characters other than whitespace are penalized, and so on.
+// Nearly all the candidates the XOR breakers score are ASCII, so a table
+// lookup saves them a chain of branches per character.
+var _asciiScores = newASCIIScores()
+
+// newASCIIScores builds _asciiScores.
+func newASCIIScores() [unicode.MaxASCII + 1]float64 {
+	var scores [unicode.MaxASCII + 1]float64
+	for c := range scores {
+		r := rune(c)
+
+		switch {
+		case r >= 'a' && r <= 'z':
+			scores[c] = _englishLetterFrequencies[r-'a']
+		case r >= 'A' && r <= 'Z':
+			scores[c] = _englishLetterFrequencies[r-'A']
+		case r == ' ':
+			scores[c] = _spaceFrequency
+		case r >= '0' && r <= '9':
+			scores[c] = _digitFrequency
+		case r == '\n' || r == '\r' || r == '\t':
+			scores[c] = _whitespaceFrequency
+		case unicode.IsControl(r):
+			scores[c] = _nonTextPenalty
+		default:
+			scores[c] = _punctuationFrequencies[r]
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// The examples run the attacks end to end against their targets. Their names
+// follow the unexported functions they show, since package main exports
+// nothing.
+
+func Example_singleByteXOR() {
+	cipherText, err := decodeCipherText([]byte("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736"), "hex")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	plainText, key := singleByteXOR(cipherText)
+	fmt.Printf("key %q: %s\n", key, plainText)
+	// Output: key 'X': Cooking MC's like a pound of bacon
+}
+
+func Example_breakRepeatingKeyXOR() {
+	encoded, err := os.ReadFile("./files/1_6.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	cipherText, err := decodeCipherText(encoded, "base64")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	plainText, key, err := breakRepeatingKeyXOR(cipherText, 40)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	firstLine, _, _ := strings.Cut(plainText, "\n")
+	fmt.Printf("key %q\n%s\n", key, firstLine)
+	// Output:
+	// key "Terminator X: Bring the noise"
+	// I'm back and I'm ringin' the bell
+}
+
+func Example_decryptOracleSecret() {
+	// the oracle appends a secret to our input and encrypts it in ECB mode,
+	// under a random key.
+	oracle, err := ecbEncryptionOracle(staticSecret("Rollin' in my 5.0"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	secret, err := decryptOracleSecret(oracle)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s\n", delPadPkcs7(secret))
+	// Output: Rollin' in my 5.0
+}
+
+func Example_decryptRandomPrefixOracleSecret() {
+	// the oracle also prepends up to 32 random bytes to our input.
+	oracle, err := randomPrefixEcbOracle(staticSecret("Rollin' in my 5.0"), 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	secret, _, err := decryptRandomPrefixOracleSecret(oracle)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s\n", delPadPkcs7(secret))
+	// Output: Rollin' in my 5.0
+}
+
+func Example_probeOracle() {
+	oracle, err := ecbEncryptionOracle(staticSecret("Rollin' in my 5.0"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	profile, err := probeOracle(oracle)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	const formatStr = "%s cipher, %d-byte blocks, ECB: %t, %d-byte prefix, %d-byte suffix\n"
+	fmt.Printf(formatStr, profile.family, profile.blockSize, profile.ecb, profile.prefixLen, profile.suffixLen)
+	// Output: block cipher, 16-byte blocks, ECB: true, 0-byte prefix, 17-byte suffix
+}
+
+func Example_createAdminProfile() {
+	encryptionOracle, isAdmin, err := newProfileOracles(_defaultProfileService)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	admin, err := createAdminProfile(encryptionOracle, isAdmin, profileFor)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("admin:", admin)
+	// Output: admin: true
+}
+
+func Example_crackPassphraseKey() {
+	// the key is the SHA-1 of a passphrase from the embedded word list.
+	key := deriveKey("Music", deriveSHA1)
+	cipherText, err := encryptAesEcb([]byte("Play that funky music white boy"), key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	match, plainText, err := crackPassphraseKey(cipherText, strings.Fields(_englishWordsFile), 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%q (%s): %s\n", match.passphrase, match.derivation, plainText)
+	// Output: "Music" (sha1): Play that funky music white boy
+}
+
+func Example_verifyHMAC() {
+	var (
+		key     = []byte("YELLOW SUBMARINE")
+		message = []byte("comment1=cooking%20MCs")
+		mac     = hmacSHA256(key, message)
+	)
+
+	fmt.Println(verifyHMAC(key, message, mac))
+	mac[0] ^= 1
+	fmt.Println(verifyHMAC(key, message, mac))
+	// Output:
+	// true
+	// false
+}
+
+func Example_explainPadding() {
+	explainPadding(os.Stdout, []byte("YELLOW SUBMARINE!"), 16, pkcs7Padding{})
+	// Output:
+	// 17 bytes + 15 of padding = 2 blocks of 16 bytes
+	//   block 0 [  0: 16] 59 45 4c 4c 4f 57 20 53 55 42 4d 41 52 49 4e 45  YELLOW SUBMARINE
+	//   block 1 [ 16: 32] 21|0f 0f 0f 0f 0f 0f 0f 0f 0f 0f 0f 0f 0f 0f 0f  !...............
+}
+
+func Example_explainCbcEncryption() {
+	var (
+		key = []byte("YELLOW SUBMARINE")
+		iv  = make([]byte, 16)
+	)
+	if _, err := explainCbcEncryption(os.Stdout, []byte("comment1=cooking%20MCs"), key, iv); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// IV      = 00000000000000000000000000000000
+	// P0      = 636f6d6d656e74313d636f6f6b696e67  comment1=cooking
+	//         = 636f6d6d656e74313d636f6f6b696e67  P0 xor IV
+	// C0      = 20887c61d4b00fa70970f067831fd799  E(P0 xor IV)
+	// P1      = 2532304d43730a0a0a0a0a0a0a0a0a0a  %20MCs..........
+	//         = 05ba4c2c97c305ad037afa6d8915dd93  P1 xor C0
+	// C1      = 5b5882721c00c4dff6e80849d3ee911a  E(P1 xor C0)
+}
+
+func Example_explainEcbLayout() {
+	// the first query of challenge 12's attack: 15 bytes of input push the
+	// first byte of the secret at the end of the first block.
+	explainEcbLayout(os.Stdout, 0, []byte(strings.Repeat("A", 15)), 21, 16)
+	// Output:
+	//   block 0 [  0: 16] AAAAAAAAAAAAAAAs
+	//   block 1 [ 16: 32] ssssssssssssssss
+	//   block 2 [ 32: 48] ssss############
+}
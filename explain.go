@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// withExplain makes the attack write a step by step explanation of what it's
+// doing to w, using the actual bytes and offsets it's working with. It's the
+// dynamic counterpart of the diagrams in the attacks' comments.
+func withExplain(w io.Writer) attackOption {
+	return func(o *attackOptions) {
+		o.explain = w
+	}
+}
+
+// explainf writes a line of explanation to w.
+func explainf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+// explainBlocks writes data to w split into blocks of blockSize bytes, one per
+// line, each with its index and boundaries. Bytes are shown as a quoted
+// string.
+func explainBlocks(w io.Writer, data []byte, blockSize int) {
+	for i, block := range blockRange(data, blockSize) {
+		start, _ := blockBounds(i, blockSize)
+		fmt.Fprintf(w, "  block %d [%3d:%3d] %q\n", i, start, start+len(block), block)
+	}
+}
+
+// explainHexBlocks is like explainBlocks, but shows the bytes hex encoded,
+// which is more suitable for cipher texts.
+func explainHexBlocks(w io.Writer, data []byte, blockSize int) {
+	for i, block := range blockRange(data, blockSize) {
+		start, _ := blockBounds(i, blockSize)
+		fmt.Fprintf(w, "  block %d [%3d:%3d] %s\n", i, start, start+len(block), hex.EncodeToString(block))
+	}
+}
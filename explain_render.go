@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// explainPadding writes data padded with scheme to w, one block per line: the
+// bytes hex encoded, with a '|' where the padding starts, followed by the
+// block as text (non printable bytes are shown as '.').
+// It's the layout the comment of padPkcs7 describes, computed from actual
+// bytes.
+func explainPadding(w io.Writer, data []byte, blockSize int, scheme paddingScheme) {
+	padded := scheme.pad(data, blockSize)
+	fmt.Fprintf(w, "%d bytes + %d of padding = %d blocks of %d bytes\n",
+		len(data), len(padded)-len(data), len(padded)/blockSize, blockSize)
+
+	for b, block := range blockRange(padded, blockSize) {
+		var (
+			start, end = blockBounds(b, blockSize)
+			hexBytes   strings.Builder
+		)
+		for i := start; i < end; i++ {
+			switch {
+			case i == len(data):
+				hexBytes.WriteByte('|')
+			case i > start:
+				hexBytes.WriteByte(' ')
+			}
+			fmt.Fprintf(&hexBytes, "%02x", padded[i])
+		}
+		fmt.Fprintf(w, "  block %d [%3d:%3d] %s  %s\n", b, start, end, hexBytes.String(), printable(block))
+	}
+}
+
+// explainCbcEncryption encrypts plainText with AES CBC, padding it with
+// PKCS#7, and writes each step of the chain to w: every plain text block is
+// XORed with the previous cipher text block (the IV for the first one), and
+// the result is encrypted with the block cipher:
+//
+//	C[i] = E(P[i] xor C[i-1]), with C[-1] = IV
+//
+// It returns the cipher text, which is what encryptAesCbc returns.
+func explainCbcEncryption(w io.Writer, plainText, key, iv []byte) ([]byte, error) {
+	if len(iv) != aes.BlockSize {
+		const formatStr = "initialization vector length %d is not the block size %d"
+		return nil, fmt.Errorf(formatStr, len(iv), aes.BlockSize)
+	}
+
+	encrypter, err := aesEncrypter(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		padded     = padPkcs7(plainText, aes.BlockSize)
+		cipherText = make([]byte, 0, len(padded))
+		prev       = iv
+	)
+	fmt.Fprintf(w, "IV      = %s\n", hex.EncodeToString(iv))
+
+	for i, block := range blockRange(padded, aes.BlockSize) {
+		prevC := fmt.Sprintf("C%d", i-1)
+		if i == 0 {
+			prevC = "IV"
+		}
+
+		mixed, err := xorBlocks(block, prev)
+		if err != nil {
+			return cipherText, err
+		}
+		encrypted := encrypter(mixed)
+
+		fmt.Fprintf(w, "P%-6d = %s  %s\n", i, hex.EncodeToString(block), printable(block))
+		fmt.Fprintf(w, "%-7s = %s  P%d xor %s\n", "", hex.EncodeToString(mixed), i, prevC)
+		fmt.Fprintf(w, "C%-6d = %s  E(P%d xor %s)\n", i, hex.EncodeToString(encrypted), i, prevC)
+
+		cipherText = append(cipherText, encrypted...)
+		prev = encrypted
+	}
+
+	return cipherText, nil
+}
+
+// explainEcbLayout writes to w how an oracle that encrypts
+// [prefix || input || suffix] with a block cipher in ECB mode and PKCS#7
+// padding lays out the message in blocks, one block per line. Bytes of the
+// prefix are shown as 'p', bytes of the suffix (e.g., the secret) as 's', and
+// padding bytes as '#'; the input is shown as text (non printable bytes as
+// '.').
+// It's the block alignment the byte-at-a-time attacks rely on: e.g., with no
+// prefix and 15 bytes of input, the first block ends with the first byte of
+// the suffix.
+func explainEcbLayout(w io.Writer, prefixLen int, input []byte, suffixLen, blockSize int) {
+	var (
+		msgLen = prefixLen + len(input) + suffixLen
+		layout = make([]byte, 0, msgLen+blockSize)
+	)
+	layout = append(layout, strings.Repeat("p", prefixLen)...)
+	layout = append(layout, printable(input)...)
+	layout = append(layout, strings.Repeat("s", suffixLen)...)
+	// PKCS#7 always adds padding, a whole block of it if the message is
+	// already aligned.
+	layout = append(layout, strings.Repeat("#", alignUp(msgLen+1, blockSize)-msgLen)...)
+
+	for i, block := range blockRange(layout, blockSize) {
+		start, end := blockBounds(i, blockSize)
+		fmt.Fprintf(w, "  block %d [%3d:%3d] %s\n", i, start, end, block)
+	}
+}
+
+// printable returns data as a string, with its non printable bytes replaced
+// by '.'.
+func printable(data []byte) string {
+	out := make([]byte, len(data))
+	for i, char := range data {
+		if char < ' ' || char > '~' {
+			char = '.'
+		}
+		out[i] = char
+	}
+	return string(out)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestExplainCbcEncryption(t *testing.T) {
+	var (
+		key       = testutil.RandomKey(t)
+		iv        = testutil.RandomIV(t)
+		plainText = []byte("comment1=cooking%20MCs;userdata=;admin=true")
+		out       bytes.Buffer
+	)
+
+	got, err := explainCbcEncryption(&out, plainText, key, iv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want, err := encryptAesCbc(plainText, key, iv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("\nwant:\t%x\ngot:\t%x\n", want, got)
+	}
+
+	// the IV, then three lines per block.
+	if lines := strings.Count(out.String(), "\n"); lines != 1+3*3 {
+		t.Errorf("want 10 lines, got %d:\n%s", lines, out.String())
+	}
+
+	if _, err := explainCbcEncryption(&out, plainText, key, iv[:8]); err == nil {
+		t.Error("want error for invalid IV, got nil")
+	}
+}
+
+func TestExplainPadding(t *testing.T) {
+	var out bytes.Buffer
+	explainPadding(&out, []byte("YELLOW SUBMARINE"), 16, iso7816Padding{})
+
+	const want = "16 bytes + 16 of padding = 2 blocks of 16 bytes\n" +
+		"  block 0 [  0: 16] 59 45 4c 4c 4f 57 20 53 55 42 4d 41 52 49 4e 45  YELLOW SUBMARINE\n" +
+		"  block 1 [ 16: 32] |80 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  ................\n"
+	if out.String() != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, out.String())
+	}
+}
+
+func TestExplainEcbLayout(t *testing.T) {
+	var out bytes.Buffer
+	explainEcbLayout(&out, 5, []byte("AAAAAAAAAAA\x00"), 3, 8)
+
+	const want = "  block 0 [  0:  8] pppppAAA\n" +
+		"  block 1 [  8: 16] AAAAAAAA\n" +
+		"  block 2 [ 16: 24] .sss####\n"
+	if out.String() != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, out.String())
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplainDecryptOracleSecret(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var explanation bytes.Buffer
+	if _, err := decryptOracleSecret(o, withExplain(&explanation)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same steps shown in example_byte_at_a_time.txt.
+	for _, want := range []string{
+		`"AAAAAAAAAAAAAAAY" matches the target block: secret[0] = 'Y'`,
+		`"AAAAAAAAAAAAAAYE" matches the target block: secret[1] = 'E'`,
+		`"AAAAAAAAAYELLOW " matches the target block: secret[6] = ' '`,
+	} {
+		if !strings.Contains(explanation.String(), want) {
+			t.Errorf("explanation does not contain %q:\n%s", want, explanation.String())
+		}
+	}
+}
+
+func TestExplainBreakRepeatingKeyXOR(t *testing.T) {
+	var (
+		plainText   = bytes.Repeat([]byte("Burning 'em, if you ain't quick and nimble. "), 10)
+		cipherText  = repeatingKeyXOR(plainText, []byte("ICE"))
+		explanation bytes.Buffer
+	)
+
+	_, key, err := breakRepeatingKeyXOR(cipherText, 10, withExplain(&explanation))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key != "ICE" {
+		t.Errorf("want key %q, got %q", "ICE", key)
+	}
+
+	for _, want := range []string{"<- chosen", "estimated key size: 3", "encrypted with key byte 'C'"} {
+		if !strings.Contains(explanation.String(), want) {
+			t.Errorf("explanation does not contain %q:\n%s", want, explanation.String())
+		}
+	}
+}
+
+func TestExplainBlocks(t *testing.T) {
+	var out bytes.Buffer
+	explainBlocks(&out, []byte("YELLOW SUBMARINE!"), 16)
+
+	const want = "  block 0 [  0: 16] \"YELLOW SUBMARINE\"\n  block 1 [ 16: 17] \"!\"\n"
+	if out.String() != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, out.String())
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"strings"
+)
+
+// _englishWordList holds the words of _englishWordsFile, in order.
+var _englishWordList = strings.Fields(_englishWordsFile)
+
+// _sentenceWords is the number of words of the sentences of
+// randomEnglishText.
+const _sentenceWords = 12
+
+// exercise is a challenge-style input generated by the gen command: the data
+// given to whoever solves it, and the answers to check a solution against.
+type exercise struct {
+	// cipherTexts are the data of the exercise. Exercises that target an
+	// oracle have none: the oracle is the data.
+	cipherTexts [][]byte
+
+	// answers are what solving the exercise recovers: the key and the
+	// secrets or plain texts.
+	answers []artifact
+}
+
+// randomEnglishText returns nWords random words of the embedded English word
+// list, in sentences of _sentenceWords words that start with a capital letter
+// and end with a period. It reads like nonsense, but its letter frequencies
+// are English enough for the attacks that score plain texts.
+func randomEnglishText(nWords int) string {
+	var b strings.Builder
+	for i := range nWords {
+		word := _englishWordList[mrand.IntN(len(_englishWordList))]
+
+		switch {
+		case i%_sentenceWords == 0:
+			if i > 0 {
+				b.WriteString(". ")
+			}
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		default:
+			b.WriteString(" " + word)
+		}
+	}
+	if nWords > 0 {
+		b.WriteByte('.')
+	}
+
+	return b.String()
+}
+
+// genXORRepeatingExercise returns an exercise like challenge 6: a text of
+// nWords random English words encrypted with repeating-key XOR under a
+// random key of keyLen bytes.
+func genXORRepeatingExercise(keyLen, nWords int) (exercise, error) {
+	if keyLen < 1 {
+		return exercise{}, fmt.Errorf("invalid key length %d; must be at least 1", keyLen)
+	}
+	if nWords < 1 {
+		return exercise{}, fmt.Errorf("invalid number of words %d; must be at least 1", nWords)
+	}
+
+	key, err := randomBytesN(keyLen)
+	if err != nil {
+		return exercise{}, fmt.Errorf("generating random key: %w", err)
+	}
+	plainText := []byte(randomEnglishText(nWords))
+
+	return exercise{
+		cipherTexts: [][]byte{repeatingKeyXOR(plainText, key)},
+		answers: []artifact{
+			{kind: artifactKey, name: "key", data: key},
+			{kind: artifactSecret, name: "plain text", data: plainText},
+		},
+	}, nil
+}
+
+// genECBSuffixExercise returns an exercise like challenge 12: a secret of
+// nWords random English words, for an oracle that appends it to its input
+// (see the -answers flag of the serve command). The oracle's key is generated
+// when it starts, so it's not part of the answers.
+func genECBSuffixExercise(nWords int) (exercise, error) {
+	if nWords < 1 {
+		return exercise{}, fmt.Errorf("invalid number of words %d; must be at least 1", nWords)
+	}
+
+	secret := []byte(randomEnglishText(nWords))
+	return exercise{
+		answers: []artifact{{kind: artifactSecret, name: "secret", data: secret}},
+	}, nil
+}
+
+// genCTRFixedNonceExercise returns an exercise like challenges 19 and 20:
+// nLines texts of up to nWords random English words, each encrypted with
+// AES-CTR under the same random key and the same nonce (all zeros), so that
+// they share the keystream.
+func genCTRFixedNonceExercise(nLines, nWords int) (exercise, error) {
+	if nLines < 1 {
+		return exercise{}, fmt.Errorf("invalid number of lines %d; must be at least 1", nLines)
+	}
+	if nWords < 1 {
+		return exercise{}, fmt.Errorf("invalid number of words %d; must be at least 1", nWords)
+	}
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return exercise{}, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	var (
+		nonce = make([]byte, _ctrNonceSize)
+		ex    = exercise{answers: []artifact{{kind: artifactKey, name: "key", data: key}}}
+	)
+	for i := range nLines {
+		plainText := []byte(randomEnglishText(1 + mrand.IntN(nWords)))
+
+		cipherText, err := encryptAesCtr(plainText, key, nonce)
+		if err != nil {
+			return exercise{}, err
+		}
+
+		ex.cipherTexts = append(ex.cipherTexts, cipherText)
+		ex.answers = append(ex.answers, artifact{
+			kind: artifactSecret,
+			name: fmt.Sprintf("line %d", i+1),
+			data: plainText,
+		})
+	}
+
+	return ex, nil
+}
+
+// answerSecret returns the data of the first secret among answers.
+func answerSecret(answers []artifact) ([]byte, error) {
+	for _, a := range answers {
+		if a.kind == artifactSecret {
+			return a.data, nil
+		}
+	}
+	return nil, errors.New("the answers hold no secret")
+}
+
+// difficulty sets how hard an oracle target generated by genOracleExercise is
+// to attack.
+type difficulty struct {
+	// secretLen is the length of the oracle's secret, in bytes.
+	secretLen int
+
+	// maxPrefix is the largest random prefix the oracle prepends to our
+	// input: 0 to maxPrefix random bytes, generated anew on every call.
+	// There is no prefix if it's 0.
+	maxPrefix int
+
+	// budget is how many queries the oracle answers before failing with
+	// errMaxOracleCalls. There is no limit if it's 0.
+	budget int64
+
+	// noise is the probability that a query fails, and that the cipher text
+	// of a query that didn't fail comes back truncated.
+	noise float64
+}
+
+// String returns the knobs of d, e.g., "secret=64 prefix=16 budget=0
+// noise=0.05".
+func (d difficulty) String() string {
+	const formatStr = "secret=%d prefix=%d budget=%d noise=%.2f"
+	return fmt.Sprintf(formatStr, d.secretLen, d.maxPrefix, d.budget, d.noise)
+}
+
+// validate checks that the knobs of d are usable.
+func (d difficulty) validate() error {
+	switch {
+	case d.secretLen < 1:
+		return fmt.Errorf("invalid secret length %d; must be at least 1", d.secretLen)
+	case d.maxPrefix < 0:
+		return fmt.Errorf("invalid maximum prefix length %d; can't be negative", d.maxPrefix)
+	case d.budget < 0:
+		return fmt.Errorf("invalid oracle budget %d; can't be negative", d.budget)
+	case d.noise < 0 || d.noise >= 1:
+		return fmt.Errorf("invalid noise %.2f; must be in [0, 1)", d.noise)
+	}
+	return nil
+}
+
+// degrade returns the given oracle wrapped so that it's as noisy and as
+// limited as d says (see flakyOracle, truncatingOracle and
+// withMaxOracleCalls). It ignores secretLen and maxPrefix, which are up to
+// the oracle itself.
+func (d difficulty) degrade(oracle aesOracle) aesOracle {
+	if d.noise > 0 {
+		oracle = truncatingOracle(flakyOracle(oracle, d.noise), d.noise)
+	}
+	// the limit is enforced by the target, but it's the same check the
+	// attacks make for themselves.
+	return attackOptions{maxOracleCalls: d.budget}.limitOracle(oracle)
+}
+
+// genOracleExercise returns an exercise like challenges 12 and 14, made as
+// hard as d says: an oracle that encrypts [random-prefix || input || secret]
+// with AES ECB, where the secret is made of random English words, and the
+// answers holding the secret. The oracle's key is not part of the answers.
+// It honors the same options as randomPrefixEcbOracle.
+func genOracleExercise(d difficulty, opts ...oracleOption) (aesOracle, exercise, error) {
+	if err := d.validate(); err != nil {
+		return nil, exercise{}, err
+	}
+
+	secret := randomEnglishBytes(d.secretLen)
+
+	var (
+		oracle aesOracle
+		err    error
+	)
+	if d.maxPrefix > 0 {
+		oracle, err = randomPrefixEcbOracle(staticSecret(secret), d.maxPrefix, opts...)
+	} else {
+		oracle, err = ecbEncryptionOracle(staticSecret(secret), opts...)
+	}
+	if err != nil {
+		return nil, exercise{}, err
+	}
+
+	ex := exercise{
+		answers: []artifact{{kind: artifactSecret, name: "secret", data: secret}},
+	}
+	return d.degrade(oracle), ex, nil
+}
+
+// randomEnglishBytes returns the first n bytes of a text of random English
+// words (see randomEnglishText).
+func randomEnglishBytes(n int) []byte {
+	// English words are about 5 letters long, plus a space.
+	text := randomEnglishText(n/4 + 1)
+	for len(text) < n {
+		text += " " + randomEnglishText(n/4+1)
+	}
+	return []byte(text[:n])
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"text/tabwriter"
+)
+
+func TestRandomEnglishText(t *testing.T) {
+	text := randomEnglishText(30)
+
+	if n := len(strings.Fields(text)); n != 30 {
+		t.Errorf("want 30 words, got %d: %q", n, text)
+	}
+	if f := wordFraction([]byte(text)); f != 1 {
+		t.Errorf("want only English words, got a fraction of %.2f: %q", f, text)
+	}
+	if !strings.HasSuffix(text, ".") || strings.Count(text, ". ") != 2 {
+		t.Errorf("want 3 sentences, got %q", text)
+	}
+
+	if text := randomEnglishText(0); text != "" {
+		t.Errorf("want empty text, got %q", text)
+	}
+}
+
+func TestGenXORRepeatingExercise(t *testing.T) {
+	ex, err := genXORRepeatingExercise(7, 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	key, plainText := ex.answers[0].data, ex.answers[1].data
+
+	if len(key) != 7 {
+		t.Errorf("want a 7-byte key, got %d bytes", len(key))
+	}
+	if got := repeatingKeyXOR(ex.cipherTexts[0], key); !bytes.Equal(got, plainText) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, got)
+	}
+
+	// the exercise must be solvable with the attack of challenge 6. It may
+	// pick a multiple of the key size, whose columns are shorter, and get a
+	// few of them wrong.
+	recovered, _, err := breakRepeatingKeyXOR(ex.cipherTexts[0], 40)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var right int
+	for i := range recovered {
+		if recovered[i] == plainText[i] {
+			right++
+		}
+	}
+	if right < len(plainText)*9/10 {
+		t.Errorf("want at least 9/10 of the bytes recovered, got %d/%d", right, len(plainText))
+	}
+}
+
+func TestGenECBSuffixExercise(t *testing.T) {
+	ex, err := genECBSuffixExercise(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	secret, err := answerSecret(ex.answers)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	oracle, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	recovered, err := decryptOracleSecret(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := delPadPkcs7(recovered); !bytes.Equal(got, secret) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, got)
+	}
+}
+
+func TestGenCTRFixedNonceExercise(t *testing.T) {
+	ex, err := genCTRFixedNonceExercise(40, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(ex.cipherTexts) != 40 || len(ex.answers) != 41 {
+		t.Fatalf("want 40 cipher texts and 41 answers, got %d and %d", len(ex.cipherTexts), len(ex.answers))
+	}
+
+	key := ex.answers[0].data
+	for i, ct := range ex.cipherTexts {
+		plainText, err := encryptAesCtr(ct, key, make([]byte, _ctrNonceSize))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := ex.answers[i+1].data; !bytes.Equal(plainText, want) {
+			t.Errorf("line %d:\nwant:\t%q\ngot:\t%q\n", i+1, want, plainText)
+		}
+	}
+
+	// the shared keystream gives away most of the plain texts.
+	plainTexts, _, err := breakChachaNonceReuse(ex.cipherTexts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var right, total int
+	for i, pt := range plainTexts {
+		want := ex.answers[i+1].data
+		for j := range pt {
+			if pt[j] == want[j] {
+				right++
+			}
+		}
+		total += len(pt)
+	}
+	if right < total*3/4 {
+		t.Errorf("want at least 3/4 of the bytes recovered, got %d/%d", right, total)
+	}
+}
+
+func TestGenExercisesInvalid(t *testing.T) {
+	if _, err := genXORRepeatingExercise(0, 10); err == nil {
+		t.Error("want error for empty key, got nil")
+	}
+	if _, err := genXORRepeatingExercise(3, 0); err == nil {
+		t.Error("want error for empty text, got nil")
+	}
+	if _, err := genECBSuffixExercise(0); err == nil {
+		t.Error("want error for empty secret, got nil")
+	}
+	if _, err := genCTRFixedNonceExercise(0, 10); err == nil {
+		t.Error("want error for no lines, got nil")
+	}
+	if _, err := answerSecret([]artifact{{kind: artifactKey, data: []byte("k")}}); err == nil {
+		t.Error("want error for answers without secret, got nil")
+	}
+}
+
+func TestGenOracleExercise(t *testing.T) {
+	d := difficulty{secretLen: 40, budget: 3}
+	oracle, ex, err := genOracleExercise(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	secret, err := answerSecret(ex.answers)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(secret) != 40 {
+		t.Errorf("want a 40-byte secret, got %d bytes: %q", len(secret), secret)
+	}
+
+	for range d.budget {
+		if _, err := oracle(nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if _, err := oracle(nil); !errors.Is(err, errMaxOracleCalls) {
+		t.Errorf("over budget: want %v, got %v", errMaxOracleCalls, err)
+	}
+
+	for _, d := range []difficulty{
+		{secretLen: 0},
+		{secretLen: 16, maxPrefix: -1},
+		{secretLen: 16, budget: -1},
+		{secretLen: 16, noise: 1},
+	} {
+		if _, _, err := genOracleExercise(d); err == nil {
+			t.Errorf("%s: want error, got nil", d)
+		}
+	}
+}
+
+// TestAttackDifficultyMatrix runs the attacks on an oracle's secret against
+// targets of increasing difficulty, and checks where each of them breaks
+// down. Run it with -v to see how many queries each attack took.
+func TestAttackDifficultyMatrix(t *testing.T) {
+	attacks := []struct {
+		name string
+		run  func(aesOracle) ([]byte, error)
+	}{
+		{"byte-at-a-time", func(o aesOracle) ([]byte, error) {
+			return decryptOracleSecret(o)
+		}},
+		{"pipelined", func(o aesOracle) ([]byte, error) {
+			return decryptOracleSecretPipelined(o)
+		}},
+		{"random-prefix", func(o aesOracle) ([]byte, error) {
+			secret, _, err := decryptRandomPrefixOracleSecret(o)
+			return secret, err
+		}},
+	}
+
+	// breaks lists the attacks that must fail against each target. A
+	// 64-byte secret takes about 6500 queries. Only the random-prefix attack
+	// copes with a random prefix, at about 16 times the queries, and it
+	// retries each query until the answer is usable, which also lets it
+	// survive a very noisy oracle.
+	tests := []struct {
+		d      difficulty
+		breaks []string
+	}{
+		{difficulty{secretLen: 16}, nil},
+		{difficulty{secretLen: 256}, nil},
+		{difficulty{secretLen: 64, maxPrefix: 16}, []string{"byte-at-a-time", "pipelined"}},
+		{difficulty{secretLen: 64, maxPrefix: 64}, []string{"byte-at-a-time", "pipelined"}},
+		{difficulty{secretLen: 64, budget: 2000}, []string{"byte-at-a-time", "pipelined", "random-prefix"}},
+		{difficulty{secretLen: 64, budget: 20000}, nil},
+		{difficulty{secretLen: 64, noise: 0.05}, nil},
+		{difficulty{secretLen: 64, noise: 0.5}, []string{"byte-at-a-time", "pipelined"}},
+		{difficulty{secretLen: 64, maxPrefix: 16, noise: 0.05}, []string{"byte-at-a-time", "pipelined"}},
+	}
+
+	var (
+		table strings.Builder
+		tw    = tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	)
+	fmt.Fprintln(tw, "DIFFICULTY\tATTACK\tQUERIES\tRESULT")
+	for _, tt := range tests {
+		for _, attack := range attacks {
+			oracle, ex, err := genOracleExercise(tt.d)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			counted, calls := countOracleCalls(oracle)
+
+			recovered, err := attack.run(counted)
+			result := "recovered"
+			switch {
+			case errors.Is(err, errMaxOracleCalls):
+				result = "over budget"
+			case err != nil:
+				result = "failed"
+				t.Logf("%s, %s: %s", tt.d, attack.name, err)
+			// the attacks recover the first byte of padding too. A wrong
+			// secret may not be padded at all.
+			case !bytes.Equal(bytes.TrimSuffix(recovered, []byte{0x01}), ex.answers[0].data):
+				result = "wrong secret"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", tt.d, attack.name, calls(), result)
+
+			wantBreak := false
+			for _, name := range tt.breaks {
+				wantBreak = wantBreak || name == attack.name
+			}
+			if broke := result != "recovered"; broke != wantBreak {
+				t.Errorf("%s, %s: want broken %t, got %q", tt.d, attack.name, wantBreak, result)
+			}
+		}
+	}
+	tw.Flush()
+	t.Logf("\n%s", table.String())
+}
@@ -1,5 +1,5 @@
 module github.com/alesforz/cryptopals
 
-go 1.22.2
+go 1.23
 
 require golang.org/x/sync v0.3.0
@@ -0,0 +1,51 @@
+//go:build groundtruth
+
+package main
+
+import "sync"
+
+// groundTruth holds the hidden parameters of an oracle, so that tests can
+// check that an attack recovered exactly them, rather than something that
+// merely looks plausible.
+type groundTruth struct {
+	// oracle is the name of the function that created the oracle.
+	oracle string
+
+	key    []byte
+	secret []byte
+}
+
+// _groundTruths holds the ground truth of every oracle created so far.
+var _groundTruths struct {
+	mu      sync.Mutex
+	records []groundTruth
+}
+
+// recordGroundTruth records the hidden key and secret of an oracle created by
+// the function with the given name.
+// It's only compiled in with the groundtruth build tag; otherwise it does
+// nothing, so that the oracles' secrets never leave them in regular builds.
+func recordGroundTruth(oracle string, key, secret []byte) {
+	_groundTruths.mu.Lock()
+	defer _groundTruths.mu.Unlock()
+
+	_groundTruths.records = append(_groundTruths.records, groundTruth{
+		oracle: oracle,
+		key:    cloneBytes(key),
+		secret: cloneBytes(secret),
+	})
+}
+
+// lastGroundTruth returns the ground truth of the last oracle created by the
+// function with the given name.
+func lastGroundTruth(oracle string) (groundTruth, bool) {
+	_groundTruths.mu.Lock()
+	defer _groundTruths.mu.Unlock()
+
+	for i := len(_groundTruths.records) - 1; i >= 0; i-- {
+		if _groundTruths.records[i].oracle == oracle {
+			return _groundTruths.records[i], true
+		}
+	}
+	return groundTruth{}, false
+}
@@ -0,0 +1,98 @@
+//go:build groundtruth
+
+// The tests in this file check that the attacks recover exactly the oracles'
+// hidden parameters. Run them with:
+//
+//	go test -tags groundtruth
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+)
+
+func TestGroundTruthECBSuffix(t *testing.T) {
+	o, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gt, ok := lastGroundTruth("ecbEncryptionOracle")
+	if !ok {
+		t.Fatalf("no ground truth recorded")
+	}
+
+	secret, err := decryptOracleSecret(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the recovered secret ends with a byte of padding.
+	if got := delPadPkcs7(secret); !bytes.Equal(got, gt.secret) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", gt.secret, got)
+	}
+}
+
+func TestGroundTruthRandomPrefix(t *testing.T) {
+	o, err := randomPrefixEcbOracle(staticSecret("a secret with a ground truth"), 40)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gt, ok := lastGroundTruth("randomPrefixEcbOracle")
+	if !ok {
+		t.Fatalf("no ground truth recorded")
+	}
+
+	secret, _, err := decryptRandomPrefixOracleSecret(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := delPadPkcs7(secret); !bytes.Equal(got, gt.secret) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", gt.secret, got)
+	}
+}
+
+func TestGroundTruthCutAndPaste(t *testing.T) {
+	svc, err := newProfileService(withFixedUID(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	encryptionOracle, _, err := newProfileOracles(svc)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gt, ok := lastGroundTruth("newProfileOracles")
+	if !ok {
+		t.Fatalf("no ground truth recorded")
+	}
+
+	// capture the forged cipher text, and decrypt it with the oracle's key.
+	var forged []byte
+	isAdmin := func(cipherText []byte) (bool, error) {
+		forged = cipherText
+		return true, nil
+	}
+	if _, err := createAdminProfile(encryptionOracle, isAdmin, svc.profileFor); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	plainText, err := decryptAesEcb(forged, gt.key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "email=foo%40bar.aaaaaaaaaa&role=admin&role=user&uid=42"
+	if got := string(delPadPkcs7(plainText)); got != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+
+	v, err := url.ParseQuery(want)
+	if err != nil || v.Get("role") != "admin" {
+		t.Errorf("forged profile doesn't parse as admin: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+)
+
+// hmacSHA256 returns the HMAC-SHA256 of data under the given key.
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// verifyHMAC reports whether mac is the HMAC-SHA256 of message under key.
+// The comparison takes the same time wherever mac differs from the right one,
+// so timing it tells an attacker nothing (see insecureVerifyHMAC, and
+// measureTimings to check it).
+func verifyHMAC(key, message, mac []byte) bool {
+	return hmac.Equal(mac, hmacSHA256(key, message))
+}
+
+// insecureVerifyHMAC is like verifyHMAC, but it compares the MACs with
+// insecureCompare: the longer the prefix of mac that is right, the longer it
+// takes. An attacker can recover the right MAC a byte at a time by timing it.
+// Don't use it for anything but attack targets and demonstrations.
+func insecureVerifyHMAC(key, message, mac []byte, delay time.Duration) bool {
+	return insecureCompare(mac, hmacSHA256(key, message), delay)
+}
+
+// insecureCompare reports whether a and b are equal comparing them a byte at a
+// time, sleeping for delay after each byte that matches, and returning as soon
+// as two bytes differ. The delay exaggerates the leak of an early-exit
+// comparison, as in challenge 31, so that it can be measured across a network.
+func insecureCompare(a, b []byte, delay time.Duration) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+	}
+
+	return true
+}
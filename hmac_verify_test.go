@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVerifyHMAC(t *testing.T) {
+	var (
+		key     = []byte("YELLOW SUBMARINE")
+		message = []byte("comment1=cooking%20MCs;userdata=foo")
+		mac     = hmacSHA256(key, message)
+	)
+
+	tampered := bytes.Clone(mac)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name string
+		mac  []byte
+		want bool
+	}{
+		{name: "valid", mac: mac, want: true},
+		{name: "tampered", mac: tampered},
+		{name: "truncated", mac: mac[:len(mac)-1]},
+		{name: "empty"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := verifyHMAC(key, message, test.mac); got != test.want {
+				t.Errorf("verifyHMAC: want %t, got %t", test.want, got)
+			}
+			if got := insecureVerifyHMAC(key, message, test.mac, 0); got != test.want {
+				t.Errorf("insecureVerifyHMAC: want %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+// wrongMACs returns two wrong MACs of message under key: the first one is
+// wrong from its first byte, the second one only in its last byte.
+func wrongMACs(key, message []byte) ([]byte, []byte) {
+	var (
+		wrongFirst = hmacSHA256(key, message)
+		wrongLast  = hmacSHA256(key, message)
+	)
+	wrongFirst[0] ^= 1
+	wrongLast[len(wrongLast)-1] ^= 1
+
+	return wrongFirst, wrongLast
+}
+
+func TestInsecureVerifyHMACLeaks(t *testing.T) {
+	var (
+		key, message          = []byte("YELLOW SUBMARINE"), []byte("foo")
+		wrongFirst, wrongLast = wrongMACs(key, message)
+	)
+
+	const delay = 5 * time.Microsecond
+	timings := measureTimings(
+		20,
+		1,
+		func() { insecureVerifyHMAC(key, message, wrongFirst, delay) },
+		func() { insecureVerifyHMAC(key, message, wrongLast, delay) },
+	)
+
+	// the MAC that is right for longer takes longer to reject.
+	leak := timingLeak(timings[1], timings[0])
+	t.Logf("wrong last byte vs wrong first byte: t = %.2f", leak)
+	if leak < 4.5 {
+		t.Errorf("want t > 4.5, got %.2f", leak)
+	}
+}
+
+func TestVerifyHMACConstantTime(t *testing.T) {
+	var (
+		key, message          = []byte("YELLOW SUBMARINE"), []byte("foo")
+		wrongFirst, wrongLast = wrongMACs(key, message)
+	)
+
+	timings := measureTimings(
+		200,
+		100,
+		func() { verifyHMAC(key, message, wrongFirst) },
+		func() { verifyHMAC(key, message, wrongLast) },
+	)
+
+	// a generous bound: a leak as large as insecureVerifyHMAC's would be way
+	// beyond it, whereas the noise of a busy machine shouldn't be.
+	leak := timingLeak(timings[1], timings[0])
+	t.Logf("wrong last byte vs wrong first byte: t = %.2f", leak)
+	if math.Abs(leak) > 10 {
+		t.Errorf("want |t| <= 10, got %.2f", leak)
+	}
+}
+
+// BenchmarkVerifyHMAC compares how long the verifiers take to reject a MAC
+// that is wrong from its first byte and one that is wrong only in its last:
+// the insecure one is slower on the latter, the constant time one isn't.
+func BenchmarkVerifyHMAC(b *testing.B) {
+	var (
+		key, message          = []byte("YELLOW SUBMARINE"), []byte("foo")
+		wrongFirst, wrongLast = wrongMACs(key, message)
+	)
+
+	verifiers := []struct {
+		name   string
+		verify func(mac []byte) bool
+	}{
+		{name: "constant time", verify: func(mac []byte) bool { return verifyHMAC(key, message, mac) }},
+		{name: "insecure", verify: func(mac []byte) bool { return insecureVerifyHMAC(key, message, mac, 0) }},
+	}
+	for _, v := range verifiers {
+		for _, mac := range []struct {
+			name string
+			mac  []byte
+		}{{"first byte wrong", wrongFirst}, {"last byte wrong", wrongLast}} {
+			b.Run(fmt.Sprintf("%s/%s", v.name, mac.name), func(b *testing.B) {
+				for range b.N {
+					v.verify(mac.mac)
+				}
+			})
+		}
+	}
+}
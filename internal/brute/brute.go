@@ -0,0 +1,158 @@
+// Package brute provides a generic engine to brute force small keyspaces
+// (e.g., the 256 values of a byte, or all the 2-character strings over an
+// alphabet) with parallel workers that stop as soon as a key is found.
+package brute
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNotFound is returned by Search when no key satisfies the predicate.
+var ErrNotFound = errors.New("no key satisfies the predicate")
+
+// Keyspace defines a finite, enumerable set of keys.
+type Keyspace[K any] interface {
+	// Size returns the number of keys in the keyspace.
+	Size() uint64
+
+	// Key returns the i-th key of the keyspace, with 0 <= i < Size().
+	Key(i uint64) K
+}
+
+// Bytes is the keyspace of all the 256 byte values, in increasing order.
+type Bytes struct{}
+
+// Size implements Keyspace.
+func (Bytes) Size() uint64 { return 256 }
+
+// Key implements Keyspace.
+func (Bytes) Key(i uint64) byte { return byte(i) }
+
+// Uint16s is the keyspace of all the 65536 16-bit values, in increasing order.
+type Uint16s struct{}
+
+// Size implements Keyspace.
+func (Uint16s) Size() uint64 { return 1 << 16 }
+
+// Key implements Keyspace.
+func (Uint16s) Key(i uint64) uint16 { return uint16(i) }
+
+// Strings is the keyspace of all the strings of Len bytes made of the bytes
+// in Alphabet, in lexicographic order of their indexes in Alphabet.
+type Strings struct {
+	Alphabet string
+	Len      int
+}
+
+// Size implements Keyspace.
+func (s Strings) Size() uint64 {
+	size := uint64(1)
+	for range s.Len {
+		size *= uint64(len(s.Alphabet))
+	}
+	return size
+}
+
+// Key implements Keyspace.
+func (s Strings) Key(i uint64) string {
+	var (
+		base = uint64(len(s.Alphabet))
+		key  = make([]byte, s.Len)
+	)
+	for pos := s.Len - 1; pos >= 0; pos-- {
+		key[pos] = s.Alphabet[i%base]
+		i /= base
+	}
+	return string(key)
+}
+
+// Options configures Search.
+type Options struct {
+	// Workers is the number of goroutines trying keys in parallel. If <= 0,
+	// it defaults to GOMAXPROCS.
+	// With a single worker, the keys are tried in order and Search returns
+	// the first one satisfying the predicate.
+	Workers int
+
+	// Progress, if not nil, is called after every key tried with the number
+	// of keys tried so far and the size of the keyspace. With more than one
+	// worker it's called concurrently.
+	Progress func(tried, total uint64)
+}
+
+// Search tries the keys of ks until pred reports that one of them is the key
+// we are looking for, and returns it.
+// With more than one worker, pred is called concurrently and, if several keys
+// satisfy it, Search returns one of them.
+// It stops at the first error returned by pred, or when ctx is done. If no
+// key satisfies pred, it returns ErrNotFound.
+func Search[K any](
+	ctx context.Context,
+	ks Keyspace[K],
+	pred func(K) (bool, error),
+	opts Options,
+) (K, error) {
+
+	var (
+		total   = ks.Size()
+		workers = opts.Workers
+	)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = int(min(uint64(workers), max(total, 1)))
+
+	var (
+		g, gCtx = errgroup.WithContext(ctx)
+		found   atomic.Bool
+		tried   atomic.Uint64
+		key     K
+	)
+	for w := range workers {
+		g.Go(func() error {
+			for i := uint64(w); i < total; i += uint64(workers) {
+				if found.Load() {
+					return nil
+				}
+				if err := gCtx.Err(); err != nil {
+					return err
+				}
+
+				candidate := ks.Key(i)
+				ok, err := pred(candidate)
+				if err != nil {
+					return err
+				}
+
+				n := tried.Add(1)
+				if opts.Progress != nil {
+					opts.Progress(n, total)
+				}
+
+				if ok {
+					if found.CompareAndSwap(false, true) {
+						key = candidate
+					}
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if found.Load() {
+		return key, nil
+	}
+
+	var zero K
+	if err != nil {
+		return zero, err
+	}
+	return zero, ErrNotFound
+}
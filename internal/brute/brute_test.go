@@ -0,0 +1,122 @@
+package brute
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestKeyspaces(t *testing.T) {
+	if got := (Bytes{}).Key(200); got != 200 {
+		t.Errorf("want key 200, got %d", got)
+	}
+	if got := (Uint16s{}).Size(); got != 65536 {
+		t.Errorf("want 65536 keys, got %d", got)
+	}
+
+	s := Strings{Alphabet: "abc", Len: 2}
+	if got := s.Size(); got != 9 {
+		t.Errorf("want 9 keys, got %d", got)
+	}
+
+	want := []string{"aa", "ab", "ac", "ba", "bb", "bc", "ca", "cb", "cc"}
+	for i, w := range want {
+		if got := s.Key(uint64(i)); got != w {
+			t.Errorf("key %d: want %q, got %q", i, w, got)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	for _, workers := range []int{1, 4, 0} {
+		var calls atomic.Int64
+		key, err := Search(
+			context.Background(),
+			Uint16s{},
+			func(k uint16) (bool, error) {
+				calls.Add(1)
+				return k == 4242, nil
+			},
+			Options{Workers: workers},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if key != 4242 {
+			t.Errorf("workers %d: want key 4242, got %d", workers, key)
+		}
+		if workers == 1 && calls.Load() != 4243 {
+			t.Errorf("want 4243 keys tried in order, got %d", calls.Load())
+		}
+		if calls.Load() == 65536 {
+			t.Errorf("workers %d: search didn't exit early", workers)
+		}
+	}
+}
+
+func TestSearchFirstMatch(t *testing.T) {
+	key, err := Search(
+		context.Background(),
+		Strings{Alphabet: "xyz", Len: 2},
+		func(k string) (bool, error) { return k[1] == 'z', nil },
+		Options{Workers: 1},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key != "xz" {
+		t.Errorf("want %q, got %q", "xz", key)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	never := func(byte) (bool, error) { return false, nil }
+
+	_, err := Search(context.Background(), Bytes{}, never, Options{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("want %v, got %v", ErrNotFound, err)
+	}
+
+	errBoom := errors.New("boom")
+	_, err = Search(
+		context.Background(),
+		Bytes{},
+		func(k byte) (bool, error) {
+			if k == 10 {
+				return false, errBoom
+			}
+			return false, nil
+		},
+		Options{Workers: 2},
+	)
+	if !errors.Is(err, errBoom) {
+		t.Errorf("want %v, got %v", errBoom, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Search(ctx, Bytes{}, never, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestSearchProgress(t *testing.T) {
+	var last atomic.Uint64
+	_, err := Search(
+		context.Background(),
+		Bytes{},
+		func(k byte) (bool, error) { return k == 99, nil },
+		Options{
+			Workers:  1,
+			Progress: func(tried, total uint64) { last.Store(tried) },
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if last.Load() != 100 {
+		t.Errorf("want 100 keys tried, got %d", last.Load())
+	}
+}
@@ -0,0 +1,80 @@
+// Package parallel provides bounded parallel map/reduce over index ranges,
+// shared by the attacks that split their work into independent pieces (e.g.,
+// one per candidate key size, or one per line of a file).
+package parallel
+
+import (
+	"context"
+	"runtime"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Map calls fn for every index in [0, n), running at most limit calls at the
+// same time, and returns their results in index order. If limit <= 0, it
+// defaults to GOMAXPROCS.
+// The first error returned by fn cancels the context passed to the other
+// calls, and is returned once all the running calls have returned.
+func Map[T any](
+	ctx context.Context,
+	n, limit int,
+	fn func(ctx context.Context, i int) (T, error),
+) ([]T, error) {
+
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		results = make([]T, max(n, 0))
+		g, gCtx = errgroup.WithContext(ctx)
+	)
+	g.SetLimit(limit)
+
+	for i := range n {
+		if gCtx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			r, err := fn(gCtx, i)
+			if err != nil {
+				return err
+			}
+			results[i] = r
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// MapReduce is like Map, but folds the results with reduce, starting from
+// init, in index order. Since the order doesn't depend on scheduling, neither
+// does the result, e.g., when picking the best of several equal results.
+func MapReduce[T, R any](
+	ctx context.Context,
+	n, limit int,
+	fn func(ctx context.Context, i int) (T, error),
+	reduce func(acc R, i int, result T) R,
+	init R,
+) (R, error) {
+
+	results, err := Map(ctx, n, limit, fn)
+	if err != nil {
+		return init, err
+	}
+
+	acc := init
+	for i, r := range results {
+		acc = reduce(acc, i, r)
+	}
+
+	return acc, nil
+}
@@ -0,0 +1,83 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	var running, maxRunning atomic.Int64
+	got, err := Map(context.Background(), 100, 3, func(_ context.Context, i int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		return i * i, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i, v := range got {
+		if v != i*i {
+			t.Fatalf("result %d: want %d, got %d", i, i*i, v)
+		}
+	}
+	if m := maxRunning.Load(); m > 3 {
+		t.Errorf("want at most 3 concurrent calls, got %d", m)
+	}
+}
+
+func TestMapError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var calls atomic.Int64
+	_, err := Map(context.Background(), 1000, 1, func(_ context.Context, i int) (int, error) {
+		calls.Add(1)
+		if i == 5 {
+			return 0, errBoom
+		}
+		return i, nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("want %v, got %v", errBoom, err)
+	}
+	if c := calls.Load(); c > 7 {
+		t.Errorf("the error didn't stop the map: %d calls", c)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Map(ctx, 10, 0, func(context.Context, int) (int, error) { return 0, nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestMapReduce(t *testing.T) {
+	// every index has the same value: the reduction must pick the first one
+	// regardless of scheduling.
+	best, err := MapReduce(
+		context.Background(), 50, 8,
+		func(context.Context, int) (int, error) { return 7, nil },
+		func(acc, i, v int) int {
+			if acc < 0 || v > 7 {
+				return i
+			}
+			return acc
+		},
+		-1,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if best != 0 {
+		t.Errorf("want index 0, got %d", best)
+	}
+}
@@ -0,0 +1,119 @@
+// Package testutil provides helpers shared by the tests of the cryptopals
+// solutions: decoding and loading fixtures, generating keys, and comparing
+// outputs against golden files.
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"os"
+	"testing"
+)
+
+// _update makes Golden write the outputs to the golden files instead of
+// comparing them, e.g.: go test -run TestDecryptAesEcb -update
+var _update = flag.Bool("update", false, "update the golden files")
+
+// MustDecodeHex decodes the hex encoded string s, failing the test on error.
+func MustDecodeHex(tb testing.TB, s string) []byte {
+	tb.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		tb.Fatalf("malformed hex string %q: %s", s, err)
+	}
+	return b
+}
+
+// MustLoadBase64 reads the base64 encoded file at path and returns its
+// decoded content, failing the test on error. Line breaks are ignored.
+func MustLoadBase64(tb testing.TB, path string) []byte {
+	tb.Helper()
+
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("reading file: %s", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(encoded), nil)))
+	if err != nil {
+		tb.Fatalf("decoding file %s from base64: %s", path, err)
+	}
+	return decoded
+}
+
+// MustLoadHexLines reads the file at path and hex decodes each of its lines,
+// failing the test on error.
+func MustLoadHexLines(tb testing.TB, path string) [][]byte {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		tb.Fatalf("opening file: %s", err)
+	}
+	defer f.Close()
+
+	var (
+		s     = bufio.NewScanner(f)
+		lines [][]byte
+	)
+	for s.Scan() {
+		lines = append(lines, MustDecodeHex(tb, s.Text()))
+	}
+
+	if err := s.Err(); err != nil {
+		tb.Fatalf("parsing file: %s", err)
+	}
+
+	return lines
+}
+
+// RandomBytes returns n random bytes, failing the test on error.
+func RandomBytes(tb testing.TB, n int) []byte {
+	tb.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		tb.Fatalf("generating random bytes: %s", err)
+	}
+	return b
+}
+
+// RandomKey returns a random AES-128 key, failing the test on error.
+func RandomKey(tb testing.TB) []byte {
+	tb.Helper()
+	return RandomBytes(tb, 16)
+}
+
+// RandomIV returns a random IV for AES, failing the test on error.
+func RandomIV(tb testing.TB) []byte {
+	tb.Helper()
+	return RandomBytes(tb, 16)
+}
+
+// Golden compares got with the content of the golden file at path, failing
+// the test if they differ. When the tests run with -update, it writes got to
+// the golden file instead.
+func Golden(tb testing.TB, path string, got []byte) {
+	tb.Helper()
+
+	if *_update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatalf("updating golden file: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("reading golden file: %s", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		tb.Errorf("output differs from golden file %s\nwant:\t%q\ngot:\t%q\n", path, want, got)
+	}
+}
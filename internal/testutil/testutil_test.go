@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMustLoadBase64(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("SGVsbG8g\nV29ybGQ=\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := MustLoadBase64(t, path); string(got) != "Hello World" {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "Hello World", got)
+	}
+}
+
+func TestMustLoadHexLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(path, []byte("6162\n63\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := MustLoadHexLines(t, path)
+	if len(got) != 2 || !bytes.Equal(got[0], []byte("ab")) || !bytes.Equal(got[1], []byte("c")) {
+		t.Errorf("unexpected lines: %q", got)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.golden")
+	if err := os.WriteFile(path, []byte("expected"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	Golden(t, path, []byte("expected"))
+}
+
+func TestRandomKey(t *testing.T) {
+	a, b := RandomKey(t), RandomKey(t)
+	if len(a) != 16 || bytes.Equal(a, b) {
+		t.Errorf("unexpected keys %x and %x", a, b)
+	}
+}
+
+func TestRandomIV(t *testing.T) {
+	a, b := RandomIV(t), RandomIV(t)
+	if len(a) != 16 || bytes.Equal(a, b) {
+		t.Errorf("unexpected IVs %x and %x", a, b)
+	}
+}
+
+func TestRandomBytes(t *testing.T) {
+	if got := RandomBytes(t, 32); len(got) != 32 {
+		t.Errorf("want 32 bytes, got %d", len(got))
+	}
+	if got := RandomBytes(t, 0); len(got) != 0 {
+		t.Errorf("want no bytes, got %d", len(got))
+	}
+}
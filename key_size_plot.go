@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// _keySizePlotWidth is the length, in characters, of the longest bar drawn by
+// plotKeySizeDistances.
+const _keySizePlotWidth = 50
+
+// plotKeySizeDistances draws the normalized Hamming distance of each key size
+// on w as a horizontal bar chart, one key size per line, and marks the chosen
+// key size.
+// The distances of wrong key sizes are all close to each other (around 3
+// bits per byte for English text), so bars don't start from 0 but from a bit
+// below the smallest distance, which makes the right key size (and its
+// multiples) stand out.
+func plotKeySizeDistances(w io.Writer, distances []keySizeDistance, chosen int) error {
+	if len(distances) == 0 {
+		_, err := fmt.Fprintln(w, "the cipher text is too short to compare key sizes")
+		return err
+	}
+
+	lo, hi := distances[0].distance, distances[0].distance
+	for _, d := range distances {
+		lo = min(lo, d.distance)
+		hi = max(hi, d.distance)
+	}
+	lo *= 0.9
+
+	var b strings.Builder
+	b.WriteString("key size  distance\n")
+	for _, d := range distances {
+		barLen := _keySizePlotWidth
+		if hi > lo {
+			barLen = 1 + int(float64(_keySizePlotWidth-1)*(d.distance-lo)/(hi-lo))
+		}
+
+		fmt.Fprintf(&b, "%8d  %8.4f |%s", d.keySize, d.distance, strings.Repeat("#", barLen))
+		if d.keySize == chosen {
+			b.WriteString(" <- chosen")
+		}
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPlotKeySizeDistances(t *testing.T) {
+	var (
+		distances = []keySizeDistance{{2, 3.3}, {3, 2.7}, {4, 3.2}}
+		out       bytes.Buffer
+	)
+	if err := plotKeySizeDistances(&out, distances, 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want a header and 3 lines, got:\n%s", out.String())
+	}
+
+	bar := func(line string) int { return strings.Count(line, "#") }
+	if bar(lines[1]) != _keySizePlotWidth {
+		t.Errorf("the largest distance has a bar of %d, want %d", bar(lines[1]), _keySizePlotWidth)
+	}
+	if bar(lines[2]) >= bar(lines[3]) {
+		t.Errorf("the smallest distance doesn't have the shortest bar:\n%s", out.String())
+	}
+	if !strings.HasSuffix(lines[2], "<- chosen") || strings.Contains(lines[1]+lines[3], "chosen") {
+		t.Errorf("only key size 3 must be marked as chosen:\n%s", out.String())
+	}
+}
+
+func TestPlotKeySizeDistancesEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := plotKeySizeDistances(&out, nil, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), "too short") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/aes"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"sync"
+)
+
+// errNonceReused is returned when a nonce that has already been issued (or
+// registered) in this process is used again.
+var errNonceReused = errors.New("nonce reused")
+
+// _issuedNonces keeps track of the nonces issued by insecure generators or
+// registered in this process, so that we can detect (and demonstrate)
+// nonce-reuse bugs. Nonces from crypto/rand aren't kept: nothing ever removes
+// them, so a long-running process would fill it up.
+var _issuedNonces = struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}{seen: make(map[string]struct{})}
+
+// keyGenerator generates AES keys, IVs and nonces reading from a source of
+// randomness.
+type keyGenerator struct {
+	mu   sync.Mutex
+	rand io.Reader
+
+	// trackNonces makes the generator register the nonces it issues (see
+	// registerNonce).
+	trackNonces bool
+}
+
+// newKeyGenerator returns a keyGenerator backed by crypto/rand.
+func newKeyGenerator() *keyGenerator {
+	return &keyGenerator{rand: crand.Reader}
+}
+
+// newInsecureKeyGenerator returns a keyGenerator backed by math/rand seeded
+// with the given seed. Keys generated this way are predictable: use it only
+// to build targets for attacks.
+func newInsecureKeyGenerator(seed uint64) *keyGenerator {
+	src := mrand.New(mrand.NewPCG(seed, seed))
+	return &keyGenerator{rand: insecureReader{src}, trackNonces: true}
+}
+
+// _keyGen is the keyGenerator used by newAESKey, newIV and newNonce.
+var _keyGen = newKeyGenerator()
+
+// newAESKey is a wrapper of keyGenerator.aesKey using crypto/rand.
+func newAESKey(bits int) ([]byte, error) {
+	return _keyGen.aesKey(bits)
+}
+
+// newIV is a wrapper of keyGenerator.iv using crypto/rand.
+func newIV(blockSize int) ([]byte, error) {
+	return _keyGen.iv(blockSize)
+}
+
+// newNonce is a wrapper of keyGenerator.nonce using crypto/rand.
+func newNonce(size int) ([]byte, error) {
+	return _keyGen.nonce(size)
+}
+
+// aesKey returns a random AES key of the given size in bits.
+// AES only accepts 128, 192 and 256 bits keys.
+func (kg *keyGenerator) aesKey(bits int) ([]byte, error) {
+	switch bits {
+	case 128, 192, 256:
+	default:
+		return nil, fmt.Errorf("invalid AES key size: %d bits", bits)
+	}
+
+	return kg.read(bits / 8)
+}
+
+// iv returns a random initialization vector for a block cipher with the given
+// block size.
+func (kg *keyGenerator) iv(blockSize int) ([]byte, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid IV size: %d", blockSize)
+	}
+
+	return kg.read(blockSize)
+}
+
+// nonce returns a random nonce of the given size. Insecure generators also
+// register it as used in this process, and return errNonceReused if they
+// produced a nonce that was already used, e.g., because another generator was
+// seeded the same way.
+func (kg *keyGenerator) nonce(size int) ([]byte, error) {
+	if size <= 0 || size > aes.BlockSize {
+		const formatStr = "invalid nonce size: %d (must be between 1 and %d)"
+		return nil, fmt.Errorf(formatStr, size, aes.BlockSize)
+	}
+
+	nonce, err := kg.read(size)
+	if err != nil {
+		return nil, err
+	}
+
+	if !kg.trackNonces {
+		return nonce, nil
+	}
+	return nonce, registerNonce(nonce)
+}
+
+// read returns n random bytes read from the generator's source.
+func (kg *keyGenerator) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+
+	kg.mu.Lock()
+	_, err := io.ReadFull(kg.rand, buf)
+	kg.mu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("reading random bytes: %w", err)
+	}
+
+	return buf, nil
+}
+
+// registerNonce marks the given nonce as used in this process. It returns
+// errNonceReused if it was already used.
+// Use it when a nonce is not generated by newNonce (e.g., a fixed nonce).
+func registerNonce(nonce []byte) error {
+	_issuedNonces.mu.Lock()
+	defer _issuedNonces.mu.Unlock()
+
+	if _, ok := _issuedNonces.seen[string(nonce)]; ok {
+		return fmt.Errorf("%w: %x", errNonceReused, nonce)
+	}
+	_issuedNonces.seen[string(nonce)] = struct{}{}
+
+	return nil
+}
+
+// insecureReader is an io.Reader that reads from a math/rand generator.
+type insecureReader struct {
+	src *mrand.Rand
+}
+
+func (ir insecureReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(ir.src.Uint32())
+	}
+	return len(p), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewAESKey(t *testing.T) {
+	for _, bits := range []int{128, 192, 256} {
+		key, err := newAESKey(bits)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(key) != bits/8 {
+			t.Errorf("want a %d bytes key, got %d bytes", bits/8, len(key))
+		}
+	}
+
+	if _, err := newAESKey(100); err == nil {
+		t.Errorf("expected an error for a 100 bits key")
+	}
+}
+
+func TestInsecureKeyGenerator(t *testing.T) {
+	const seed = 42
+	var (
+		kg1 = newInsecureKeyGenerator(seed)
+		kg2 = newInsecureKeyGenerator(seed)
+	)
+
+	key1, err := kg1.aesKey(128)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	key2, err := kg2.aesKey(128)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// same seed, same key: that's what makes it insecure.
+	if !bytes.Equal(key1, key2) {
+		t.Errorf("keys generated with the same seed differ:\n%x\n%x", key1, key2)
+	}
+}
+
+func TestNonceReuse(t *testing.T) {
+	const seed = 7
+
+	// the nonce registry is process-wide, so this one might already be
+	// registered if the test runs more than once.
+	_, err := newInsecureKeyGenerator(seed).nonce(8)
+	if err != nil && !errors.Is(err, errNonceReused) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// a second generator with the same seed produces the same nonce.
+	_, err = newInsecureKeyGenerator(seed).nonce(8)
+	if !errors.Is(err, errNonceReused) {
+		t.Errorf("want %q error, got %v", errNonceReused, err)
+	}
+}
+
+func TestNewNonceNotRegistered(t *testing.T) {
+	nonce, err := newNonce(8)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// registering it would fail if newNonce had registered it, and the
+	// registry would grow with every sealed box.
+	if err := registerNonce(nonce); err != nil {
+		t.Errorf("newNonce registered its nonce: %s", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command defines a cryptopals CLI subcommand.
+type command struct {
+	// summary is a one line description of the command shown in the usage.
+	summary string
+
+	// run executes the command with the given arguments, reading its input
+	// from stdin and writing its output to stdout.
+	run func(args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+// _commands maps the name of each subcommand to its implementation.
+var _commands = map[string]command{
+	"enc": {
+		summary: "encrypt a file with AES",
+		run:     runEnc,
+	},
+	"dec": {
+		summary: "decrypt a file with AES",
+		run:     runDec,
+	},
+	"detect-ecb": {
+		summary: "find the cipher texts encrypted with AES ECB in a file",
+		run:     runDetectECB,
+	},
+	"crack": {
+		summary: "run an attack against a cipher text or a remote oracle",
+		run:     runCrack,
+	},
+	"stats": {
+		summary: "compare the attack runs kept in a results store",
+		run:     runStats,
+	},
+	"gen": {
+		summary: "generate a fresh variant of an exercise, with its answers",
+		run:     runGen,
+	},
+	"serve": {
+		summary: "run an oracle over stdin and stdout",
+		run:     runServe,
+	},
+	"sets": {
+		summary: "run the implemented challenges of each set and check their outputs",
+		run:     runSets,
+	},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cryptopals:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the subcommand named by the first of the given arguments.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage())
+	}
+
+	cmd, ok := _commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+
+	return cmd.run(args[1:], stdin, stdout)
+}
+
+// usage returns the list of the available subcommands.
+func usage() string {
+	names := make([]string, 0, len(_commands))
+	for name := range _commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("usage: cryptopals <command> [flags]\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-10s %s\n", name, _commands[name].summary)
+	}
+
+	return b.String()
+}
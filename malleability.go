@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/aes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// malleableMode is a mode of operation under a fixed key, as
+// measureMalleability sees it.
+type malleableMode struct {
+	name string
+
+	// headerLen is the number of bytes encrypt prepends to the cipher text
+	// proper (e.g., the IV or the nonce).
+	headerLen int
+
+	// encrypt encrypts a plain text, and decrypt returns the plain text of a
+	// cipher text returned by encrypt, padding included. decrypt returns an
+	// error if it detects that the cipher text was modified.
+	encrypt func(plainText []byte) ([]byte, error)
+	decrypt func(cipherText []byte) ([]byte, error)
+}
+
+// malleableModes returns the modes of operation implemented in this package,
+// each under its own random key: ECB, CBC, CTR, ChaCha20 and sealBox.
+func malleableModes() ([]malleableMode, error) {
+	aesKey, err := newAESKey(128)
+	if err != nil {
+		return nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+	chachaKey, err := randomBytesN(_chachaKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("generating random ChaCha20 key: %w", err)
+	}
+	boxKey, err := newAESKey(8 * _boxKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("generating random box key: %w", err)
+	}
+
+	modes := []malleableMode{
+		{
+			name: "ECB",
+			encrypt: func(plainText []byte) ([]byte, error) {
+				return encryptAesEcb(plainText, aesKey)
+			},
+			decrypt: func(cipherText []byte) ([]byte, error) {
+				return decryptAesEcb(cipherText, aesKey)
+			},
+		},
+		{
+			name:      "CBC",
+			headerLen: aes.BlockSize,
+			encrypt: func(plainText []byte) ([]byte, error) {
+				iv, err := newIV(aes.BlockSize)
+				if err != nil {
+					return nil, err
+				}
+				cipherText, err := encryptAesCbc(plainText, aesKey, iv)
+				return concatInto(nil, iv, cipherText), err
+			},
+			decrypt: func(cipherText []byte) ([]byte, error) {
+				iv, cipherText := cipherText[:aes.BlockSize], cipherText[aes.BlockSize:]
+				return decryptAesCbc(cipherText, aesKey, iv)
+			},
+		},
+		{
+			name:      "CTR",
+			headerLen: _ctrNonceSize,
+			encrypt: func(plainText []byte) ([]byte, error) {
+				nonce, err := randomBytesN(_ctrNonceSize)
+				if err != nil {
+					return nil, err
+				}
+				cipherText, err := encryptAesCtr(plainText, aesKey, nonce)
+				return concatInto(nil, nonce, cipherText), err
+			},
+			decrypt: func(cipherText []byte) ([]byte, error) {
+				return encryptAesCtr(cipherText[_ctrNonceSize:], aesKey, cipherText[:_ctrNonceSize])
+			},
+		},
+		{
+			name:      "ChaCha20",
+			headerLen: _chachaNonceSize,
+			encrypt: func(plainText []byte) ([]byte, error) {
+				nonce, err := randomBytesN(_chachaNonceSize)
+				if err != nil {
+					return nil, err
+				}
+				cipherText, err := chacha20XOR(plainText, chachaKey, nonce, 1)
+				return concatInto(nil, nonce, cipherText), err
+			},
+			decrypt: func(cipherText []byte) ([]byte, error) {
+				return chacha20XOR(cipherText[_chachaNonceSize:], chachaKey, cipherText[:_chachaNonceSize], 1)
+			},
+		},
+		{
+			name:      "box (CTR+HMAC)",
+			headerLen: _ctrNonceSize,
+			encrypt: func(plainText []byte) ([]byte, error) {
+				return sealBox(boxKey, plainText)
+			},
+			decrypt: func(cipherText []byte) ([]byte, error) {
+				return openBox(boxKey, cipherText)
+			},
+		},
+	}
+
+	return modes, nil
+}
+
+// byteDamage is what flipping bits of a cipher text did to a byte of the
+// plain text.
+type byteDamage int
+
+const (
+	// byteIntact means the byte didn't change.
+	byteIntact byteDamage = iota
+
+	// byteFlipped means the byte changed by exactly the bits flipped in the
+	// cipher text: an attacker controls it.
+	byteFlipped
+
+	// byteGarbled means the byte changed unpredictably.
+	byteGarbled
+)
+
+// _malleabilityMasks are the bits measureMalleability flips, one mask at a
+// time. A garbled byte changes by one of them with probability 1/256: with
+// several masks, it's very unlikely to follow all of them.
+var _malleabilityMasks = []byte{0x01, 0x10, 0x80, 0xff}
+
+// malleability is the damage flipping bits of a byte of a cipher text does to
+// its plain text.
+type malleability struct {
+	// mode is the name of the mode of operation.
+	mode string
+
+	// flipped is the index of the byte flipped, in the cipher text proper
+	// (i.e., after the mode's header).
+	flipped int
+
+	// rejected is true if the mode refused to decrypt the modified cipher
+	// text.
+	rejected bool
+
+	// damage is what happened to each byte of the plain text.
+	damage []byteDamage
+}
+
+// measureMalleability encrypts plainText with mode, flips bits of byte n of
+// the cipher text (not counting the mode's header) with each of
+// _malleabilityMasks in turn, decrypts it, and reports the damage done to
+// each byte of the plain text, padding included. A byte is flipped only if it
+// changed by exactly the mask every time, and garbled if it changed in any
+// other way.
+func measureMalleability(mode malleableMode, plainText []byte, n int) (malleability, error) {
+	cipherText, err := mode.encrypt(plainText)
+	if err != nil {
+		return malleability{}, fmt.Errorf("encrypting with %s: %w", mode.name, err)
+	}
+	if n < 0 || mode.headerLen+n >= len(cipherText) {
+		const formatStr = "can't flip byte %d of a %d-byte %s cipher text"
+		return malleability{}, fmt.Errorf(formatStr, n, len(cipherText)-mode.headerLen, mode.name)
+	}
+
+	want, err := mode.decrypt(cipherText)
+	if err != nil {
+		return malleability{}, fmt.Errorf("decrypting with %s: %w", mode.name, err)
+	}
+
+	var (
+		result = malleability{mode: mode.name, flipped: n, damage: make([]byteDamage, len(want))}
+
+		// changed and followed count the masks with which each byte
+		// changed, and changed by exactly the mask.
+		changed  = make([]int, len(want))
+		followed = make([]int, len(want))
+	)
+	for _, mask := range _malleabilityMasks {
+		cipherText[mode.headerLen+n] ^= mask
+		got, err := mode.decrypt(cipherText)
+		cipherText[mode.headerLen+n] ^= mask
+
+		if err != nil {
+			return malleability{mode: mode.name, flipped: n, rejected: true}, nil
+		}
+		if len(got) != len(want) {
+			const formatStr = "%s decrypted a %d-byte plain text to %d bytes"
+			return malleability{}, fmt.Errorf(formatStr, mode.name, len(want), len(got))
+		}
+
+		for i := range got {
+			switch got[i] ^ want[i] {
+			case 0:
+			case mask:
+				changed[i]++
+				followed[i]++
+			default:
+				changed[i]++
+			}
+		}
+	}
+
+	for i := range result.damage {
+		switch {
+		case changed[i] == 0:
+			result.damage[i] = byteIntact
+		case followed[i] == len(_malleabilityMasks):
+			result.damage[i] = byteFlipped
+		default:
+			result.damage[i] = byteGarbled
+		}
+	}
+
+	return result, nil
+}
+
+// String summarizes the damage, e.g., "bytes 0-15 garbled, byte 21 flipped".
+func (m malleability) String() string {
+	if m.rejected {
+		return "rejected"
+	}
+
+	var parts []string
+	for start := 0; start < len(m.damage); {
+		end := start
+		for end+1 < len(m.damage) && m.damage[end+1] == m.damage[start] {
+			end++
+		}
+
+		if d := m.damage[start]; d != byteIntact {
+			what := fmt.Sprintf("bytes %d-%d", start, end)
+			if start == end {
+				what = fmt.Sprintf("byte %d", start)
+			}
+			if d == byteFlipped {
+				parts = append(parts, what+" flipped")
+			} else {
+				parts = append(parts, what+" garbled")
+			}
+		}
+		start = end + 1
+	}
+
+	if len(parts) == 0 {
+		return "intact"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareMalleability flips byte n of the cipher text of plainText in each of
+// malleableModes, and returns the damage each one suffered.
+func compareMalleability(plainText []byte, n int) ([]malleability, error) {
+	modes, err := malleableModes()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]malleability, 0, len(modes))
+	for _, mode := range modes {
+		result, err := measureMalleability(mode, plainText, n)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// writeMalleabilityTable writes a table comparing the damage done by
+// flipping the same byte of the cipher text in each mode.
+func writeMalleabilityTable(w io.Writer, results []malleability) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tFLIPPED\tPLAIN TEXT")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", r.mode, r.flipped, r)
+	}
+	return tw.Flush()
+}
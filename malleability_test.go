@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+// _malleabilityPlainText is three blocks long, so that the padding takes a
+// whole fourth block in the block cipher modes.
+var _malleabilityPlainText = []byte("comment1=cooking%20MCs;userdata=;admin=false;xxx")
+
+func TestMeasureMalleability(t *testing.T) {
+	tests := []struct {
+		n    int
+		want map[string]string
+	}{
+		{
+			n: 5,
+			want: map[string]string{
+				"ECB":            "bytes 0-15 garbled",
+				"CBC":            "bytes 0-15 garbled, byte 21 flipped",
+				"CTR":            "byte 5 flipped",
+				"ChaCha20":       "byte 5 flipped",
+				"box (CTR+HMAC)": "rejected",
+			},
+		},
+		{
+			n: 47,
+			want: map[string]string{
+				"ECB":            "bytes 32-47 garbled",
+				"CBC":            "bytes 32-47 garbled, byte 63 flipped",
+				"CTR":            "byte 47 flipped",
+				"ChaCha20":       "byte 47 flipped",
+				"box (CTR+HMAC)": "rejected",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		results, err := compareMalleability(_malleabilityPlainText, test.n)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(results) != len(test.want) {
+			t.Fatalf("want %d modes, got %d", len(test.want), len(results))
+		}
+
+		for _, r := range results {
+			if got := r.String(); got != test.want[r.mode] {
+				t.Errorf("%s, byte %d flipped:\nwant:\t%q\ngot:\t%q\n", r.mode, test.n, test.want[r.mode], got)
+			}
+		}
+	}
+}
+
+func TestMeasureMalleabilityOutOfRange(t *testing.T) {
+	modes, err := malleableModes()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, mode := range modes {
+		if _, err := measureMalleability(mode, []byte("YELLOW SUBMARINE"), 64); err == nil {
+			t.Errorf("%s: want error for byte out of range, got nil", mode.name)
+		}
+		if _, err := measureMalleability(mode, []byte("YELLOW SUBMARINE"), -1); err == nil {
+			t.Errorf("%s: want error for negative byte, got nil", mode.name)
+		}
+	}
+}
+
+func TestWriteMalleabilityTable(t *testing.T) {
+	results, err := compareMalleability(_malleabilityPlainText, 21)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeMalleabilityTable(&buf, results); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testutil.Golden(t, "files/malleability.golden", buf.Bytes())
+}
+
+func BenchmarkMeasureMalleability(b *testing.B) {
+	modes, err := malleableModes()
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			for range b.N {
+				if _, err := measureMalleability(mode, _malleabilityPlainText, 21); err != nil {
+					b.Fatalf("unexpected error: %s", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+//go:build openssl
+
+// The tests in this file check our AES implementations against the openssl
+// command line tool. Run them with:
+//
+//	go test -tags openssl -run OpenSSL
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os/exec"
+	"testing"
+)
+
+// opensslEnc runs "openssl enc" with the given cipher, key and IV (empty for
+// ECB), feeding it input, and returns its output. It encrypts, or decrypts if
+// decrypt is true, always using PKCS#7 padding.
+func opensslEnc(
+	t *testing.T,
+	cipher string,
+	key, iv, input []byte,
+	decrypt bool,
+) []byte {
+
+	t.Helper()
+
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found in PATH")
+	}
+
+	args := []string{"enc", "-" + cipher, "-K", hex.EncodeToString(key), "-nosalt"}
+	if len(iv) > 0 {
+		args = append(args, "-iv", hex.EncodeToString(iv))
+	}
+	if decrypt {
+		args = append(args, "-d")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("openssl", args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running openssl %v: %s: %s", args, err, stderr.String())
+	}
+	return out
+}
+
+func TestOpenSSLInterop(t *testing.T) {
+	var (
+		key = []byte("YELLOW SUBMARINE")
+		iv  = []byte("0123456789abcdef")
+	)
+
+	// lengths around the block size, to exercise every padding length.
+	for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 100} {
+		plainText := bytes.Repeat([]byte("cryptopals!"), 10)[:n]
+
+		t.Run("ECB", func(t *testing.T) {
+			got, err := encryptAesEcb(plainText, key)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			want := opensslEnc(t, "aes-128-ecb", key, nil, plainText, false)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("length %d:\nwant:\t%x\ngot:\t%x\n", n, want, got)
+			}
+
+			decrypted, err := decryptAesEcb(want, key)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !bytes.Equal(delPadPkcs7(decrypted), plainText) {
+				t.Errorf("length %d:\nwant:\t%q\ngot:\t%q\n", n, plainText, decrypted)
+			}
+		})
+
+		t.Run("CBC", func(t *testing.T) {
+			got, err := encryptAesCbc(plainText, key, iv)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			want := opensslEnc(t, "aes-128-cbc", key, iv, plainText, false)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("length %d:\nwant:\t%x\ngot:\t%x\n", n, want, got)
+			}
+
+			// openssl checks and removes the padding we added.
+			decrypted := opensslEnc(t, "aes-128-cbc", key, iv, got, true)
+			if !bytes.Equal(decrypted, plainText) {
+				t.Errorf("length %d:\nwant:\t%q\ngot:\t%q\n", n, plainText, decrypted)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	mrand "math/rand/v2"
+	"time"
+)
+
+// errOracleUnavailable is returned by oracles made flaky by flakyOracle.
+var errOracleUnavailable = errors.New("oracle temporarily unavailable")
+
+// flakyOracle returns an aesOracle that wraps the given oracle and fails with
+// errOracleUnavailable with probability p, like an overloaded server would.
+func flakyOracle(oracle aesOracle, p float64) aesOracle {
+	return func(plainText []byte) ([]byte, error) {
+		if mrand.Float64() < p {
+			return nil, errOracleUnavailable
+		}
+		return oracle(plainText)
+	}
+}
+
+// truncatingOracle returns an aesOracle that wraps the given oracle and, with
+// probability p, drops a random number of bytes from the end of the cipher
+// text it returns, like a server closing the connection too early would.
+func truncatingOracle(oracle aesOracle, p float64) aesOracle {
+	return func(plainText []byte) ([]byte, error) {
+		cipherText, err := oracle(plainText)
+		if err != nil || len(cipherText) == 0 || mrand.Float64() >= p {
+			return cipherText, err
+		}
+		return cipherText[:mrand.IntN(len(cipherText))], nil
+	}
+}
+
+// jitteryOracle returns an aesOracle that wraps the given oracle and waits a
+// random time between 0 and maxDelay before answering, like a remote server
+// would.
+func jitteryOracle(oracle aesOracle, maxDelay time.Duration) aesOracle {
+	return func(plainText []byte) ([]byte, error) {
+		time.Sleep(mrand.N(maxDelay + 1))
+		return oracle(plainText)
+	}
+}
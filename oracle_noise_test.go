@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNoisyOracles(t *testing.T) {
+	echo := func(plainText []byte) ([]byte, error) { return plainText, nil }
+
+	var (
+		plainText = []byte("YELLOW SUBMARINE")
+		flaky     = flakyOracle(echo, 1)
+		truncated = truncatingOracle(echo, 1)
+		jittery   = jitteryOracle(echo, time.Millisecond)
+	)
+
+	if _, err := flaky(plainText); !errors.Is(err, errOracleUnavailable) {
+		t.Errorf("want %q error, got %v", errOracleUnavailable, err)
+	}
+
+	got, err := truncated(plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) >= len(plainText) || !bytes.HasPrefix(plainText, got) {
+		t.Errorf("cipher text was not truncated: %q", got)
+	}
+
+	got, err = jittery(plainText)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(got, plainText) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, got)
+	}
+}
+
+func TestDecryptNoisyOracleSecret(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no jitter here: the attack makes thousands of queries.
+	const p = 0.05
+	o = truncatingOracle(flakyOracle(o, p), p)
+
+	decryptedSecret, err := decryptOracleSecret(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, []byte(secret)) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, got)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compression is the format the encryption oracles can compress the plain
+// text with before encrypting it, like protocols that compress their payloads
+// do. The length of the cipher text then depends on the contents of the plain
+// text, and not only on its length: that's the leak challenge 51 exploits.
+type compression int
+
+const (
+	noCompression compression = iota
+	compressFlate
+	compressGzip
+)
+
+// _nCompressions is the number of compression values.
+const _nCompressions = 3
+
+// _compressionNames are the names of the compression values, as accepted by
+// parseCompression.
+var _compressionNames = [_nCompressions]string{
+	noCompression: "none",
+	compressFlate: "flate",
+	compressGzip:  "gzip",
+}
+
+func (c compression) String() string {
+	if c < 0 || c >= _nCompressions {
+		return fmt.Sprintf("compression(%d)", int(c))
+	}
+	return _compressionNames[c]
+}
+
+// parseCompression returns the compression with the given name.
+func parseCompression(name string) (compression, error) {
+	for c, n := range _compressionNames {
+		if n == name {
+			return compression(c), nil
+		}
+	}
+	const formatStr = "unsupported compression %q (must be one of: %s)"
+	return 0, fmt.Errorf(formatStr, name, strings.Join(_compressionNames[:], ", "))
+}
+
+// oracleOptions configures the encryption oracles that append a secret to our
+// input: ecbEncryptionOracle, randomPrefixEcbOracle, twoRegionEcbOracle and
+// newBadChachaOracle.
+type oracleOptions struct {
+	// compression is the format the plain text is compressed with before
+	// being encrypted.
+	compression compression
+
+	// bucket, if positive, is the multiple of which the (compressed) plain
+	// text's length is padded to before being encrypted.
+	bucket int
+}
+
+// oracleOption defines a type that sets an option of the encryption oracles.
+type oracleOption func(*oracleOptions)
+
+// withCompression makes the encryption oracles compress the whole plain text,
+// secret included, with the given format before encrypting it.
+func withCompression(c compression) oracleOption {
+	return func(o *oracleOptions) {
+		o.compression = c
+	}
+}
+
+// withLengthBuckets makes the encryption oracles pad the plain text, after
+// compressing it, to a multiple of size bytes with ISO/IEC 7816-4 padding
+// before encrypting it, so that the length of the cipher text only tells
+// which bucket the plain text falls into. It's how protocols hide the
+// lengths of their messages (e.g., TLS 1.3's record padding). Buckets larger
+// than the block size hide the byte-by-byte changes in length the attacks on
+// the oracles rely on.
+func withLengthBuckets(size int) oracleOption {
+	return func(o *oracleOptions) {
+		o.bucket = size
+	}
+}
+
+// newOracleOptions returns the oracleOptions resulting from applying opts to
+// the default options, which neither compress nor pad the plain text.
+func newOracleOptions(opts []oracleOption) (oracleOptions, error) {
+	var o oracleOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.compression < 0 || o.compression >= _nCompressions {
+		return oracleOptions{}, fmt.Errorf("unsupported compression %s", o.compression)
+	}
+	if o.bucket < 0 {
+		return oracleOptions{}, fmt.Errorf("invalid length bucket %d; must not be negative", o.bucket)
+	}
+
+	return o, nil
+}
+
+// encode compresses and pads the plain text according to the options, before
+// the oracle encrypts it. It returns the plain text itself if there's nothing
+// to do.
+func (o oracleOptions) encode(plainText []byte) ([]byte, error) {
+	if o.compression != noCompression {
+		compressed, err := compress(plainText, o.compression)
+		if err != nil {
+			return nil, fmt.Errorf("compressing plain text: %w", err)
+		}
+		plainText = compressed
+	}
+
+	if o.bucket > 0 {
+		plainText = iso7816Padding{}.pad(plainText, o.bucket)
+	}
+
+	return plainText, nil
+}
+
+// compress returns data compressed with the given format, at the default
+// compression level.
+func compress(data []byte, c compression) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch c {
+	case noCompression:
+		return data, nil
+	case compressFlate:
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		w = fw
+	case compressGzip:
+		w = gzip.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", c)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
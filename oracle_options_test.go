@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseCompression(t *testing.T) {
+	for c := range compression(_nCompressions) {
+		got, err := parseCompression(c.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != c {
+			t.Errorf("want %s, got %s", c, got)
+		}
+	}
+
+	if _, err := parseCompression("zstd"); err == nil {
+		t.Error("want error for unsupported compression, got nil")
+	}
+}
+
+func TestOracleOptionsEncode(t *testing.T) {
+	plainText := []byte(strings.Repeat("YELLOW SUBMARINE", 10) + "!")
+
+	tests := []struct {
+		name   string
+		opts   []oracleOption
+		decode func([]byte) (io.Reader, error)
+		bucket int
+	}{
+		{name: "none"},
+		{
+			name: "flate",
+			opts: []oracleOption{withCompression(compressFlate)},
+			decode: func(b []byte) (io.Reader, error) {
+				return flate.NewReader(bytes.NewReader(b)), nil
+			},
+		},
+		{
+			name: "gzip",
+			opts: []oracleOption{withCompression(compressGzip)},
+			decode: func(b []byte) (io.Reader, error) {
+				return gzip.NewReader(bytes.NewReader(b))
+			},
+		},
+		{name: "buckets", opts: []oracleOption{withLengthBuckets(64)}, bucket: 64},
+		{
+			name: "gzip and buckets",
+			opts: []oracleOption{withCompression(compressGzip), withLengthBuckets(32)},
+			decode: func(b []byte) (io.Reader, error) {
+				return gzip.NewReader(bytes.NewReader(b))
+			},
+			bucket: 32,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options, err := newOracleOptions(test.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			encoded, err := options.encode(plainText)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if test.bucket > 0 {
+				if len(encoded)%test.bucket != 0 {
+					t.Errorf("want a multiple of %d bytes, got %d", test.bucket, len(encoded))
+				}
+				encoded, err = iso7816Padding{}.unpad(encoded, test.bucket)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+
+			if test.decode != nil {
+				r, err := test.decode(encoded)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				encoded, err = io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+
+			if !bytes.Equal(encoded, plainText) {
+				t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, encoded)
+			}
+		})
+	}
+}
+
+func TestOracleOptionsInvalid(t *testing.T) {
+	if _, err := newOracleOptions([]oracleOption{withLengthBuckets(-1)}); err == nil {
+		t.Error("want error for negative bucket, got nil")
+	}
+	if _, err := newOracleOptions([]oracleOption{withCompression(_nCompressions)}); err == nil {
+		t.Error("want error for unsupported compression, got nil")
+	}
+	if _, err := ecbEncryptionOracle(staticSecret("secret"), withLengthBuckets(-1)); err == nil {
+		t.Error("want error for negative bucket, got nil")
+	}
+}
+
+// TestCompressionLeaksContents checks that a compressing oracle leaks what it
+// encrypts: an input repeating the secret compresses better than one sharing
+// nothing with it.
+func TestCompressionLeaksContents(t *testing.T) {
+	const secret = "Cookie: sessionid=TmV2ZXIgcmV2ZWFsIHRoZSBXdS1UYW5nIFNlY3JldCE=; " +
+		"csrftoken=Um9sbGluJyBpbiBteSA1LjAKV2l0aCBteSByYWctdG9wIGRvd24gc28gbXkgaGFpciBjYW4gYmxvdw=="
+
+	oracle, err := ecbEncryptionOracle(staticSecret(secret), withCompression(compressFlate))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	matching, err := oracle([]byte(secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// the same bytes, in an order that doesn't repeat any of the secret's.
+	unrelated, err := oracle(shuffledBytes([]byte(secret)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(matching) >= len(unrelated) {
+		t.Errorf("want matching input shorter than %d bytes, got %d", len(unrelated), len(matching))
+	}
+}
+
+// TestLengthBucketsHideLength checks that inputs of different lengths give
+// cipher texts of the same length when they fall in the same bucket.
+func TestLengthBucketsHideLength(t *testing.T) {
+	oracle, err := ecbEncryptionOracle(staticSecret("YELLOW SUBMARINE"), withLengthBuckets(64))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lengths := make(map[int]bool)
+	for n := range 40 {
+		cipherText, err := oracle(bytes.Repeat([]byte{'A'}, n))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		lengths[len(cipherText)] = true
+	}
+
+	// 16 bytes of secret, up to 39 of input and the padding's 0x80 fit in a
+	// single bucket, plus a block of PKCS#7 padding.
+	if len(lengths) != 1 || !lengths[64+16] {
+		t.Errorf("want all cipher texts 80 bytes long, got lengths %v", lengths)
+	}
+}
+
+func TestServeInvalidOracleOptions(t *testing.T) {
+	var out bytes.Buffer
+	if err := runServe([]string{"-compress", "zstd"}, strings.NewReader(""), &out); err == nil {
+		t.Error("want error for unsupported compression, got nil")
+	}
+	if err := runServe([]string{"-bucket", "-1"}, strings.NewReader(""), &out); err == nil {
+		t.Error("want error for negative bucket, got nil")
+	}
+}
+
+// shuffledBytes returns the bytes of b in a fixed order that breaks up its
+// substrings: every 7th byte, wrapping around.
+func shuffledBytes(b []byte) []byte {
+	shuffled := make([]byte, 0, len(b))
+	for start := range 7 {
+		for i := start; i < len(b); i += 7 {
+			shuffled = append(shuffled, b[i])
+		}
+	}
+	return shuffled
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// _schedulerSlowdown is how much slower than the fastest one seen a query
+// must be for oracleScheduler to consider the oracle overloaded.
+const _schedulerSlowdown = 2
+
+// oracleScheduler limits how many queries are in flight at the same time
+// across all the oracles it wraps, e.g., all the attacks running against the
+// same remote server. When oracles are remote, their latency dominates the
+// attacks' running time, and queries in flight hide it, but too many of
+// them overload the server, which answers slower or not at all.
+// The limit adapts to the oracles' behavior, like TCP's congestion window:
+// it grows by one query per round trip while queries succeed quickly, and it
+// halves when a query fails or takes more than _schedulerSlowdown times the
+// fastest one seen. It never exceeds the maximum it was created with.
+// It's safe for concurrent use.
+type oracleScheduler struct {
+	maxInFlight int
+
+	// now returns the current time. Tests replace it with a fake clock.
+	now func() time.Time
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    float64
+	inFlight int
+
+	// minLatency is the latency of the fastest query seen so far, the
+	// baseline to tell whether the oracle is overloaded.
+	minLatency time.Duration
+
+	calls, errors int64
+}
+
+// newOracleScheduler returns an oracleScheduler allowing at most maxInFlight
+// queries at the same time. It starts from a single query in flight.
+func newOracleScheduler(maxInFlight int) *oracleScheduler {
+	s := &oracleScheduler{
+		maxInFlight: max(maxInFlight, 1),
+		now:         time.Now,
+		limit:       1,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// schedulerStats is a snapshot of the state of an oracleScheduler.
+type schedulerStats struct {
+	// limit is the current number of queries allowed in flight.
+	limit    int
+	inFlight int
+
+	calls, errors int64
+	minLatency    time.Duration
+}
+
+// stats returns a snapshot of the state of the scheduler.
+func (s *oracleScheduler) stats() schedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return schedulerStats{
+		limit:      int(s.limit),
+		inFlight:   s.inFlight,
+		calls:      s.calls,
+		errors:     s.errors,
+		minLatency: s.minLatency,
+	}
+}
+
+// wrap returns an aesOracle that queries the given oracle when the scheduler
+// allows it, and blocks until then.
+func (s *oracleScheduler) wrap(oracle aesOracle) aesOracle {
+	return func(plainText []byte) ([]byte, error) {
+		s.acquire()
+
+		start := s.now()
+		cipherText, err := oracle(plainText)
+		s.release(s.now().Sub(start), err)
+
+		return cipherText, err
+	}
+}
+
+// acquire blocks until a query can be put in flight.
+func (s *oracleScheduler) acquire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.inFlight >= int(s.limit) {
+		s.cond.Wait()
+	}
+	s.inFlight++
+}
+
+// release records the outcome of a query that took the given time, and
+// adapts the limit accordingly.
+func (s *oracleScheduler) release(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	s.calls++
+
+	switch {
+	case err != nil:
+		s.errors++
+		s.limit = max(1, s.limit/2)
+
+	case s.minLatency > 0 && latency > _schedulerSlowdown*s.minLatency:
+		s.limit = max(1, s.limit/2)
+
+	default:
+		if s.minLatency == 0 || latency < s.minLatency {
+			s.minLatency = latency
+		}
+		// +1/limit per query is +1 per round trip of limit queries.
+		s.limit = min(float64(s.maxInFlight), s.limit+1/s.limit)
+	}
+
+	s.cond.Broadcast()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestOracleSchedulerAdapts(t *testing.T) {
+	s := newOracleScheduler(4)
+
+	// fast successes open the window up to the maximum, one query per round
+	// trip.
+	for range 20 {
+		s.acquire()
+		s.release(time.Millisecond, nil)
+	}
+	if got := s.stats().limit; got != 4 {
+		t.Fatalf("want limit 4, got %d", got)
+	}
+
+	s.acquire()
+	s.release(time.Millisecond, errOracleUnavailable)
+	if got := s.stats().limit; got != 2 {
+		t.Errorf("after an error, want limit 2, got %d", got)
+	}
+
+	s.acquire()
+	s.release(10*time.Millisecond, nil)
+	if got := s.stats().limit; got != 1 {
+		t.Errorf("after a slow query, want limit 1, got %d", got)
+	}
+
+	// the limit never goes below one query.
+	s.acquire()
+	s.release(time.Millisecond, errOracleUnavailable)
+	stats := s.stats()
+	if stats.limit != 1 {
+		t.Errorf("want limit 1, got %d", stats.limit)
+	}
+	if stats.calls != 23 || stats.errors != 2 || stats.minLatency != time.Millisecond {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestOracleSchedulerLimitsInFlight(t *testing.T) {
+	const maxInFlight = 3
+
+	var (
+		inFlight, maxSeen atomic.Int64
+		oracle            = func(plainText []byte) ([]byte, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				seen := maxSeen.Load()
+				if n <= seen || maxSeen.CompareAndSwap(seen, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return plainText, nil
+		}
+		s = newOracleScheduler(maxInFlight)
+	)
+	// the slow down rule would make the test depend on the machine's load.
+	s.now = func() time.Time { return time.Time{} }
+
+	// two attacks sharing the scheduler.
+	var (
+		oracleA = s.wrap(oracle)
+		oracleB = s.wrap(oracle)
+		g       errgroup.Group
+	)
+	for i := range 50 {
+		o := oracleA
+		if i%2 == 1 {
+			o = oracleB
+		}
+		g.Go(func() error {
+			_, err := o([]byte("YELLOW SUBMARINE"))
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := maxSeen.Load(); got > maxInFlight {
+		t.Errorf("%d queries in flight, the maximum is %d", got, maxInFlight)
+	}
+	if got := s.stats().limit; got != maxInFlight {
+		t.Errorf("want limit %d, got %d", maxInFlight, got)
+	}
+}
+
+func TestOracleSchedulerPassesErrors(t *testing.T) {
+	var (
+		s       = newOracleScheduler(2)
+		wantErr = errors.New("boom")
+		oracle  = s.wrap(func([]byte) ([]byte, error) { return nil, wantErr })
+	)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := oracle(nil); !errors.Is(err, wantErr) {
+				t.Errorf("want %v, got %v", wantErr, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := s.stats(); stats.errors != 10 || stats.inFlight != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
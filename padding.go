@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// paddingScheme defines a way of padding data to a multiple of the block size,
+// and of removing that padding.
+type paddingScheme interface {
+	// pad returns data padded to a multiple of blockSize. It always adds at
+	// least one byte, so that the padding can be removed unambiguously.
+	pad(data []byte, blockSize int) []byte
+
+	// unpad validates and removes the padding added by pad.
+	unpad(data []byte, blockSize int) ([]byte, error)
+}
+
+// pkcs7Padding is the PKCS#7 padding scheme: n bytes of padding, each of value
+// n. It's the default scheme of the AES encryption functions.
+type pkcs7Padding struct{}
+
+func (pkcs7Padding) pad(data []byte, blockSize int) []byte {
+	return padPkcs7(data, blockSize)
+}
+
+func (pkcs7Padding) unpad(data []byte, blockSize int) ([]byte, error) {
+	return unpadPkcs7Exact(data, blockSize)
+}
+
+// iso7816Padding is the padding scheme of ISO/IEC 7816-4: a single 0x80 byte
+// followed by as many zero bytes as needed to fill the block.
+type iso7816Padding struct{}
+
+func (iso7816Padding) pad(data []byte, blockSize int) []byte {
+	padLen := blockSize - len(data)%blockSize
+
+	padded := make([]byte, len(data)+padLen)
+	copy(padded, data)
+	padded[len(data)] = 0x80
+
+	return padded
+}
+
+func (iso7816Padding) unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		const formatStr = "%w: data length (%d) is not a multiple of the block size (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(data), blockSize)
+	}
+
+	// the last non-zero byte; searching runes instead would decode it, and
+	// the byte before it, as UTF-8.
+	end := len(bytes.TrimRight(data, "\x00")) - 1
+	if end == -1 || data[end] != 0x80 || len(data)-end > blockSize {
+		return nil, errors.New("invalid ISO/IEC 7816-4 padding")
+	}
+
+	return data[:end], nil
+}
+
+// withPadding makes the AES encryption functions pad the plain text with the
+// given scheme instead of PKCS#7.
+func withPadding(scheme paddingScheme) cipherOption {
+	return func(o *cipherOptions) {
+		o.padding = scheme
+	}
+}
+
+// withoutPadding makes the AES encryption functions encrypt the plain text as
+// it is, which must then be a multiple of the block size. Use it when the
+// plain text is already padded, or when you need the raw block cipher mode
+// (e.g., to build a MAC out of it).
+func withoutPadding() cipherOption {
+	return func(o *cipherOptions) {
+		o.padding = nil
+	}
+}
+
+// padPlainText pads the plain text according to the options, or checks that
+// it's block aligned if padding is disabled.
+func (o cipherOptions) padPlainText(plainText []byte, blockSize int) ([]byte, error) {
+	if o.padding != nil {
+		return o.padding.pad(plainText, blockSize), nil
+	}
+
+	if len(plainText)%blockSize != 0 {
+		const formatStr = "%w: plain text's length (%d) is not a multiple of the block size (%d)"
+		return nil, fmt.Errorf(formatStr, errNotBlockAligned, len(plainText), blockSize)
+	}
+	return plainText, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPaddingSchemes(t *testing.T) {
+	schemes := []struct {
+		name   string
+		scheme paddingScheme
+	}{
+		{"PKCS#7", pkcs7Padding{}},
+		{"ISO/IEC 7816-4", iso7816Padding{}},
+	}
+
+	for _, s := range schemes {
+		t.Run(s.name, func(t *testing.T) {
+			for n := range 40 {
+				data := bytes.Repeat([]byte{0x80}, n)
+
+				padded := s.scheme.pad(data, 16)
+				if len(padded)%16 != 0 || len(padded) <= n {
+					t.Fatalf("padding %d bytes produced %d bytes", n, len(padded))
+				}
+
+				unpadded, err := s.scheme.unpad(padded, 16)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if !bytes.Equal(unpadded, data) {
+					t.Errorf("\nwant:\t%x\ngot:\t%x\n", data, unpadded)
+				}
+			}
+		})
+	}
+
+	if _, err := (iso7816Padding{}).unpad(make([]byte, 16), 16); err == nil {
+		t.Error("ISO/IEC 7816-4 accepted a block of zeros")
+	}
+}
+
+func TestISO7816PaddingBinary(t *testing.T) {
+	// 0xC2 0x80 is the UTF-8 encoding of U+0080.
+	for _, data := range [][]byte{{'a', 0xc2}, {0xc2}, bytes.Repeat([]byte{0xc2}, 16)} {
+		padded := iso7816Padding{}.pad(data, 16)
+
+		unpadded, err := iso7816Padding{}.unpad(padded, 16)
+		if err != nil {
+			t.Fatalf("%x: unexpected error: %s", data, err)
+		}
+		if !bytes.Equal(unpadded, data) {
+			t.Errorf("\nwant:\t%x\ngot:\t%x\n", data, unpadded)
+		}
+	}
+}
+
+func TestEncryptWithoutPadding(t *testing.T) {
+	var (
+		key = []byte("YELLOW SUBMARINE")
+		iv  = make([]byte, len(key))
+	)
+
+	encrypted, err := encryptAesEcb([]byte("YELLOW SUBMARINE"), key, withoutPadding())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(encrypted) != 16 {
+		t.Errorf("want 16 bytes of cipher text, got %d", len(encrypted))
+	}
+
+	for _, opts := range [][]cipherOption{{withoutPadding()}, {withStdlib(), withoutPadding()}} {
+		_, err := encryptAesCbc([]byte("YELLOW"), key, iv, opts...)
+		if !errors.Is(err, errNotBlockAligned) {
+			t.Errorf("want %v, got %v", errNotBlockAligned, err)
+		}
+
+		encrypted, err := encryptAesCbc(nil, key, iv, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(encrypted) != 0 {
+			t.Errorf("want no cipher text for an empty plain text, got %d bytes", len(encrypted))
+		}
+	}
+}
+
+func TestEncryptWithPadding(t *testing.T) {
+	var (
+		plainText = []byte("ICE ICE BABY")
+		key       = []byte("YELLOW SUBMARINE")
+		iv        = make([]byte, len(key))
+	)
+
+	cipherText, err := encryptAesCbc(plainText, key, iv, withPadding(iso7816Padding{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	decrypted, err := decryptAesCbc(cipherText, key, iv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "ICE ICE BABY\x80\x00\x00\x00"
+	if string(decrypted) != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, decrypted)
+	}
+}
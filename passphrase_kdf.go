@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// keyDerivation is a naive way to turn a passphrase into an AES-128 key, the
+// kind applications come up with when nobody told them about key derivation
+// functions: stretching the passphrase to 16 bytes, or hashing it once.
+// Either way, the key has as little entropy as the passphrase, and trying a
+// passphrase costs a hash at most (see crackPassphraseKey).
+type keyDerivation int
+
+const (
+	deriveSpaces keyDerivation = iota // "yellow          "
+	deriveZeros                       // "yellow\x00\x00..."
+	derivePkcs7                       // "yellow\x0a\x0a..."
+	deriveRepeat                      // "yellowyellowyell"
+	deriveMD5                         // MD5("yellow")
+	deriveSHA1                        // SHA-1("yellow"), truncated to 16 bytes
+	deriveSHA256                      // SHA-256("yellow"), truncated to 16 bytes
+)
+
+// _nKeyDerivations is the number of keyDerivation values.
+const _nKeyDerivations = 7
+
+// _keyDerivationNames are the names of the keyDerivation values, as accepted
+// by parseKeyDerivation.
+var _keyDerivationNames = [_nKeyDerivations]string{
+	deriveSpaces: "spaces",
+	deriveZeros:  "zeros",
+	derivePkcs7:  "pkcs7",
+	deriveRepeat: "repeat",
+	deriveMD5:    "md5",
+	deriveSHA1:   "sha1",
+	deriveSHA256: "sha256",
+}
+
+func (d keyDerivation) String() string {
+	if d < 0 || d >= _nKeyDerivations {
+		return fmt.Sprintf("keyDerivation(%d)", int(d))
+	}
+	return _keyDerivationNames[d]
+}
+
+// parseKeyDerivation returns the keyDerivation with the given name.
+func parseKeyDerivation(name string) (keyDerivation, error) {
+	for d, n := range _keyDerivationNames {
+		if n == name {
+			return keyDerivation(d), nil
+		}
+	}
+	const formatStr = "unsupported key derivation %q (must be one of: %s)"
+	return 0, fmt.Errorf(formatStr, name, strings.Join(_keyDerivationNames[:], ", "))
+}
+
+// deriveKey returns the AES-128 key derived from passphrase with derivation,
+// or nil if the derivation doesn't apply to it: a passphrase can be stretched
+// to 16 bytes only if it's not empty and not longer than that.
+func deriveKey(passphrase string, derivation keyDerivation) []byte {
+	switch derivation {
+	case deriveMD5:
+		sum := md5.Sum([]byte(passphrase))
+		return sum[:]
+	case deriveSHA1:
+		sum := sha1.Sum([]byte(passphrase))
+		return sum[:aes.BlockSize]
+	case deriveSHA256:
+		sum := sha256.Sum256([]byte(passphrase))
+		return sum[:aes.BlockSize]
+	}
+
+	if passphrase == "" || len(passphrase) > aes.BlockSize {
+		return nil
+	}
+
+	key := make([]byte, aes.BlockSize)
+	n := copy(key, passphrase)
+	for i := n; i < len(key); i++ {
+		switch derivation {
+		case deriveSpaces:
+			key[i] = ' '
+		case deriveZeros:
+			key[i] = 0
+		case derivePkcs7:
+			key[i] = byte(aes.BlockSize - n)
+		case deriveRepeat:
+			key[i] = key[i-n]
+		}
+	}
+
+	return key
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestDeriveKey(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		derivation keyDerivation
+		want       string
+	}{
+		{passphrase: "yellow", derivation: deriveSpaces, want: "yellow          "},
+		{passphrase: "yellow", derivation: deriveZeros, want: "yellow\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"},
+		{passphrase: "yellow", derivation: derivePkcs7, want: "yellow\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a\x0a"},
+		{passphrase: "yellow", derivation: deriveRepeat, want: "yellowyellowyell"},
+		{passphrase: "YELLOW SUBMARINE", derivation: derivePkcs7, want: "YELLOW SUBMARINE"},
+		// echo -n yellow | md5sum, sha1sum and sha256sum.
+		{passphrase: "yellow", derivation: deriveMD5, want: string(testutil.MustDecodeHex(t, "d487dd0b55dfcacdd920ccbdaeafa351"))},
+		{passphrase: "yellow", derivation: deriveSHA1, want: string(testutil.MustDecodeHex(t, "96de5543d183d7de52ac5fa21c46fc81"))},
+		{passphrase: "yellow", derivation: deriveSHA256, want: string(testutil.MustDecodeHex(t, "c685a2c9bab235ccdd2ab0ea92281a52"))},
+	}
+
+	for _, test := range tests {
+		got := deriveKey(test.passphrase, test.derivation)
+		if string(got) != test.want {
+			t.Errorf("%s(%q)\nwant:\t%q\ngot:\t%q\n", test.derivation, test.passphrase, test.want, got)
+		}
+	}
+}
+
+func TestDeriveKeyNotApplicable(t *testing.T) {
+	for _, passphrase := range []string{"", "yellow submarines"} {
+		for _, derivation := range []keyDerivation{deriveSpaces, deriveZeros, derivePkcs7, deriveRepeat} {
+			if key := deriveKey(passphrase, derivation); key != nil {
+				t.Errorf("%s(%q): want nil, got %q", derivation, passphrase, key)
+			}
+		}
+	}
+
+	if key := deriveKey("yellow submarines", deriveSHA1); len(key) != 16 {
+		t.Errorf("want a 16 bytes key, got %q", key)
+	}
+}
+
+func TestParseKeyDerivation(t *testing.T) {
+	for d := range keyDerivation(_nKeyDerivations) {
+		got, err := parseKeyDerivation(d.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != d {
+			t.Errorf("want %s, got %s", d, got)
+		}
+	}
+
+	if _, err := parseKeyDerivation("pbkdf2"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+const (
+	// _poly1305KeySize is the size of a Poly1305 one-time key in bytes.
+	_poly1305KeySize = 32
+
+	// _poly1305TagSize is the size of a Poly1305 tag in bytes.
+	_poly1305TagSize = 16
+)
+
+var (
+	// _poly1305Prime is 2^130 - 5, the prime Poly1305 works modulo of.
+	_poly1305Prime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+
+	// _poly1305Clamp clears the bits of r that Poly1305 requires to be 0.
+	_poly1305Clamp, _ = new(big.Int).SetString("0ffffffc0ffffffc0ffffffc0fffffff", 16)
+
+	// _poly1305TagMask keeps the low 128 bits of the accumulator.
+	_poly1305TagMask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+)
+
+// poly1305MAC returns the Poly1305 tag of msg under the given one-time key.
+// The key is made of two 16 byte halves, r and s. The message is split into
+// 16 byte blocks, each read as a little endian number with an extra 1 bit
+// appended, and evaluated as a polynomial in r modulo 2^130 - 5:
+//
+//	acc = ((acc + block) * r) mod (2^130 - 5)
+//
+// The tag is the low 128 bits of acc + s.
+// We use math/big rather than 130-bit limbs to keep the arithmetic readable;
+// unlike a real implementation, this is not constant time.
+// A key must never be used for more than one message: two tags under the same
+// key reveal it.
+func poly1305MAC(msg, key []byte) ([]byte, error) {
+	if len(key) != _poly1305KeySize {
+		return nil, fmt.Errorf("invalid Poly1305 key size %d; want %d", len(key), _poly1305KeySize)
+	}
+
+	var (
+		r   = leBytesToInt(key[:16])
+		s   = leBytesToInt(key[16:])
+		acc = new(big.Int)
+	)
+	r.And(r, _poly1305Clamp)
+
+	for block := range chunks(msg, 16) {
+		n := leBytesToInt(append(slices.Clone(block), 0x01))
+		acc.Add(acc, n)
+		acc.Mul(acc, r)
+		acc.Mod(acc, _poly1305Prime)
+	}
+
+	acc.Add(acc, s)
+	acc.And(acc, _poly1305TagMask)
+
+	tag := make([]byte, _poly1305TagSize)
+	acc.FillBytes(tag)
+	slices.Reverse(tag)
+
+	return tag, nil
+}
+
+// leBytesToInt returns the number encoded by b in little endian.
+func leBytesToInt(b []byte) *big.Int {
+	be := slices.Clone(b)
+	slices.Reverse(be)
+	return new(big.Int).SetBytes(be)
+}
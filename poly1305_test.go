@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestPoly1305MAC(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		msg  []byte
+		tag  string
+	}{
+		{
+			// RFC 8439, section 2.5.2.
+			name: "RFC 8439",
+			key:  "85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b",
+			msg:  []byte("Cryptographic Forum Research Group"),
+			tag:  "a8061dc1305136c6c22b8baf0c0127a9",
+		},
+		{
+			// RFC 8439, appendix A.3, test vector 1: all zeros.
+			name: "zero key",
+			key:  "0000000000000000000000000000000000000000000000000000000000000000",
+			msg:  make([]byte, 64),
+			tag:  "00000000000000000000000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := poly1305MAC(tt.msg, testutil.MustDecodeHex(t, tt.key))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if want := testutil.MustDecodeHex(t, tt.tag); !bytes.Equal(tag, want) {
+				t.Errorf("\nwant:\t%x\ngot:\t%x\n", want, tag)
+			}
+		})
+	}
+
+	if _, err := poly1305MAC(nil, make([]byte, 16)); err == nil {
+		t.Error("a 16 byte key was accepted")
+	}
+}
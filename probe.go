@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// _maxProbedBlockSize is the largest block size probeOracle looks for.
+const _maxProbedBlockSize = 64
+
+// errIrregularOracle is returned by probeOracle when the oracle doesn't behave
+// like a deterministic block cipher encrypting its input and fixed affixes,
+// e.g., because it compresses its input, or adds random bytes to it.
+var errIrregularOracle = errors.New("oracle doesn't behave like a block cipher")
+
+// modeFamily classifies how a cipher turns plain texts into cipher texts, as
+// far as the length of its output tells.
+type modeFamily int
+
+const (
+	// modeFamilyBlock ciphers encrypt whole, padded blocks (e.g., ECB or
+	// CBC): the cipher text grows a block at a time.
+	modeFamilyBlock modeFamily = iota
+
+	// modeFamilyStream ciphers XOR the plain text with a keystream (e.g.,
+	// CTR, OFB or ChaCha20): the cipher text grows byte for byte with the
+	// plain text.
+	modeFamilyStream
+)
+
+// String implements fmt.Stringer.
+func (f modeFamily) String() string {
+	switch f {
+	case modeFamilyBlock:
+		return "block"
+	case modeFamilyStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("modeFamily(%d)", int(f))
+	}
+}
+
+// oracleProfile describes what an encryption oracle does with its input, as
+// found out by probeOracle.
+type oracleProfile struct {
+	family modeFamily
+
+	// blockSize is the block size of the cipher, in bytes. It's 1 for stream
+	// ciphers.
+	blockSize int
+
+	// ecb reports whether the oracle encrypts in ECB mode.
+	ecb bool
+
+	// prefixLen and suffixLen are the number of bytes the oracle prepends
+	// and appends to our input before encrypting it. Only their sum is known
+	// if the oracle doesn't use ECB, in which case they are both -1.
+	// For stream ciphers, the sum includes whatever else the oracle adds to
+	// the cipher text, like a nonce.
+	prefixLen int
+	suffixLen int
+
+	// affixLen is prefixLen + suffixLen.
+	affixLen int
+}
+
+// probeOracle finds out the block size and mode of an oracle that encrypts
+// [prefix || input || suffix] with a block cipher and PKCS#7 padding, as well
+// as the lengths of the prefix and suffix, which must not change between
+// calls.
+// It also recognizes oracles using a stream cipher (or a block cipher in a
+// streaming mode, like CTR), whose cipher texts grow byte for byte with the
+// input, and reports them as modeFamilyStream.
+// It makes a bounded number of queries, and it checks that the oracle does
+// behave as described, failing with errIrregularOracle otherwise, so that it
+// can be pointed at unknown targets.
+// It honors withMaxOracleCalls and withMaxSecretLen, which limits the
+// combined length of prefix and suffix.
+func probeOracle(oracle aesOracle, opts ...attackOption) (oracleProfile, error) {
+	var (
+		p       oracleProfile
+		options = newAttackOptions(opts)
+	)
+	oracle = options.limitOracle(oracle)
+
+	blockSize, affixLen, err := probeBlockSize(oracle)
+	if err != nil {
+		return p, err
+	}
+	if err := options.checkSecretLen(affixLen); err != nil {
+		return p, err
+	}
+	p.blockSize, p.affixLen = blockSize, affixLen
+
+	if blockSize == 1 {
+		p.family = modeFamilyStream
+		p.prefixLen, p.suffixLen = -1, -1
+		return p, nil
+	}
+
+	// three blocks of equal bytes contain at least two aligned ones, whatever
+	// the prefix's length.
+	cipherText, err := oracle(make([]byte, 3*blockSize))
+	if err != nil {
+		return p, fmt.Errorf("detecting mode: %w", err)
+	}
+	p.ecb = firstRepeatedBlock(cipherText, blockSize) >= 0
+
+	if !p.ecb {
+		p.prefixLen, p.suffixLen = -1, -1
+		return p, nil
+	}
+
+	prefixLen, err := probePrefixLen(oracle, blockSize)
+	if err != nil {
+		return p, err
+	}
+	p.prefixLen = prefixLen
+	p.suffixLen = affixLen - prefixLen
+
+	return p, nil
+}
+
+// probeBlockSize feeds the oracle longer and longer inputs until the length of
+// the cipher text grows. Since PKCS#7 always pads, the cipher text grows by a
+// whole block exactly when [prefix || input || suffix] fills its last block.
+// It returns the block size and the combined length of prefix and suffix.
+// A stream cipher grows with the first byte of input: its block size is 1,
+// and there's no padding.
+// It fails with errIrregularOracle if the length of the cipher text changes
+// between equal queries, or if it doesn't grow in whole blocks, linearly with
+// the input.
+func probeBlockSize(oracle aesOracle) (int, int, error) {
+	initialLen, err := cipherTextLen(oracle, 0)
+	if err != nil {
+		return 0, 0, fmt.Errorf("detecting block size: %w", err)
+	}
+
+	// an oracle adding a random number of bytes (e.g., a random prefix) would
+	// make us find a wrong block size.
+	againLen, err := cipherTextLen(oracle, 0)
+	if err != nil {
+		return 0, 0, fmt.Errorf("detecting block size: %w", err)
+	}
+	if againLen != initialLen {
+		const formatStr = "%w: equal inputs encrypted to %d and %d bytes"
+		return 0, 0, fmt.Errorf(formatStr, errIrregularOracle, initialLen, againLen)
+	}
+
+	for inputLen := 1; inputLen <= _maxProbedBlockSize; inputLen++ {
+		n, err := cipherTextLen(oracle, inputLen)
+		if err != nil {
+			return 0, 0, fmt.Errorf("detecting block size: %w", err)
+		}
+
+		grown := n - initialLen
+		if grown == 0 {
+			continue
+		}
+		if grown < 0 || initialLen%grown != 0 {
+			const formatStr = "%w: cipher text's length went from %d to %d bytes"
+			return 0, 0, fmt.Errorf(formatStr, errIrregularOracle, initialLen, n)
+		}
+
+		// a few more blocks of input must grow the cipher text by as many
+		// blocks. An oracle compressing its input would fail this: the zeros
+		// we send compress well.
+		const extraBlocks = 4
+		extendedLen, err := cipherTextLen(oracle, inputLen+extraBlocks*grown)
+		if err != nil {
+			return 0, 0, fmt.Errorf("detecting block size: %w", err)
+		}
+		if want := n + extraBlocks*grown; extendedLen != want {
+			const formatStr = "%w: %d more bytes of input grew the cipher text by %d bytes, not %d"
+			return 0, 0, fmt.Errorf(formatStr, errIrregularOracle, extraBlocks*grown, extendedLen-n, extraBlocks*grown)
+		}
+
+		if grown == 1 {
+			// no block cipher has 1-byte blocks: this is a stream cipher.
+			return 1, initialLen, nil
+		}
+
+		// the plain text [prefix || input || suffix] is block aligned, so
+		// it's followed by a full block of padding.
+		return grown, n - grown - inputLen, nil
+	}
+
+	const formatStr = "%w: cipher text's length didn't change with inputs up to %d bytes"
+	return 0, 0, fmt.Errorf(formatStr, errIrregularOracle, _maxProbedBlockSize)
+}
+
+// cipherTextLen returns the length of the cipher text of inputLen zero bytes.
+func cipherTextLen(oracle aesOracle, inputLen int) (int, error) {
+	cipherText, err := oracle(make([]byte, inputLen))
+	return len(cipherText), err
+}
+
+// probePrefixLen finds the length of the prefix an ECB oracle prepends to our
+// input: it prepends more and more filler bytes to two blocks of equal bytes,
+// until they are aligned to a block boundary and encrypt to two equal
+// consecutive blocks.
+// The prefix or the suffix could contain equal consecutive blocks too (e.g., a
+// suffix starting with a run of the bytes we send), so we send each input
+// twice, with two different sentinel bytes, and only trust a pair of equal
+// blocks that is at the same position in both cipher texts and that changes
+// with the sentinel: blocks of the prefix or suffix can't.
+func probePrefixLen(oracle aesOracle, blockSize int) (int, error) {
+	const (
+		filler    = 0x01
+		sentinelA = 'A'
+		sentinelB = 'B'
+	)
+
+	for fillerLen := range blockSize {
+		cipherTextA, err := oracle(sentinelInput(fillerLen, filler, sentinelA, blockSize))
+		if err != nil {
+			return 0, fmt.Errorf("detecting prefix length: %w", err)
+		}
+		cipherTextB, err := oracle(sentinelInput(fillerLen, filler, sentinelB, blockSize))
+		if err != nil {
+			return 0, fmt.Errorf("detecting prefix length: %w", err)
+		}
+
+		for _, idx := range repeatedBlockIndexes(cipherTextA, blockSize) {
+			if idx+2 > len(cipherTextB)/blockSize {
+				break
+			}
+
+			var (
+				blockA = blockAt(cipherTextA, idx, blockSize)
+				blockB = blockAt(cipherTextB, idx, blockSize)
+				nextB  = blockAt(cipherTextB, idx+1, blockSize)
+			)
+			if bytes.Equal(blockB, nextB) && !bytes.Equal(blockA, blockB) {
+				start, _ := blockBounds(idx, blockSize)
+				return start - fillerLen, nil
+			}
+		}
+	}
+
+	// a fixed prefix is aligned by fewer than blockSize filler bytes: the
+	// prefix must be changing between queries.
+	return 0, fmt.Errorf("%w: couldn't align the input to a block boundary", errIrregularOracle)
+}
+
+// sentinelInput returns fillerLen filler bytes followed by two blocks of
+// sentinel bytes.
+func sentinelInput(fillerLen int, filler, sentinel byte, blockSize int) []byte {
+	return append(
+		bytes.Repeat([]byte{filler}, fillerLen),
+		bytes.Repeat([]byte{sentinel}, 2*blockSize)...,
+	)
+}
+
+// repeatedBlockIndexes returns the indexes of the blocks of data that are
+// equal to the block following them.
+func repeatedBlockIndexes(data []byte, blockSize int) []int {
+	var indexes []int
+	for i := 1; i < len(data)/blockSize; i++ {
+		if bytes.Equal(blockAt(data, i-1, blockSize), blockAt(data, i, blockSize)) {
+			indexes = append(indexes, i-1)
+		}
+	}
+	return indexes
+}
+
+// firstRepeatedBlock returns the index of the first block of data that is
+// equal to the block following it, or -1 if there's none.
+func firstRepeatedBlock(data []byte, blockSize int) int {
+	if indexes := repeatedBlockIndexes(data, blockSize); len(indexes) > 0 {
+		return indexes[0]
+	}
+	return -1
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestProbeOracle(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		suffix string
+	}{
+		{name: "no prefix", suffix: "some secret suffix"},
+		{name: "multi-block prefix", prefix: "a prefix that spans more than two blocks", suffix: "secret"},
+		{name: "empty suffix", prefix: "prefix"},
+		{name: "aligned prefix", prefix: "a prefix of exactly two blocks..", suffix: "secret"},
+		{name: "no prefix and no suffix"},
+		{name: "repeated blocks in prefix", prefix: strings.Repeat("p", 37), suffix: "secret"},
+		{name: "aligned repeated prefix", prefix: strings.Repeat("p", 32), suffix: "secret"},
+		{name: "suffix starting with sentinels", prefix: "prefix", suffix: strings.Repeat("A", 40)},
+		{name: "suffix of fillers", prefix: "pre", suffix: strings.Repeat("\x01", 16) + strings.Repeat("A", 32)},
+		{name: "repeated prefix and suffix", prefix: strings.Repeat("B", 20), suffix: strings.Repeat("A", 33)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oracle := fixedAffixOracle(t, tt.prefix, tt.suffix, false)
+
+			got, err := probeOracle(oracle)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			want := oracleProfile{
+				family:    modeFamilyBlock,
+				blockSize: aes.BlockSize,
+				ecb:       true,
+				prefixLen: len(tt.prefix),
+				suffixLen: len(tt.suffix),
+				affixLen:  len(tt.prefix) + len(tt.suffix),
+			}
+			if got != want {
+				t.Errorf("\nwant:\t%+v\ngot:\t%+v\n", want, got)
+			}
+		})
+	}
+}
+
+func TestProbeOracleCBC(t *testing.T) {
+	oracle := fixedAffixOracle(t, "prefix", "suffix", true)
+
+	got, err := probeOracle(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := oracleProfile{
+		family:    modeFamilyBlock,
+		blockSize: aes.BlockSize,
+		prefixLen: -1,
+		suffixLen: -1,
+		affixLen:  len("prefix") + len("suffix"),
+	}
+	if got != want {
+		t.Errorf("\nwant:\t%+v\ngot:\t%+v\n", want, got)
+	}
+}
+
+func TestProbeOracleStream(t *testing.T) {
+	var (
+		key   = testutil.RandomBytes(t, _chachaKeySize)
+		nonce = make([]byte, _chachaNonceSize)
+	)
+
+	tests := map[string]struct {
+		oracle   aesOracle
+		affixLen int
+	}{
+		"stream cipher with suffix": {
+			oracle: func(plainText []byte) ([]byte, error) {
+				return chacha20XOR(concatInto(nil, plainText, []byte("suffix")), key, nonce, 0)
+			},
+			affixLen: len("suffix"),
+		},
+		// a random nonce prepended to every cipher text, like CTR would.
+		"nonce and affixes": {
+			oracle: func(plainText []byte) ([]byte, error) {
+				nonce := testutil.RandomBytes(t, _chachaNonceSize)
+				ct, err := chacha20XOR(concatInto(nil, []byte("pre"), plainText, []byte("suf")), key, nonce, 1)
+				return concatInto(nil, nonce, ct), err
+			},
+			affixLen: _chachaNonceSize + len("pre") + len("suf"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := probeOracle(test.oracle)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			want := oracleProfile{
+				family:    modeFamilyStream,
+				blockSize: 1,
+				prefixLen: -1,
+				suffixLen: -1,
+				affixLen:  test.affixLen,
+			}
+			if got != want {
+				t.Errorf("\nwant:\t%+v\ngot:\t%+v\n", want, got)
+			}
+		})
+	}
+}
+
+func TestProbeOracleAdversarial(t *testing.T) {
+	var (
+		key       = testutil.RandomKey(t)
+		ecb       = fixedAffixOracle(t, "", "secret", false)
+		alternate atomic.Int64
+	)
+
+	tests := map[string]aesOracle{
+		// compresses its input before encrypting it, like the oracle of
+		// challenge 51.
+		"compressing": func(plainText []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			w, _ := flate.NewWriter(&buf, flate.BestCompression)
+			w.Write(plainText)
+			w.Write([]byte("secret"))
+			w.Close()
+			return encryptAesEcb(buf.Bytes(), key)
+		},
+		// adds a block to every other query.
+		"variable length": func(plainText []byte) ([]byte, error) {
+			ct, err := ecb(plainText)
+			if alternate.Add(1)%2 == 0 {
+				ct = append(ct, make([]byte, aes.BlockSize)...)
+			}
+			return ct, err
+		},
+		"constant length": func([]byte) ([]byte, error) {
+			return make([]byte, 64), nil
+		},
+		"shrinking": func(plainText []byte) ([]byte, error) {
+			return make([]byte, max(0, 64-len(plainText))), nil
+		},
+		"not block quantized": func(plainText []byte) ([]byte, error) {
+			return make([]byte, 20+16*(len(plainText)/16)), nil
+		},
+	}
+
+	for name, oracle := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				calls    int
+				counting = func(plainText []byte) ([]byte, error) {
+					calls++
+					return oracle(plainText)
+				}
+			)
+
+			_, err := probeOracle(counting)
+			if !errors.Is(err, errIrregularOracle) {
+				t.Errorf("want %v, got %v", errIrregularOracle, err)
+			}
+			if calls > _maxProbedBlockSize+2 {
+				t.Errorf("%d queries, want at most %d", calls, _maxProbedBlockSize+2)
+			}
+		})
+	}
+}
+
+func TestProbeOracleLimits(t *testing.T) {
+	oracle := fixedAffixOracle(t, "prefix", strings.Repeat("s", 100), false)
+
+	_, err := probeOracle(oracle, withMaxSecretLen(64))
+	if !errors.Is(err, errSecretTooLong) {
+		t.Errorf("want %v, got %v", errSecretTooLong, err)
+	}
+
+	_, err = probeOracle(oracle, withMaxOracleCalls(5))
+	if !errors.Is(err, errMaxOracleCalls) {
+		t.Errorf("want %v, got %v", errMaxOracleCalls, err)
+	}
+}
+
+func TestDecryptOracleSecretSanityChecks(t *testing.T) {
+	oracle := fixedAffixOracle(t, "", strings.Repeat("s", 100), false)
+
+	_, err := decryptOracleSecret(oracle, withMaxSecretLen(64))
+	if !errors.Is(err, errSecretTooLong) {
+		t.Errorf("want %v, got %v", errSecretTooLong, err)
+	}
+	_, err = decryptOracleSecretPipelined(oracle, withMaxSecretLen(64))
+	if !errors.Is(err, errSecretTooLong) {
+		t.Errorf("want %v, got %v", errSecretTooLong, err)
+	}
+
+	// a stream cipher's cipher texts are as long as their plain texts.
+	stream := func(plainText []byte) ([]byte, error) {
+		return concatInto(nil, plainText, []byte("a secret of 21 bytes!")), nil
+	}
+	_, err = decryptOracleSecret(stream)
+	if !errors.Is(err, errNotBlockAligned) {
+		t.Errorf("want %v, got %v", errNotBlockAligned, err)
+	}
+}
+
+// fixedAffixOracle returns an aesOracle that encrypts
+// [prefix || plain text || suffix] with AES ECB, or CBC if cbc is true.
+func fixedAffixOracle(t *testing.T, prefix, suffix string, cbc bool) aesOracle {
+	t.Helper()
+
+	var (
+		key = testutil.RandomKey(t)
+		iv  = testutil.RandomIV(t)
+	)
+
+	return func(plainText []byte) ([]byte, error) {
+		padded := concatInto(nil, []byte(prefix), plainText, []byte(suffix))
+		if cbc {
+			return encryptAesCbc(padded, key, iv)
+		}
+		return encryptAesEcb(padded, key)
+	}
+}
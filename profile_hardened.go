@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// _strictEmail matches the email addresses accepted by the hardened profile
+// oracle: a local part made of letters, digits, and ._+- characters, and a
+// domain made of at least two dot separated labels.
+var _strictEmail = regexp.MustCompile(
+	`^[A-Za-z0-9._+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}$`,
+)
+
+// errInvalidMAC is returned by the hardened admin oracle when the MAC of an
+// encrypted profile doesn't verify.
+var errInvalidMAC = errors.New("invalid MAC")
+
+// newHardenedProfileOracles is like newProfileOracles, but it applies the
+// mitigations that make the ECB cut-and-paste attack fail:
+//   - emails are strictly validated, so they can't be crafted to align
+//     arbitrary text (like "admin") to a block boundary as easily.
+//   - profiles are encrypted with AES CBC under a random IV, so equal blocks
+//     of plain text don't produce equal blocks of cipher text.
+//   - the IV and cipher text are authenticated with HMAC-SHA256
+//     (encrypt-then-MAC), so any cut-and-pasted cipher text is rejected
+//     before being decrypted.
+//   - the decrypted profile is decoded strictly, rejecting unknown and
+//     duplicated fields.
+//
+// The encrypted profile is [IV || cipher text || MAC].
+func newHardenedProfileOracles() (aesOracle, adminOracle, error) {
+	encKey, err := newAESKey(128)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	macKey, err := randomBytesN(sha256.Size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating random MAC key: %w", err)
+	}
+
+	encryptionOracle := func(email []byte) ([]byte, error) {
+		if !_strictEmail.Match(email) {
+			return nil, fmt.Errorf("invalid email address %q", email)
+		}
+
+		userProfile, err := profileFor(string(email))
+		if err != nil {
+			return nil, err
+		}
+
+		iv, err := newIV(aes.BlockSize)
+		if err != nil {
+			return nil, fmt.Errorf("generating random IV: %w", err)
+		}
+
+		cipherText, err := encryptAesCbc([]byte(userProfile), encKey, iv)
+		if err != nil {
+			return nil, err
+		}
+
+		authenticated := concatInto(nil, iv, cipherText)
+		return append(authenticated, hmacSHA256(macKey, authenticated)...), nil
+	}
+
+	isAdmin := func(encrypted []byte) (bool, error) {
+		if len(encrypted) < aes.BlockSize+sha256.Size {
+			return false, errors.New("encrypted profile is too short")
+		}
+
+		var (
+			macStart      = len(encrypted) - sha256.Size
+			authenticated = encrypted[:macStart]
+			mac           = encrypted[macStart:]
+		)
+		if !verifyHMAC(macKey, authenticated, mac) {
+			return false, errInvalidMAC
+		}
+
+		var (
+			iv         = authenticated[:aes.BlockSize]
+			cipherText = authenticated[aes.BlockSize:]
+		)
+		plainText, err := decryptAesCbc(cipherText, encKey, iv)
+		if err != nil {
+			return false, err
+		}
+
+		plainText, err = unpadPkcs7Exact(plainText, aes.BlockSize)
+		if err != nil {
+			return false, err
+		}
+
+		profile, err := decodeProfileStrict(string(plainText))
+		if err != nil {
+			return false, err
+		}
+
+		return profile["role"] == "admin", nil
+	}
+
+	return encryptionOracle, isAdmin, nil
+}
+
+// decodeProfileStrict decodes a user profile encoded by profileFor. Unlike
+// url.ParseQuery, it rejects profiles with unknown, missing, or duplicated
+// fields, so a profile like "email=...&role=admin&role=user" is invalid.
+func decodeProfileStrict(encoded string) (map[string]string, error) {
+	var (
+		fields  = [...]string{"email", "role", "uid"}
+		profile = make(map[string]string, len(fields))
+	)
+	for _, pair := range strings.Split(encoded, "&") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed profile field %q", pair)
+		}
+
+		switch key {
+		case fields[0], fields[1], fields[2]:
+		default:
+			return nil, fmt.Errorf("unknown profile field %q", key)
+		}
+
+		if _, ok := profile[key]; ok {
+			return nil, fmt.Errorf("duplicated profile field %q", key)
+		}
+		profile[key] = value
+	}
+
+	for _, f := range fields {
+		if _, ok := profile[f]; !ok {
+			return nil, fmt.Errorf("missing profile field %q", f)
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHardenedProfileOracles(t *testing.T) {
+	encryptionOracle, isAdmin, err := newHardenedProfileOracles()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	encrypted, err := encryptionOracle([]byte("foo@bar.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	admin, err := isAdmin(encrypted)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if admin {
+		t.Errorf("a regular user profile was accepted as admin")
+	}
+
+	// flip a bit of the cipher text.
+	encrypted[20] ^= 1
+	if _, err := isAdmin(encrypted); !errors.Is(err, errInvalidMAC) {
+		t.Errorf("want %q error, got %v", errInvalidMAC, err)
+	}
+
+	if _, err := encryptionOracle([]byte("foo@aaaaadmin")); err == nil {
+		t.Errorf("expected an error for an invalid email address")
+	}
+}
+
+func TestCreateAdminUserHardened(t *testing.T) {
+	encryptionOracle, isAdmin, err := newHardenedProfileOracles()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	admin, err := createAdminProfile(encryptionOracle, isAdmin, profileFor)
+	if admin {
+		t.Fatalf("the attack forged an admin profile against the hardened oracles")
+	}
+	t.Logf("attack failed as expected: %v", err)
+}
+
+func TestDecodeProfileStrict(t *testing.T) {
+	if _, err := decodeProfileStrict("email=foo%40bar.com&role=user&uid=10"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	for _, encoded := range []string{
+		"email=foo%40bar.com&role=admin&role=user&uid=10",
+		"email=foo%40bar.com&role=user",
+		"email=foo%40bar.com&role=user&uid=10&admin=true",
+	} {
+		if _, err := decodeProfileStrict(encoded); err == nil {
+			t.Errorf("expected an error decoding %q", encoded)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// profileService encodes user profiles as URL queries, like profileFor, but
+// its fields, their order, and how uids are assigned are configurable.
+type profileService struct {
+	// fields lists the names of the profile's fields in the order they're
+	// encoded. It always contains "email", "role", and "uid".
+	fields []string
+
+	// values holds the values of the fields that don't depend on the user,
+	// i.e., all of them but "email" and "uid".
+	values map[string]string
+
+	// nextUID returns the uid of the next profile.
+	nextUID func() int
+}
+
+// profileOption defines a type that configures a profileService.
+type profileOption func(*profileService)
+
+// withFieldOrder makes the service encode the profile's fields in the given
+// order. It must list every field of the profile exactly once.
+func withFieldOrder(fields ...string) profileOption {
+	return func(s *profileService) {
+		s.fields = fields
+	}
+}
+
+// withProfileField adds a field with the given constant value to the profiles,
+// after the existing ones. If the field already exists, it sets its value
+// instead (e.g., withProfileField("role", "guest")).
+func withProfileField(name, value string) profileOption {
+	return func(s *profileService) {
+		if !slices.Contains(s.fields, name) {
+			s.fields = append(s.fields, name)
+		}
+		s.values[name] = value
+	}
+}
+
+// withFixedUID makes the service assign the same uid to every profile, which
+// makes its output deterministic.
+func withFixedUID(uid int) profileOption {
+	return func(s *profileService) {
+		s.nextUID = func() int { return uid }
+	}
+}
+
+// newProfileService returns a profileService that, by default, encodes
+// profiles as "email=<email>&role=user&uid=<uid>", where uid is a random
+// number from 10 to 99.
+func newProfileService(opts ...profileOption) (*profileService, error) {
+	s := &profileService{
+		fields:  []string{"email", "role", "uid"},
+		values:  map[string]string{"role": "user"},
+		nextUID: func() int { return 10 + rand.Intn(90) },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	seen := make(map[string]bool, len(s.fields))
+	for _, f := range s.fields {
+		if seen[f] {
+			return nil, fmt.Errorf("duplicated profile field %q", f)
+		}
+		seen[f] = true
+
+		if _, ok := s.values[f]; !ok && f != "email" && f != "uid" {
+			return nil, fmt.Errorf("profile field %q has no value", f)
+		}
+	}
+	for _, f := range []string{"email", "role", "uid"} {
+		if !seen[f] {
+			return nil, fmt.Errorf("missing profile field %q", f)
+		}
+	}
+
+	return s, nil
+}
+
+// profileFor returns the encoding of the user with the given email formatted
+// as a URL query.
+func (s *profileService) profileFor(email string) (string, error) {
+	if strings.ContainsAny(email, "&=") {
+		const errMsg = "invalid email address; can't contain '&' or '=' characters"
+		return "", errors.New(errMsg)
+	}
+
+	pairs := make([]string, 0, len(s.fields))
+	for _, f := range s.fields {
+		var value string
+		switch f {
+		case "email":
+			value = email
+		case "uid":
+			value = strconv.Itoa(s.nextUID())
+		default:
+			value = s.values[f]
+		}
+		pairs = append(pairs, url.QueryEscape(f)+"="+url.QueryEscape(value))
+	}
+
+	return strings.Join(pairs, "&"), nil
+}
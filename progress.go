@@ -0,0 +1,60 @@
+package main
+
+// attackEventKind identifies what happened during an attack.
+type attackEventKind int
+
+const (
+	// eventGuess is reported after the oracle encrypted a guess.
+	eventGuess attackEventKind = iota
+
+	// eventRecovered is reported when a byte of the secret is recovered.
+	eventRecovered
+
+	// eventTarget is reported once, before the first guess, with the
+	// encryption of the secret alone as cipherText.
+	eventTarget
+)
+
+// attackEvent describes a step of an attack.
+type attackEvent struct {
+	kind attackEventKind
+
+	// blockSize is the block size of the cipher under attack.
+	blockSize int
+
+	// targetBlock is the index of the cipher text block under attack.
+	targetBlock int
+
+	// plainText is the plain text the attack sent to the oracle, and
+	// cipherText is what the oracle returned.
+	plainText  []byte
+	cipherText []byte
+
+	// guessPos is the position, within plainText, of the byte being brute
+	// forced.
+	guessPos int
+
+	// recovered holds the bytes of the secret recovered so far.
+	recovered []byte
+}
+
+// progressReporter defines a type that receives the events of an attack as it
+// progresses. The event's slices must not be retained nor modified.
+type progressReporter func(attackEvent)
+
+// withProgress makes the attack report its progress to the given reporter.
+func withProgress(progress progressReporter) attackOption {
+	return func(o *attackOptions) {
+		o.progress = progress
+	}
+}
+
+// multiProgress returns a progressReporter that reports each event to all the
+// given reporters, in order.
+func multiProgress(reporters ...progressReporter) progressReporter {
+	return func(e attackEvent) {
+		for _, report := range reporters {
+			report(e)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// _alignmentMarker is the block we send twice in a row to the oracle to find
+// out where our input begins in the cipher text.
+// It must not be periodic (i.e., no rotation of it is equal to itself),
+// otherwise a misaligned copy of the marker could look like an aligned one.
+var _alignmentMarker = []byte("YELLOW SUBMARINE")
+
+// _maxAlignmentTries is how many queries decryptRandomPrefixOracleSecret
+// makes to get a single aligned cipher text before giving up. With a uniformly
+// random prefix, a query is aligned once every 16 calls on average.
+const _maxAlignmentTries = 1000
+
+// randomPrefixEcbOracle returns an aesOracle that encrypts
+// [random-prefix || plain text || secret] with AES ECB, using the same
+// (randomly generated) key every time. The prefix is made of 0 to maxPrefix
+// random bytes, and it's generated anew on every call.
+// It honors withCompression and withLengthBuckets.
+func randomPrefixEcbOracle(sp secretProvider, maxPrefix int, opts ...oracleOption) (aesOracle, error) {
+	options, err := newOracleOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := sp.secret()
+	if err != nil {
+		return nil, fmt.Errorf("getting oracle's secret: %w", err)
+	}
+	// the provider may hand out memory the caller can still modify.
+	secret = cloneBytes(secret)
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	recordGroundTruth("randomPrefixEcbOracle", key, secret)
+
+	encOracle := func(plainText []byte) ([]byte, error) {
+		prefix, err := randomBytesRange(0, maxPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("generating random prefix: %w", err)
+		}
+
+		encoded, err := options.encode(concatInto(nil, prefix, plainText, secret))
+		if err != nil {
+			return nil, err
+		}
+		return encryptAesEcb(encoded, key, withStdlib())
+	}
+
+	return encOracle, nil
+}
+
+// randomPrefixAtkStats reports how many oracle queries
+// decryptRandomPrefixOracleSecret made.
+type randomPrefixAtkStats struct {
+	// oracleCalls is the total number of queries made to the oracle.
+	oracleCalls int
+
+	// alignedCalls is the number of queries whose cipher text was aligned,
+	// and therefore usable by the attack.
+	alignedCalls int
+}
+
+// extraCalls returns the number of queries wasted because the random prefix
+// didn't align our input to a block boundary.
+func (s randomPrefixAtkStats) extraCalls() int {
+	return s.oracleCalls - s.alignedCalls
+}
+
+// decryptRandomPrefixOracleSecret implements the byte-at-a-time decryption
+// attack against an oracle that encrypts
+// [random-prefix || plain text || secret] with AES ECB, where the prefix has a
+// random length that may change on every call.
+// The idea is to turn such an oracle into one without a prefix, and then run
+// the usual attack (decryptOracleSecret) on it.
+// To do so, we prepend two copies of a marker block to every plain text:
+//
+//	[prefix][filler][marker][marker][plain text][secret]
+//
+// and we keep querying the oracle until the two markers land exactly on two
+// block boundaries, which we recognize because the cipher text then contains
+// two consecutive copies of the encrypted marker. Whatever follows them is the
+// encryption of [plain text || secret], as if there was no prefix at all.
+// The filler's length changes on every query, so this also works when the
+// prefix has a fixed length (i.e., challenge 14).
+// It honors the same options as decryptOracleSecret, except for withBlockSize:
+// the markers are AES blocks.
+func decryptRandomPrefixOracleSecret(
+	encryptionOracle aesOracle,
+	opts ...attackOption,
+) ([]byte, randomPrefixAtkStats, error) {
+
+	var stats randomPrefixAtkStats
+
+	options := newAttackOptions(opts)
+	encryptionOracle = options.limitOracle(encryptionOracle)
+
+	encMarker, err := findEncryptedMarker(encryptionOracle, &stats)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	aligned := func(plainText []byte) ([]byte, error) {
+		return alignedQuery(encryptionOracle, encMarker, plainText, &stats)
+	}
+
+	// the limit on oracle calls, if any, is already enforced on the oracle
+	// the aligned one wraps.
+	opts = append(slices.Clip(opts), withBlockSize(aes.BlockSize), withMaxOracleCalls(0))
+
+	secret, err := decryptOracleSecret(aligned, opts...)
+	return secret, stats, err
+}
+
+// findEncryptedMarker returns the encryption of _alignmentMarker under the
+// oracle's key.
+// Two equal consecutive cipher text blocks can also be produced by a
+// misaligned marker if the random prefix happens to end with the right bytes,
+// so we only trust a candidate after seeing it twice.
+func findEncryptedMarker(
+	encryptionOracle aesOracle,
+	stats *randomPrefixAtkStats,
+) ([]byte, error) {
+
+	const blockSize = aes.BlockSize
+
+	// we follow the markers with a block of zeros: if the secret started with
+	// the marker itself, a misaligned [marker][marker][secret] would produce
+	// equal consecutive blocks that are not the encrypted marker.
+	separator := make([]byte, blockSize)
+
+	seen := make(map[string]int)
+	for try := range _maxAlignmentTries {
+		plainText := markedPlainText(try%blockSize, separator)
+
+		cipherText, err := encryptionOracle(plainText)
+		stats.oracleCalls++
+		if errors.Is(err, errMaxOracleCalls) {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+
+		for i := 1; i < len(cipherText)/blockSize; i++ {
+			var (
+				blockA = blockAt(cipherText, i-1, blockSize)
+				blockB = blockAt(cipherText, i, blockSize)
+			)
+			if !bytes.Equal(blockA, blockB) {
+				continue
+			}
+
+			seen[string(blockA)]++
+			if seen[string(blockA)] == 2 {
+				return cloneBytes(blockA), nil
+			}
+			break
+		}
+	}
+
+	return nil, errors.New("couldn't find the encryption of the alignment marker")
+}
+
+// alignedQuery asks the oracle to encrypt the given plain text, preceded by
+// the alignment markers, until the markers are aligned to block boundaries
+// (and the cipher text is made of whole blocks, i.e., not truncated).
+// It then returns the part of the cipher text following the markers.
+func alignedQuery(
+	encryptionOracle aesOracle,
+	encMarker, plainText []byte,
+	stats *randomPrefixAtkStats,
+) ([]byte, error) {
+
+	const blockSize = aes.BlockSize
+
+	var lastErr error
+	for try := range _maxAlignmentTries {
+		cipherText, err := encryptionOracle(markedPlainText(try%blockSize, plainText))
+		stats.oracleCalls++
+		if errors.Is(err, errMaxOracleCalls) {
+			return nil, err
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(cipherText)%blockSize != 0 {
+			// the oracle truncated its answer: whatever follows the
+			// markers isn't the whole encryption of our plain text.
+			lastErr = fmt.Errorf("%w: got %d bytes", errNotBlockAligned, len(cipherText))
+			continue
+		}
+
+		for i := 1; i < len(cipherText)/blockSize; i++ {
+			var (
+				blockA = blockAt(cipherText, i-1, blockSize)
+				blockB = blockAt(cipherText, i, blockSize)
+			)
+			if bytes.Equal(blockA, encMarker) && bytes.Equal(blockB, encMarker) {
+				// our plain text starts right after the second marker.
+				_, end := blockBounds(i, blockSize)
+				stats.alignedCalls++
+				return cipherText[end:], nil
+			}
+		}
+	}
+
+	if lastErr != nil {
+		const formatStr = "no aligned cipher text after %d queries, last error: %w"
+		return nil, fmt.Errorf(formatStr, _maxAlignmentTries, lastErr)
+	}
+	return nil, fmt.Errorf("no aligned cipher text after %d queries", _maxAlignmentTries)
+}
+
+// markedPlainText returns [filler || marker || marker || plain text], where
+// the filler is made of fillerLen zero bytes.
+func markedPlainText(fillerLen int, plainText []byte) []byte {
+	var (
+		markerLen = len(_alignmentMarker)
+		buf       = make([]byte, fillerLen+2*markerLen+len(plainText))
+	)
+	copy(buf[fillerLen:], _alignmentMarker)
+	copy(buf[fillerLen+markerLen:], _alignmentMarker)
+	copy(buf[fillerLen+2*markerLen:], plainText)
+
+	return buf
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecryptRandomPrefixOracleSecret(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := randomPrefixEcbOracle(staticSecret(secret), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptedSecret, stats, err := decryptRandomPrefixOracleSecret(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, []byte(secret)) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, got)
+	}
+
+	const formatStr = "oracle calls: %d, aligned: %d, extra: %d"
+	t.Logf(formatStr, stats.oracleCalls, stats.alignedCalls, stats.extraCalls())
+}
+
+func TestDecryptFixedPrefixOracleSecret(t *testing.T) {
+	const secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fixed prefix of 7 bytes, as in challenge 14.
+	prefix := []byte("0123456")
+	fixedPrefix := func(plainText []byte) ([]byte, error) {
+		return o(append(prefix[:len(prefix):len(prefix)], plainText...))
+	}
+
+	decryptedSecret, _, err := decryptRandomPrefixOracleSecret(fixedPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := delPadPkcs7(decryptedSecret); !bytes.Equal(got, []byte(secret)) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", secret, got)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits how many queries each caller can make to an oracle
+// server: at most limit queries per window. A caller exceeding the limit is
+// locked out for the lockout duration, like a server defending against
+// brute force would. It's safe for concurrent use.
+type rateLimiter struct {
+	limit   int
+	window  time.Duration
+	lockout time.Duration
+
+	// now returns the current time. Tests replace it with a fake clock.
+	now func() time.Time
+
+	mu      sync.Mutex
+	callers map[string]*callerQuota
+}
+
+// callerQuota is what a rateLimiter knows about a caller.
+type callerQuota struct {
+	windowStart time.Time
+	queries     int
+	lockedUntil time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing limit queries per window to
+// each caller, and locking out for lockout the callers that exceed it.
+func newRateLimiter(limit int, window, lockout time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		lockout: lockout,
+		now:     time.Now,
+		callers: make(map[string]*callerQuota),
+	}
+}
+
+// allow reports whether the caller can make a query now. If it can't, it also
+// returns how long the caller has to wait.
+func (rl *rateLimiter) allow(caller string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+
+	q, ok := rl.callers[caller]
+	if !ok {
+		q = &callerQuota{windowStart: now}
+		rl.callers[caller] = q
+	}
+
+	if now.Before(q.lockedUntil) {
+		return false, q.lockedUntil.Sub(now)
+	}
+	if now.Sub(q.windowStart) >= rl.window {
+		q.windowStart, q.queries = now, 0
+	}
+
+	q.queries++
+	if q.queries > rl.limit {
+		q.lockedUntil = now.Add(rl.lockout)
+		q.windowStart, q.queries = q.lockedUntil, 0
+		return false, rl.lockout
+	}
+
+	return true, 0
+}
+
+// withRateLimit makes the oracle handler reply 429 Too Many Requests, with a
+// Retry-After header, to the callers rl doesn't allow.
+func withRateLimit(rl *rateLimiter) oracleHandlerOption {
+	return func(o *oracleHandlerOptions) {
+		o.rateLimit = rl
+	}
+}
+
+// rateLimited checks whether the request's caller is allowed by rl and, if
+// it's not, replies 429 and returns true.
+func rateLimited(rl *rateLimiter, w http.ResponseWriter, r *http.Request) bool {
+	caller, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		caller = r.RemoteAddr
+	}
+
+	ok, wait := rl.allow(caller)
+	if ok {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+	return true
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)}
+
+	rl := newRateLimiter(3, time.Second, 10*time.Second)
+	rl.now = clock.Now
+
+	for i := range 3 {
+		if ok, _ := rl.allow("mallory"); !ok {
+			t.Fatalf("query %d refused", i)
+		}
+	}
+
+	ok, wait := rl.allow("mallory")
+	if ok || wait != 10*time.Second {
+		t.Errorf("want a 10s lockout, got allowed=%t wait=%s", ok, wait)
+	}
+	if ok, _ := rl.allow("alice"); !ok {
+		t.Errorf("another caller was locked out")
+	}
+
+	clock.Sleep(9 * time.Second)
+	if ok, wait := rl.allow("mallory"); ok || wait != time.Second {
+		t.Errorf("want 1s left of lockout, got allowed=%t wait=%s", ok, wait)
+	}
+
+	clock.Sleep(time.Second)
+	if ok, _ := rl.allow("mallory"); !ok {
+		t.Errorf("query refused after the lockout")
+	}
+}
+
+func TestHTTPOracleBacksOff(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)}
+
+	rl := newRateLimiter(5, time.Second, 3*time.Second)
+	rl.now = clock.Now
+
+	echo := func(plainText []byte) ([]byte, error) { return plainText, nil }
+	srv := httptest.NewServer(oracleHandler(echo, withRateLimit(rl)))
+	defer srv.Close()
+
+	var (
+		sleep = func(o *httpOracleOptions) { o.sleep = clock.Sleep }
+		o     = httpOracle(srv.Client(), srv.URL, sleep)
+		start = clock.Now()
+	)
+	for i := range 50 {
+		got, err := o([]byte("A"))
+		if err != nil {
+			t.Fatalf("query %d: unexpected error: %s", i, err)
+		}
+		if string(got) != "A" {
+			t.Fatalf("query %d: want %q, got %q", i, "A", got)
+		}
+	}
+
+	// 50 queries at 5 per second can't take less than 9 seconds.
+	if elapsed := clock.Now().Sub(start); elapsed < 9*time.Second {
+		t.Errorf("50 queries took only %s", elapsed)
+	}
+}
+
+func TestHTTPOracleGivesUp(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)}
+
+	// the limit is 0: every query is refused.
+	rl := newRateLimiter(0, time.Second, time.Second)
+	rl.now = clock.Now
+
+	echo := func(plainText []byte) ([]byte, error) { return plainText, nil }
+	srv := httptest.NewServer(oracleHandler(echo, withRateLimit(rl)))
+	defer srv.Close()
+
+	o := httpOracle(srv.Client(), srv.URL, func(o *httpOracleOptions) { o.sleep = clock.Sleep })
+	_, err := o([]byte("A"))
+	if err == nil || !strings.Contains(err.Error(), http.StatusText(http.StatusTooManyRequests)) {
+		t.Errorf("want a %q error, got %v", http.StatusText(http.StatusTooManyRequests), err)
+	}
+}
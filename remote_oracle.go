@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// _maxRateLimitRetries is how many times httpOracle retries a query the
+// remote oracle refused because of its rate limit.
+const _maxRateLimitRetries = 5
+
+// httpOracleOptions configures httpOracle.
+type httpOracleOptions struct {
+	// sleep waits for the given duration. Tests replace it with a fake clock.
+	sleep func(time.Duration)
+}
+
+// httpOracleOption defines a type that sets an option of httpOracle.
+type httpOracleOption func(*httpOracleOptions)
+
+// httpOracle returns an aesOracle that queries a remote oracle over HTTP.
+// The plain text is sent hex encoded in the body of a POST request to the
+// given URL, and the oracle is expected to reply with status 200 and the hex
+// encoded cipher text in the body of the response.
+// If the oracle replies 429 Too Many Requests, the query is retried after the
+// time given by its Retry-After header. The oracle also adapts its pace: after
+// being throttled it waits between queries, doubling the pause on every 429
+// and halving it on every success, so that an attack settles just under the
+// server's limit instead of hitting it again and again.
+func httpOracle(client *http.Client, url string, opts ...httpOracleOption) aesOracle {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	options := httpOracleOptions{sleep: time.Sleep}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var (
+		mu    sync.Mutex
+		pause time.Duration
+	)
+	adjustPause := func(throttled bool, retryAfter time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case throttled:
+			pause = max(2*pause, retryAfter/_maxRateLimitRetries, time.Millisecond)
+		case pause < time.Millisecond:
+			pause = 0
+		default:
+			pause /= 2
+		}
+	}
+
+	return func(plainText []byte) ([]byte, error) {
+		mu.Lock()
+		p := pause
+		mu.Unlock()
+		if p > 0 {
+			options.sleep(p)
+		}
+
+		for try := 0; ; try++ {
+			cipherText, retryAfter, err := queryHTTPOracle(client, url, plainText)
+			if retryAfter < 0 {
+				adjustPause(false, 0)
+				return cipherText, err
+			}
+
+			adjustPause(true, retryAfter)
+			if try == _maxRateLimitRetries {
+				return nil, err
+			}
+			options.sleep(retryAfter)
+		}
+	}
+}
+
+// queryHTTPOracle makes a single query to the remote oracle. If the oracle
+// refused it because of its rate limit, it also returns how long to wait
+// before trying again, otherwise it returns a negative duration.
+func queryHTTPOracle(
+	client *http.Client,
+	url string,
+	plainText []byte,
+) ([]byte, time.Duration, error) {
+
+	body := bytes.NewBufferString(hex.EncodeToString(plainText))
+
+	resp, err := client.Post(url, "text/plain", body)
+	if err != nil {
+		return nil, -1, fmt.Errorf("querying remote oracle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, -1, fmt.Errorf("reading remote oracle's response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		const formatStr = "remote oracle replied %s: %s"
+		err := fmt.Errorf(formatStr, resp.Status, bytes.TrimSpace(respBody))
+
+		retryAfter := time.Duration(-1)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter = time.Second
+			if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs >= 0 {
+				retryAfter = time.Duration(secs) * time.Second
+			}
+		}
+		return nil, retryAfter, err
+	}
+
+	cipherText, err := hex.DecodeString(string(bytes.TrimSpace(respBody)))
+	if err != nil {
+		return nil, -1, fmt.Errorf("decoding remote oracle's response: %w", err)
+	}
+
+	return cipherText, -1, nil
+}
+
+// oracleHandlerOptions configures oracleHandler.
+type oracleHandlerOptions struct {
+	// audit, if not nil, records every query.
+	audit *auditLog
+
+	// rateLimit, if not nil, limits the queries of each caller.
+	rateLimit *rateLimiter
+}
+
+// oracleHandlerOption defines a type that sets an option of oracleHandler.
+type oracleHandlerOption func(*oracleHandlerOptions)
+
+// oracleHandler returns an http.Handler that exposes the given oracle using
+// the protocol expected by httpOracle.
+func oracleHandler(oracle aesOracle, opts ...oracleHandlerOption) http.Handler {
+	var options oracleHandlerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// audit records the query, if there's an audit log. plainText is nil if
+	// the query was refused before its plain text was known.
+	audit := func(r *http.Request, plainText []byte, status int) {
+		if options.audit != nil {
+			options.audit.record(r.RemoteAddr, plainText, status)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if options.rateLimit != nil && rateLimited(options.rateLimit, w, r) {
+			audit(r, nil, http.StatusTooManyRequests)
+			return
+		}
+
+		reqBody, err := io.ReadAll(r.Body)
+		if err != nil {
+			audit(r, nil, http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		plainText, err := hex.DecodeString(string(bytes.TrimSpace(reqBody)))
+		if err != nil {
+			audit(r, nil, http.StatusBadRequest)
+			http.Error(w, "malformed hex plain text", http.StatusBadRequest)
+			return
+		}
+
+		cipherText, err := oracle(plainText)
+		if err != nil {
+			audit(r, plainText, http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		audit(r, plainText, http.StatusOK)
+		fmt.Fprintln(w, hex.EncodeToString(cipherText))
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPOracle(t *testing.T) {
+	reverse := func(plainText []byte) ([]byte, error) {
+		if len(plainText) == 0 {
+			return nil, errors.New("empty plain text")
+		}
+
+		cipherText := make([]byte, len(plainText))
+		for i, b := range plainText {
+			cipherText[len(plainText)-1-i] = b
+		}
+		return cipherText, nil
+	}
+
+	srv := httptest.NewServer(oracleHandler(reverse))
+	defer srv.Close()
+
+	o := httpOracle(srv.Client(), srv.URL)
+
+	got, err := o([]byte("abc"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(got, []byte("cba")) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "cba", got)
+	}
+
+	if _, err := o(nil); err == nil {
+		t.Errorf("expected the remote oracle's error to be returned")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// _markdownCell escapes the characters that would break a code span in a
+// Markdown table cell.
+var _markdownCell = strings.NewReplacer("|", `\|`, "`", "'")
+
+// attackReport collects the events of an attack on an oracle's secret (see
+// withProgress) to write a report of it in plain language, once the attack
+// completed.
+type attackReport struct {
+	// blockSize is the block size of the cipher under attack, and
+	// secretBlocks the number of blocks the oracle encrypts the secret into.
+	blockSize    int
+	secretBlocks int
+
+	// guesses is the number of guesses the attack sent to the oracle, and
+	// blocks what it did to each block of the secret, in order.
+	guesses int64
+	blocks  []blockSteps
+}
+
+// blockSteps is what an attack did to a block of the secret.
+type blockSteps struct {
+	index     int
+	guesses   int64
+	recovered []byte
+}
+
+// reporter returns a progressReporter that records the events it receives
+// in r. It's not safe for concurrent use.
+func (r *attackReport) reporter() progressReporter {
+	return func(e attackEvent) {
+		r.blockSize = e.blockSize
+
+		switch e.kind {
+		case eventTarget:
+			r.secretBlocks = len(e.cipherText) / e.blockSize
+			return
+		case eventGuess:
+			r.guesses++
+		}
+
+		if len(r.blocks) == 0 || r.blocks[len(r.blocks)-1].index != e.targetBlock {
+			r.blocks = append(r.blocks, blockSteps{index: e.targetBlock})
+		}
+		block := &r.blocks[len(r.blocks)-1]
+
+		switch e.kind {
+		case eventGuess:
+			block.guesses++
+		case eventRecovered:
+			// the event's slices are reused by the attack.
+			block.recovered = append(block.recovered, e.recovered[len(e.recovered)-1])
+		}
+	}
+}
+
+// writeMarkdown writes a Markdown report of the attack that produced res: what
+// it found out about the target, the steps it took, the oracle calls it made
+// and what it recovered.
+func (r *attackReport) writeMarkdown(w io.Writer, res attackResult) error {
+	var (
+		b         strings.Builder
+		recovered int
+	)
+	for _, block := range r.blocks {
+		recovered += len(block.recovered)
+	}
+	// the attacks recover the first byte of padding too, unless they
+	// stopped early (see withVerifier).
+	complete := recovered > len(res.PlainText)
+
+	fmt.Fprintf(&b, "# Attack report: %s\n\n", res.Attack)
+
+	b.WriteString("## Target\n\n")
+	fmt.Fprintf(&b, "- The cipher works on blocks of %d bytes.\n", r.blockSize)
+	b.WriteString("- The oracle prepends nothing to our input: it starts the first block.\n")
+	if complete {
+		fmt.Fprintf(&b, "- The oracle appends a secret of %d bytes to our input, ", len(res.PlainText))
+	} else {
+		fmt.Fprintf(&b, "- The oracle appends a secret of up to %d bytes to our input, ", r.secretBlocks*r.blockSize-1)
+	}
+	fmt.Fprintf(&b, "which it pads and encrypts into %d blocks (%d bytes).\n\n", r.secretBlocks, r.secretBlocks*r.blockSize)
+
+	b.WriteString("## Steps\n\n")
+	b.WriteString("Each byte of the secret was recovered by pushing it to the end of a block with filler bytes, ")
+	b.WriteString("then trying every value of the last byte of that block until the oracle's cipher text matched. ")
+	if complete {
+		b.WriteString("The last byte recovered is the first byte of padding, after which the attack stopped.\n\n")
+	} else {
+		fmt.Fprintf(&b, "The attack stopped after %d bytes, as soon as they achieved its goal.\n\n", recovered)
+	}
+	b.WriteString("| Block | Bytes recovered | Guesses | Recovered |\n")
+	b.WriteString("|------:|----------------:|--------:|-----------|\n")
+	for _, block := range r.blocks {
+		fmt.Fprintf(&b, "| %d | %d | %d | `%s` |\n",
+			block.index, len(block.recovered), block.guesses, _markdownCell.Replace(printable(block.recovered)))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Oracle calls\n\n")
+	if res.OracleCalls > 0 {
+		fmt.Fprintf(&b, "- %d calls in total, %d of which were guesses.\n", res.OracleCalls, r.guesses)
+	} else {
+		fmt.Fprintf(&b, "- %d guesses.\n", r.guesses)
+	}
+	if recovered > 0 {
+		fmt.Fprintf(&b, "- %.1f guesses per byte recovered, on average.\n", float64(r.guesses)/float64(recovered))
+	}
+	fmt.Fprintf(&b, "- The attack took %s.\n\n", res.Duration)
+
+	b.WriteString("## Recovered secret\n\n")
+	fmt.Fprintf(&b, "```\n%s\n```\n", strings.TrimSuffix(string(res.PlainText), "\n"))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+func TestAttackReport(t *testing.T) {
+	const secret = "YELLOW SUBMARINE|RED `SUNSHINE`"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		report        attackReport
+		oracle, calls = countOracleCalls(o)
+	)
+	recovered, err := decryptOracleSecret(oracle, withProgress(report.reporter()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	res := attackResult{
+		Attack:      "ecb-suffix",
+		PlainText:   delPadPkcs7(recovered),
+		OracleCalls: calls(),
+		Duration:    time.Second,
+	}
+	var buf bytes.Buffer
+	if err := report.writeMarkdown(&buf, res); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the oracle's key is random, but the guesses aren't.
+	testutil.Golden(t, "files/report.golden", buf.Bytes())
+}
+
+func TestMultiProgress(t *testing.T) {
+	var got []string
+	progress := multiProgress(
+		func(e attackEvent) { got = append(got, "first") },
+		func(e attackEvent) { got = append(got, "second") },
+	)
+	progress(attackEvent{})
+
+	if want := "first second"; strings.Join(got, " ") != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+}
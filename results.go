@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// errNoRuns is returned when a results store has no runs to summarize.
+var errNoRuns = errors.New("no runs recorded")
+
+// runRecord holds the metadata of an attack run, as kept by a results store.
+// It doesn't hold what the attack recovered: the store is for comparing runs,
+// and it shouldn't become a file full of keys.
+type runRecord struct {
+	// Attack is the name of the attack (e.g., "ecb-suffix").
+	Attack string `json:"attack"`
+
+	// Time is when the run started.
+	Time time.Time `json:"time"`
+
+	Duration    time.Duration `json:"durationNs"`
+	OracleCalls int64         `json:"oracleCalls,omitempty"`
+	Success     bool          `json:"success"`
+
+	// Error is the reason the run failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// newRunRecord returns the record of a run of the given attack that started
+// at start and ended now with the given error.
+func newRunRecord(attack string, start time.Time, oracleCalls int64, err error) runRecord {
+	r := runRecord{
+		Attack:      attack,
+		Time:        start,
+		Duration:    time.Since(start),
+		OracleCalls: oracleCalls,
+		Success:     err == nil,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// appendRunRecord appends the given record to the results store at path,
+// creating the store if it doesn't exist.
+// The store is a file of JSON objects, one per line, so that appending is
+// cheap and the file can be inspected with any JSON tool.
+func appendRunRecord(path string, r runRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding run record: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening results store: %w", err)
+	}
+
+	// a single write, so that concurrent runs appending to the same store
+	// don't interleave their lines.
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("writing results store: %w", err)
+	}
+	return f.Close()
+}
+
+// readRunRecords reads all the records of a results store.
+func readRunRecords(r io.Reader) ([]runRecord, error) {
+	var (
+		records []runRecord
+		scanner = bufio.NewScanner(r)
+		lineNum int
+	)
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec runRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// loadRunRecords reads all the records of the results store at path.
+func loadRunRecords(path string) ([]runRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening results store: %w", err)
+	}
+	defer f.Close()
+
+	return readRunRecords(f)
+}
+
+// attackStats summarizes the runs of an attack.
+type attackStats struct {
+	Attack    string `json:"attack"`
+	Runs      int    `json:"runs"`
+	Successes int    `json:"successes"`
+
+	// the durations and oracle calls only account for the successful runs:
+	// failed ones often stop early, and would make an attack look faster than
+	// it is.
+	MeanDuration    time.Duration `json:"meanDurationNs"`
+	MinDuration     time.Duration `json:"minDurationNs"`
+	MaxDuration     time.Duration `json:"maxDurationNs"`
+	MeanOracleCalls float64       `json:"meanOracleCalls"`
+
+	// First and Last are the start times of the oldest and newest runs.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// summarizeRuns returns the statistics of each attack in the given records,
+// sorted by attack name.
+func summarizeRuns(records []runRecord) []attackStats {
+	byAttack := make(map[string]*attackStats)
+	for _, r := range records {
+		s, ok := byAttack[r.Attack]
+		if !ok {
+			s = &attackStats{Attack: r.Attack, First: r.Time, Last: r.Time}
+			byAttack[r.Attack] = s
+		}
+
+		s.Runs++
+		if r.Time.Before(s.First) {
+			s.First = r.Time
+		}
+		if r.Time.After(s.Last) {
+			s.Last = r.Time
+		}
+		if !r.Success {
+			continue
+		}
+
+		if s.Successes == 0 || r.Duration < s.MinDuration {
+			s.MinDuration = r.Duration
+		}
+		if r.Duration > s.MaxDuration {
+			s.MaxDuration = r.Duration
+		}
+		// running means, so that we don't need a second pass.
+		s.Successes++
+		s.MeanDuration += (r.Duration - s.MeanDuration) / time.Duration(s.Successes)
+		s.MeanOracleCalls += (float64(r.OracleCalls) - s.MeanOracleCalls) / float64(s.Successes)
+	}
+
+	stats := make([]attackStats, 0, len(byAttack))
+	for _, s := range byAttack {
+		stats = append(stats, *s)
+	}
+	slices.SortFunc(stats, func(a, b attackStats) int {
+		return cmp.Compare(a.Attack, b.Attack)
+	})
+
+	return stats
+}
+
+// recordRun appends the record of a run to the results store at path, if path
+// isn't empty, and returns the run's error. If the run succeeded but couldn't
+// be recorded, it returns the recording error instead.
+func recordRun(path string, r runRecord, runErr error) error {
+	if path == "" {
+		return runErr
+	}
+	if err := appendRunRecord(path, r); err != nil && runErr == nil {
+		return err
+	}
+	return runErr
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunRecordsRoundTrip(t *testing.T) {
+	var (
+		path  = filepath.Join(t.TempDir(), "runs.jsonl")
+		start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		want  = []runRecord{
+			{Attack: "ecb-suffix", Time: start, Duration: time.Second, OracleCalls: 3000, Success: true},
+			{Attack: "ecb-suffix", Time: start.Add(time.Hour), Duration: time.Millisecond, Error: "boom"},
+		}
+	)
+	for _, r := range want {
+		if err := appendRunRecord(path, r); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	got, err := loadRunRecords(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\nwant:\t%v\ngot:\t%v\n", want, got)
+	}
+}
+
+func TestReadRunRecordsMalformed(t *testing.T) {
+	_, err := readRunRecords(strings.NewReader("{\"attack\":\"a\"}\nnope\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("want an error on line 2, got %v", err)
+	}
+}
+
+func TestSummarizeRuns(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []runRecord{
+		{Attack: "xor-single", Time: start, Duration: 2 * time.Millisecond, Success: true},
+		{Attack: "ecb-suffix", Time: start.Add(time.Hour), Duration: 3 * time.Second, OracleCalls: 300, Success: true},
+		{Attack: "ecb-suffix", Time: start, Duration: time.Second, OracleCalls: 100, Success: true},
+		{Attack: "ecb-suffix", Time: start.Add(2 * time.Hour), Duration: time.Millisecond, Error: "boom"},
+	}
+
+	want := []attackStats{
+		{
+			Attack:          "ecb-suffix",
+			Runs:            3,
+			Successes:       2,
+			MeanDuration:    2 * time.Second,
+			MinDuration:     time.Second,
+			MaxDuration:     3 * time.Second,
+			MeanOracleCalls: 200,
+			First:           start,
+			Last:            start.Add(2 * time.Hour),
+		},
+		{
+			Attack:       "xor-single",
+			Runs:         1,
+			Successes:    1,
+			MeanDuration: 2 * time.Millisecond,
+			MinDuration:  2 * time.Millisecond,
+			MaxDuration:  2 * time.Millisecond,
+			First:        start,
+			Last:         start,
+		},
+	}
+
+	if got := summarizeRuns(records); !reflect.DeepEqual(got, want) {
+		t.Errorf("\nwant:\t%+v\ngot:\t%+v\n", want, got)
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	var (
+		badPath = filepath.Join(t.TempDir(), "missing", "runs.jsonl")
+		runErr  = errors.New("attack failed")
+		r       = runRecord{Attack: "xor-single"}
+	)
+
+	if err := recordRun("", r, runErr); err != runErr {
+		t.Errorf("want %v, got %v", runErr, err)
+	}
+	// the attack's error wins over the recording's one.
+	if err := recordRun(badPath, r, runErr); err != runErr {
+		t.Errorf("want %v, got %v", runErr, err)
+	}
+	if err := recordRun(badPath, r, nil); err == nil {
+		t.Error("want an error recording to a missing directory")
+	}
+}
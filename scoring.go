@@ -0,0 +1,51 @@
+package main
+
+import "math"
+
+// scorer defines a type that rates how plausible a candidate plain text is.
+// Higher scores are more plausible. A score of -Inf rejects the candidate.
+type scorer func([]byte) float64
+
+// withScorer makes the attacks that rank candidate plain texts (e.g., the XOR
+// breakers) use the given scorer instead of englishScore. This allows them to
+// recover plain texts that are not English text, like binary files.
+func withScorer(s scorer) attackOption {
+	return func(o *attackOptions) {
+		o.scorer = s
+	}
+}
+
+// englishScore is the default scorer: it rejects candidates that don't look
+// like text at all (see scoreBinaryLikelihood), and rates the others with
+// computeTextScore.
+func englishScore(data []byte) float64 {
+	if scoreBinaryLikelihood(data) > _maxBinaryLikelihood {
+		return math.Inf(-1)
+	}
+	return computeTextScore(data)
+}
+
+// binaryScore is a scorer for binary formats (e.g., executables, protobufs, or
+// file headers): rather than letter frequencies, it rewards the bytes that
+// dominate such data, i.e., zero bytes (padding, high bytes of small
+// integers), 0xff bytes (negative integers, masks) and other small values
+// (lengths, tags, flags).
+func binaryScore(data []byte) float64 {
+	if len(data) == 0 {
+		return math.Inf(-1)
+	}
+
+	var score float64
+	for _, b := range data {
+		switch {
+		case b == 0x00:
+			score += 1
+		case b == 0xff:
+			score += 0.3
+		case b < 0x10:
+			score += 0.2
+		}
+	}
+
+	return score / float64(len(data))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBreakRepeatingKeyXORBinary(t *testing.T) {
+	// a binary file made of records holding a small id, a flags field, a
+	// negative value, and some zero padding.
+	var plainText []byte
+	for i := range 200 {
+		record := make([]byte, 16)
+		binary.LittleEndian.PutUint32(record[0:], uint32(i))
+		binary.LittleEndian.PutUint16(record[4:], uint16(i%4))
+		binary.LittleEndian.PutUint32(record[6:], math.MaxUint32-uint32(i%3))
+		plainText = append(plainText, record...)
+	}
+
+	const key = "B1n@ry!"
+	cipherText := repeatingKeyXOR(plainText, []byte(key))
+
+	gotPlainText, gotKey, err := breakRepeatingKeyXOR(cipherText, 40, withScorer(binaryScore))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the key size estimation may pick a multiple of the key's size, which
+	// decrypts just as well.
+	if gotKey != strings.Repeat(key, len(gotKey)/len(key)) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", key, gotKey)
+	}
+	if !bytes.Equal([]byte(gotPlainText), plainText) {
+		t.Errorf("recovered plain text doesn't match the original")
+	}
+}
+
+func TestEnglishScore(t *testing.T) {
+	if score := englishScore([]byte{0x00, 0x01, 0x02, 0x03}); !math.IsInf(score, -1) {
+		t.Errorf("binary data was not rejected, score: %f", score)
+	}
+
+	if score := englishScore([]byte("plain text")); score <= 0 {
+		t.Errorf("text has non positive score %f", score)
+	}
+}
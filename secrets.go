@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// secretProvider defines a type that provides the secret appended by an
+// encryption oracle to the plain texts it encrypts.
+type secretProvider interface {
+	secret() ([]byte, error)
+}
+
+// staticSecret is a secretProvider returning the given bytes.
+type staticSecret []byte
+
+func (s staticSecret) secret() ([]byte, error) {
+	return s, nil
+}
+
+// fileSecret is a secretProvider reading the secret from the file at the given
+// path.
+type fileSecret string
+
+func (path fileSecret) secret() ([]byte, error) {
+	s, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading secret from file: %w", err)
+	}
+	return s, nil
+}
+
+// envSecret is a secretProvider reading the secret from the environment
+// variable with the given name.
+type envSecret string
+
+func (name envSecret) secret() ([]byte, error) {
+	s, ok := os.LookupEnv(string(name))
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return []byte(s), nil
+}
+
+// generatedSecret is a secretProvider generating a random secret of the given
+// length.
+type generatedSecret int
+
+func (n generatedSecret) secret() ([]byte, error) {
+	s, err := randomBytesN(int(n))
+	if err != nil {
+		return nil, fmt.Errorf("generating random secret: %w", err)
+	}
+	return s, nil
+}
+
+// base64Secret is a secretProvider decoding the base64 secret provided by
+// another secretProvider.
+type base64Secret struct {
+	secretProvider
+}
+
+func (b base64Secret) secret() ([]byte, error) {
+	encoded, err := b.secretProvider.secret()
+	if err != nil {
+		return nil, err
+	}
+
+	s := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(s, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 secret: %w", err)
+	}
+	return s[:n], nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretProviders(t *testing.T) {
+	const want = "YELLOW SUBMARINE"
+
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(path, []byte(want), 0o600); err != nil {
+		t.Fatalf("writing secret file: %s", err)
+	}
+
+	const envVar = "CRYPTOPALS_TEST_SECRET"
+	t.Setenv(envVar, "WUVMTE9XIFNVQk1BUklORQ==")
+
+	providers := map[string]secretProvider{
+		"static": staticSecret(want),
+		"file":   fileSecret(path),
+		"env":    base64Secret{envSecret(envVar)},
+	}
+	for name, sp := range providers {
+		got, err := sp.secret()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		} else if string(got) != want {
+			t.Errorf("%s:\nwant:\t%q\ngot:\t%q\n", name, want, got)
+		}
+	}
+
+	got, err := generatedSecret(10).secret()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 10 {
+		t.Errorf("want a secret of 10 bytes, got %d bytes", len(got))
+	}
+
+	if _, err := envSecret("CRYPTOPALS_UNSET_VARIABLE").secret(); err == nil {
+		t.Errorf("expected an error for an unset environment variable")
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// _setFiles holds the input files of the challenges and the golden files of
+// their outputs, so that runSet doesn't depend on the working directory.
+//
+//go:embed files/[12]_*.txt files/[12]_*.golden
+var _setFiles embed.FS
+
+// challenge is an implemented challenge of a set.
+type challenge struct {
+	number int
+	title  string
+
+	// run solves the challenge and returns its output, which runSet checks
+	// against the challenge's golden file (see goldenPath).
+	run func() ([]byte, error)
+
+	// golden is the path of the golden file in _setFiles, if the challenge
+	// shares it with another one (e.g., it recovers the same secret).
+	golden string
+}
+
+// challengeSet is a set of challenges, in order.
+type challengeSet struct {
+	number     int
+	title      string
+	challenges []challenge
+}
+
+// _sets holds the sets with at least one implemented challenge, in order.
+var _sets = []challengeSet{
+	{
+		number: 1,
+		title:  "Basics",
+		challenges: []challenge{
+			{number: 1, title: "Convert hex to base64", run: runChallenge1},
+			{number: 2, title: "Fixed XOR", run: runChallenge2},
+			{number: 3, title: "Single-byte XOR cipher", run: runChallenge3},
+			{number: 4, title: "Detect single-character XOR", run: runChallenge4},
+			{number: 5, title: "Implement repeating-key XOR", run: runChallenge5},
+			{number: 6, title: "Break repeating-key XOR", run: runChallenge6},
+			{number: 7, title: "AES in ECB mode", run: runChallenge7},
+			{number: 8, title: "Detect AES in ECB mode", run: runChallenge8},
+		},
+	},
+	{
+		number: 2,
+		title:  "Block crypto",
+		challenges: []challenge{
+			{number: 9, title: "Implement PKCS#7 padding", run: runChallenge9},
+			{
+				number: 10,
+				title:  "Implement CBC mode",
+				run:    runChallenge10,
+				golden: "files/1_7.golden",
+			},
+			{number: 11, title: "An ECB/CBC detection oracle", run: runChallenge11},
+			{number: 12, title: "Byte-at-a-time ECB decryption (Simple)", run: runChallenge12},
+			{number: 13, title: "ECB cut-and-paste", run: runChallenge13},
+			{
+				number: 14,
+				title:  "Byte-at-a-time ECB decryption (Harder)",
+				run:    runChallenge14,
+				golden: "files/2_12.golden",
+			},
+			{number: 15, title: "PKCS#7 padding validation", run: runChallenge15},
+		},
+	},
+}
+
+// findSet returns the set with the given number.
+func findSet(number int) (challengeSet, bool) {
+	for _, s := range _sets {
+		if s.number == number {
+			return s, true
+		}
+	}
+	return challengeSet{}, false
+}
+
+// goldenPath returns the path, in _setFiles, of the golden file of challenge c
+// of set s: files/<set>_<challenge>.golden, unless c sets its own.
+func (s challengeSet) goldenPath(c challenge) string {
+	if c.golden != "" {
+		return c.golden
+	}
+	return fmt.Sprintf("files/%d_%d.golden", s.number, c.number)
+}
+
+// challengeResult is the outcome of a challenge run by runSet.
+type challengeResult struct {
+	challenge challenge
+	duration  time.Duration
+
+	// err is nil if the challenge's output matched its golden file.
+	err error
+}
+
+// runSet runs the challenges of s in order, checks their outputs against their
+// golden files and returns their results. A challenge that fails doesn't stop
+// the ones after it.
+func runSet(s challengeSet) []challengeResult {
+	results := make([]challengeResult, 0, len(s.challenges))
+	for _, c := range s.challenges {
+		start := time.Now()
+		output, err := c.run()
+		res := challengeResult{challenge: c, duration: time.Since(start), err: err}
+
+		if err == nil {
+			res.err = checkGolden(s.goldenPath(c), output)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// checkGolden compares output with the golden file at path in _setFiles.
+func checkGolden(path string, output []byte) error {
+	want, err := _setFiles.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading golden file: %w", err)
+	}
+	if !bytes.Equal(output, want) {
+		return fmt.Errorf("output differs from golden file %s", path)
+	}
+	return nil
+}
+
+// writeChecklist writes to w the checklist of set s with the given results of
+// its challenges (see runSet): a line per challenge, checked if it passed,
+// with the time it took and why it failed, if it did, followed by a summary.
+// It returns the number of challenges that failed.
+func writeChecklist(w io.Writer, s challengeSet, results []challengeResult) (int, error) {
+	var (
+		b      strings.Builder
+		failed int
+		total  time.Duration
+	)
+	fmt.Fprintf(&b, "Set %d: %s\n", s.number, s.title)
+
+	for _, res := range results {
+		total += res.duration
+
+		mark := "x"
+		if res.err != nil {
+			mark = " "
+			failed++
+		}
+		fmt.Fprintf(&b, "  [%s] %2d. %-40s %10s", mark, res.challenge.number, res.challenge.title,
+			res.duration.Round(time.Microsecond))
+		if res.err != nil {
+			fmt.Fprintf(&b, "  %s", res.err)
+		}
+		b.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&b, "  %d/%d challenges passed in %s\n",
+		len(results)-failed, len(results), total.Round(time.Millisecond))
+
+	_, err := io.WriteString(w, b.String())
+	return failed, err
+}
+
+// loadBase64File returns the decoded content of the base64 encoded file at
+// path in _setFiles. Line breaks are ignored.
+func loadBase64File(path string) ([]byte, error) {
+	data, err := _setFiles.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCipherText(data, "base64")
+}
+
+// loadHexLines returns the hex decoded lines of the file at path in
+// _setFiles.
+func loadHexLines(path string) ([][]byte, error) {
+	data, err := _setFiles.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]byte
+	for _, line := range strings.Fields(string(data)) {
+		decoded, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		lines = append(lines, decoded)
+	}
+	return lines, nil
+}
+
+func runChallenge1() ([]byte, error) {
+	const input = "49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d"
+
+	encoded, err := hexToBase64(input)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(encoded + "\n"), nil
+}
+
+func runChallenge2() ([]byte, error) {
+	xored, err := xorHexStrings("1c0111001f010100061a024b53535009181c", "686974207468652062756c6c277320657965")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(xored + "\n"), nil
+}
+
+func runChallenge3() ([]byte, error) {
+	cipherText, err := hex.DecodeString("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+	if err != nil {
+		return nil, err
+	}
+
+	plainText, key := singleByteXOR(cipherText)
+	return fmt.Appendf(nil, "key %q: %s\n", key, plainText), nil
+}
+
+func runChallenge4() ([]byte, error) {
+	lines, err := loadHexLines("files/1_4.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	line, best, err := detectSingleByteXOR(lines)
+	if err != nil {
+		return nil, err
+	}
+	// the plain text ends with a newline.
+	return fmt.Appendf(nil, "line %d, key %q: %s", line+1, best.key, best.plainText), nil
+}
+
+func runChallenge5() ([]byte, error) {
+	const plainText = "Burning 'em, if you ain't quick and nimble\nI go crazy when I hear a cymbal"
+
+	cipherText := repeatingKeyXOR([]byte(plainText), []byte("ICE"))
+	return []byte(hex.EncodeToString(cipherText) + "\n"), nil
+}
+
+func runChallenge6() ([]byte, error) {
+	cipherText, err := loadBase64File("files/1_6.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	plainText, key, err := breakRepeatingKeyXOR(cipherText, 40)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "key %q\n\n%s", key, plainText), nil
+}
+
+func runChallenge7() ([]byte, error) {
+	cipherText, err := loadBase64File("files/1_7.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	plainText, err := decryptAesEcb(cipherText, []byte("YELLOW SUBMARINE"))
+	if err != nil {
+		return nil, err
+	}
+	return unpadPkcs7Exact(plainText, aes.BlockSize)
+}
+
+func runChallenge8() ([]byte, error) {
+	lines, err := loadHexLines("files/1_8.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	for i, cipherText := range lines {
+		if isEncryptedAesEcb(cipherText) {
+			out = fmt.Appendf(out, "line %d\n", i+1)
+		}
+	}
+	return out, nil
+}
+
+func runChallenge9() ([]byte, error) {
+	return fmt.Appendf(nil, "%q\n", padPkcs7([]byte("YELLOW SUBMARINE"), 20)), nil
+}
+
+func runChallenge10() ([]byte, error) {
+	cipherText, err := loadBase64File("files/2_10.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	plainText, err := decryptAesCbc(cipherText, []byte("YELLOW SUBMARINE"), make([]byte, aes.BlockSize))
+	if err != nil {
+		return nil, err
+	}
+	return unpadPkcs7Exact(plainText, aes.BlockSize)
+}
+
+// runChallenge11 encrypts the same plain text with either mode, the way
+// encryptionOracle does (which doesn't tell which one it picked), and checks
+// that the detection tells them apart.
+func runChallenge11() ([]byte, error) {
+	plainText := bytes.Repeat([]byte("Let's encrypt this stuff"), 5)
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+	iv, err := newIV(aes.BlockSize)
+	if err != nil {
+		return nil, fmt.Errorf("generating random IV: %w", err)
+	}
+
+	modes := []struct {
+		name    string
+		encrypt func([]byte) ([]byte, error)
+	}{
+		{"ECB", func(pt []byte) ([]byte, error) { return encryptAesEcb(pt, key) }},
+		{"CBC", func(pt []byte) ([]byte, error) { return encryptAesCbc(pt, key, iv) }},
+	}
+
+	var out []byte
+	for _, m := range modes {
+		padded, err := addRandomNoise(plainText)
+		if err != nil {
+			return nil, err
+		}
+		cipherText, err := m.encrypt(padded)
+		if err != nil {
+			return nil, err
+		}
+
+		detected := "CBC"
+		if isEncryptedAesEcb(cipherText) {
+			detected = "ECB"
+		}
+		out = fmt.Appendf(out, "%s detected as %s\n", m.name, detected)
+	}
+	return out, nil
+}
+
+func runChallenge12() ([]byte, error) {
+	oracle, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := decryptOracleSecret(oracle)
+	if err != nil {
+		return nil, err
+	}
+
+	// the attack recovers the first byte of padding too.
+	secret, _, err = unpadPkcs7(secret, 0)
+	return secret, err
+}
+
+func runChallenge13() ([]byte, error) {
+	encryptionOracle, isAdmin, err := newProfileOracles(_defaultProfileService)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := createAdminProfile(encryptionOracle, isAdmin, profileFor)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		return nil, errors.New("the forged profile is not an admin")
+	}
+	return []byte("role=admin\n"), nil
+}
+
+// runChallenge14 attacks the oracle of challenge 12 with a random prefix,
+// generated once, in front of our input: it recovers the same secret.
+func runChallenge14() ([]byte, error) {
+	oracle, err := ecbEncryptionOracle(_challenge12Secret)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := randomBytesRange(1, 3*aes.BlockSize)
+	if err != nil {
+		return nil, fmt.Errorf("generating random prefix: %w", err)
+	}
+	prefixed := func(plainText []byte) ([]byte, error) {
+		return oracle(concatInto(nil, prefix, plainText))
+	}
+
+	secret, _, err := decryptRandomPrefixOracleSecret(prefixed)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, _, err = unpadPkcs7(secret, 0)
+	return secret, err
+}
+
+func runChallenge15() ([]byte, error) {
+	var out []byte
+	for _, padded := range []string{
+		"ICE ICE BABY\x04\x04\x04\x04",
+		"ICE ICE BABY\x05\x05\x05\x05",
+		"ICE ICE BABY\x01\x02\x03\x04",
+	} {
+		unpadded, _, err := unpadPkcs7([]byte(padded), aes.BlockSize)
+		switch {
+		case errors.Is(err, errInvalidPadding):
+			out = fmt.Appendf(out, "%q: invalid padding\n", padded)
+		case err != nil:
+			return nil, err
+		default:
+			out = fmt.Appendf(out, "%q: %q\n", padded, unpadded)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSet runs the set with the given number and fails the test for each of
+// its challenges that failed.
+func testSet(t *testing.T, number int) {
+	t.Helper()
+
+	s, ok := findSet(number)
+	if !ok {
+		t.Fatalf("set %d not found", number)
+	}
+
+	results := runSet(s)
+	if len(results) != len(s.challenges) {
+		t.Fatalf("got %d results for %d challenges", len(results), len(s.challenges))
+	}
+	for _, res := range results {
+		if res.err != nil {
+			t.Errorf("challenge %d (%s): %s", res.challenge.number, res.challenge.title, res.err)
+		}
+	}
+}
+
+func TestSet1(t *testing.T) {
+	testSet(t, 1)
+}
+
+func TestSet2(t *testing.T) {
+	testSet(t, 2)
+}
+
+func TestWriteChecklist(t *testing.T) {
+	var (
+		s = challengeSet{
+			number: 1,
+			title:  "Basics",
+			challenges: []challenge{
+				{number: 1, title: "Convert hex to base64"},
+				{number: 2, title: "Fixed XOR"},
+			},
+		}
+		results = []challengeResult{
+			{challenge: s.challenges[0], duration: 1500 * time.Microsecond},
+			{challenge: s.challenges[1], duration: 2 * time.Millisecond, err: errors.New("boom")},
+		}
+		out bytes.Buffer
+	)
+
+	failed, err := writeChecklist(&out, s, results)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if failed != 1 {
+		t.Errorf("want 1 failed challenge, got %d", failed)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 lines, got %d:\n%s", len(lines), out.String())
+	}
+	for i, prefix := range []string{"Set 1: Basics", "  [x]  1. Convert hex to base64", "  [ ]  2. Fixed XOR"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: want prefix %q, got %q", i, prefix, lines[i])
+		}
+	}
+	if !strings.HasSuffix(lines[1], "1.5ms") {
+		t.Errorf("line 1 does not end with the duration: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "  boom") {
+		t.Errorf("line 2 does not end with the error: %q", lines[2])
+	}
+	if want := "  1/2 challenges passed in 4ms"; lines[3] != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, lines[3])
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	if err := checkGolden("files/2_13.golden", []byte("role=admin\n")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := checkGolden("files/2_13.golden", []byte("role=user\n")); err == nil {
+		t.Error("want error for an output that differs from the golden file, got nil")
+	}
+	if err := checkGolden("files/0_0.golden", nil); err == nil {
+		t.Error("want error for a missing golden file, got nil")
+	}
+}
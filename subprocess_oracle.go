@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// The line protocol spoken by subprocess oracles is:
+//   - the attack writes a line with the hex encoded plain text.
+//   - the oracle replies with a line with the hex encoded cipher text, or with
+//     a line starting with _oracleErrorPrefix followed by an error message.
+//
+// Lines are terminated by '\n'. It's simple enough to implement an oracle in
+// any language, e.g.:
+//
+//	for line in sys.stdin:
+//	    print(encrypt(bytes.fromhex(line)).hex(), flush=True)
+const _oracleErrorPrefix = "error: "
+
+// subprocessOracle starts the given command and returns an aesOracle that
+// queries it using the line protocol described above, over the command's
+// stdin and stdout. The command's stderr is discarded.
+// The returned function stops the command, and must be called once the oracle
+// is not needed anymore. The oracle is safe for concurrent use, but queries are
+// serialized.
+func subprocessOracle(name string, args ...string) (aesOracle, func() error, error) {
+	cmd := exec.Command(name, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening oracle's stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening oracle's stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("starting oracle: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		reader = bufio.NewReader(stdout)
+	)
+	oracle := func(plainText []byte) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, err := fmt.Fprintln(stdin, hex.EncodeToString(plainText)); err != nil {
+			return nil, fmt.Errorf("writing to oracle: %w", err)
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading oracle's response: %w", err)
+		}
+		line = strings.TrimSpace(line)
+
+		if msg, ok := strings.CutPrefix(line, _oracleErrorPrefix); ok {
+			return nil, fmt.Errorf("oracle replied with an error: %s", msg)
+		}
+
+		cipherText, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("decoding oracle's response: %w", err)
+		}
+
+		return cipherText, nil
+	}
+
+	stop := func() error {
+		// closing stdin tells a well behaved oracle to exit.
+		stdin.Close()
+		return cmd.Wait()
+	}
+
+	return oracle, stop, nil
+}
+
+// serveOracle exposes the given oracle over r and w using the line protocol
+// expected by subprocessOracle, until r is exhausted.
+func serveOracle(oracle aesOracle, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	// a line holds a hex encoded plain text, which may be larger than the
+	// scanner's default 64KiB limit.
+	scanner.Buffer(nil, 1<<24)
+
+	for scanner.Scan() {
+		var reply string
+
+		plainText, err := hex.DecodeString(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			reply = _oracleErrorPrefix + "malformed hex plain text"
+		} else if cipherText, err := oracle(plainText); err != nil {
+			reply = _oracleErrorPrefix + strings.ReplaceAll(err.Error(), "\n", " ")
+		} else {
+			reply = hex.EncodeToString(cipherText)
+		}
+
+		if _, err := fmt.Fprintln(w, reply); err != nil {
+			return fmt.Errorf("writing response: %w", err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as a subprocess oracle: when
+// _helperOracleEnv is set, it serves challenge 12's oracle over stdin and
+// stdout instead of running the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv(_helperOracleEnv) != "" {
+		if err := runServe(nil, os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+const _helperOracleEnv = "CRYPTOPALS_HELPER_ORACLE"
+
+func TestSubprocessOracle(t *testing.T) {
+	t.Setenv(_helperOracleEnv, "1")
+
+	oracle, stop, err := subprocessOracle(os.Args[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	secret, err := decryptOracleSecret(oracle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("stopping oracle: %s", err)
+	}
+
+	want, err := _challenge12Secret.secret()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := delPadPkcs7(secret); !bytes.Equal(got, want) {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+}
+
+func TestServeOracle(t *testing.T) {
+	upper := func(plainText []byte) ([]byte, error) {
+		return bytes.ToUpper(plainText), nil
+	}
+
+	var (
+		// "abc", then a malformed line.
+		in  = strings.NewReader("616263\nxyz\n")
+		out bytes.Buffer
+	)
+	if err := serveOracle(upper, in, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	const want = "414243\n" + _oracleErrorPrefix + "malformed hex plain text\n"
+	if got := out.String(); got != want {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", want, got)
+	}
+}
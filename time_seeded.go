@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	mrand "math/rand/v2"
+	"time"
+
+	"github.com/alesforz/cryptopals/internal/brute"
+)
+
+// _passwordAlphabet is the set of characters of the passwords generated by
+// timeSeededPassword.
+const _passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// timeSeededPassword returns a password of n characters generated with a PRNG
+// seeded with the given Unix time, the moral equivalent of C's
+// srand(time(NULL)) followed by rand() calls.
+// The password looks random, but there are only as many of them as there are
+// seconds in which it could have been generated: knowing roughly when it was
+// generated is enough to enumerate them all (see crackTimeSeededPassword).
+func timeSeededPassword(unixTime int64, n int) string {
+	var (
+		seed     = uint64(unixTime)
+		rng      = mrand.New(mrand.NewPCG(seed, seed))
+		password = make([]byte, n)
+	)
+	for i := range password {
+		password[i] = _passwordAlphabet[rng.IntN(len(_passwordAlphabet))]
+	}
+	return string(password)
+}
+
+// passwordGenerator generates passwords with timeSeededPassword, using the
+// current time as seed. It stores only their SHA-256 hash, as a server would.
+type passwordGenerator struct {
+	// now returns the current time. Tests replace it with a fixed clock.
+	now func() time.Time
+
+	// length is the number of characters of the passwords.
+	length int
+}
+
+// generate returns a new password and its SHA-256 hash.
+func (g passwordGenerator) generate() (string, [sha256.Size]byte) {
+	password := timeSeededPassword(g.now().Unix(), g.length)
+	return password, sha256.Sum256([]byte(password))
+}
+
+// secondsRange is the keyspace of the Unix times (in seconds) from first
+// included, for n seconds.
+type secondsRange struct {
+	first int64
+	n     uint64
+}
+
+// Size implements brute.Keyspace.
+func (r secondsRange) Size() uint64 { return r.n }
+
+// Key implements brute.Keyspace.
+func (r secondsRange) Key(i uint64) int64 { return r.first + int64(i) }
+
+// crackTimeSeededPassword recovers a password generated by passwordGenerator
+// from its hash, knowing that it was generated within window of around (e.g.,
+// the creation date of the account, as shown on the user's profile).
+// It tries every second of the window as seed, in parallel, and returns the
+// password and the time it was generated at. A window of a day is only 86400
+// candidates.
+func crackTimeSeededPassword(
+	hash [sha256.Size]byte,
+	length int,
+	around time.Time,
+	window time.Duration,
+	opts ...attackOption,
+) (string, time.Time, error) {
+
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return "", time.Time{}, err
+	}
+
+	seconds := int64(window / time.Second)
+	if seconds <= 0 {
+		return "", time.Time{}, fmt.Errorf("window %s is shorter than a second", window)
+	}
+
+	var (
+		keyspace = secondsRange{first: around.Unix() - seconds, n: uint64(2*seconds + 1)}
+		pred     = func(unixTime int64) (bool, error) {
+			candidate := sha256.Sum256([]byte(timeSeededPassword(unixTime, length)))
+			return subtle.ConstantTimeCompare(candidate[:], hash[:]) == 1, nil
+		}
+	)
+	seed, err := brute.Search(context.Background(), keyspace, pred, brute.Options{Workers: options.parallelism})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("no password generated within %s of %s: %w", window, around, err)
+	}
+
+	return timeSeededPassword(seed, length), time.Unix(seed, 0), nil
+}
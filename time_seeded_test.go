@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alesforz/cryptopals/internal/brute"
+)
+
+func TestTimeSeededPassword(t *testing.T) {
+	a, b := timeSeededPassword(1700000000, 16), timeSeededPassword(1700000000, 16)
+	if a != b {
+		t.Errorf("same seed, different passwords: %q and %q", a, b)
+	}
+	if c := timeSeededPassword(1700000001, 16); c == a {
+		t.Errorf("different seeds, same password %q", a)
+	}
+}
+
+func TestCrackTimeSeededPassword(t *testing.T) {
+	var (
+		createdAt = time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC)
+		gen       = passwordGenerator{now: func() time.Time { return createdAt }, length: 12}
+	)
+	password, hash := gen.generate()
+
+	// we only know the day the account was created.
+	noon := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	got, at, err := crackTimeSeededPassword(hash, 12, noon, 12*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != password {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", password, got)
+	}
+	if !at.Equal(createdAt) {
+		t.Errorf("want generation time %s, got %s", createdAt, at)
+	}
+
+	// outside of the window.
+	_, _, err = crackTimeSeededPassword(hash, 12, noon.Add(-24*time.Hour), time.Hour)
+	if !errors.Is(err, brute.ErrNotFound) {
+		t.Errorf("want %v, got %v", brute.ErrNotFound, err)
+	}
+}
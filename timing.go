@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// measureTimings calls each of fns batch times in a row, n times, and returns
+// how long each batch took, per function.
+// The batches of the different functions are interleaved, so that the noise of
+// the machine (e.g., other processes, or the CPU changing frequency) affects
+// them alike. Batching makes functions faster than the clock's resolution
+// measurable.
+func measureTimings(n, batch int, fns ...func()) [][]time.Duration {
+	timings := make([][]time.Duration, len(fns))
+	for i := range timings {
+		timings[i] = make([]time.Duration, n)
+	}
+
+	for sample := range n {
+		for i, fn := range fns {
+			start := time.Now()
+			for range batch {
+				fn()
+			}
+			timings[i][sample] = time.Since(start)
+		}
+	}
+
+	return timings
+}
+
+// _timingOutliers is the fraction of the slowest samples timingLeak discards:
+// they are the ones most likely to have been interrupted (e.g., by the
+// scheduler or by the garbage collector).
+const _timingOutliers = 0.1
+
+// timingLeak returns Welch's t statistic of two samples of timings (e.g.,
+// returned by measureTimings), after discarding their slowest
+// _timingOutliers: how many standard errors apart their means are.
+// If the two samples time the same code with two different secrets, a large
+// |t| means that the code's running time depends on the secret: it leaks.
+// Side-channel testing tools (e.g., dudect) take |t| > 4.5 as evidence of a
+// leak. It returns NaN if either sample is empty.
+func timingLeak(a, b []time.Duration) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return math.NaN()
+	}
+
+	var (
+		meanA, varA, nA = timingStats(a)
+		meanB, varB, nB = timingStats(b)
+		stdErr          = math.Sqrt(varA/float64(nA) + varB/float64(nB))
+	)
+	if stdErr == 0 {
+		switch {
+		case meanA > meanB:
+			return math.Inf(1)
+		case meanA < meanB:
+			return math.Inf(-1)
+		}
+		return 0
+	}
+
+	return (meanA - meanB) / stdErr
+}
+
+// timingStats returns the mean and the sample variance of timings, in
+// nanoseconds, after discarding the slowest _timingOutliers of them, and the
+// number of timings left.
+func timingStats(timings []time.Duration) (float64, float64, int) {
+	sorted := slices.Sorted(slices.Values(timings))
+	sorted = sorted[:max(1, len(sorted)-int(float64(len(sorted))*_timingOutliers))]
+
+	var sum float64
+	for _, t := range sorted {
+		sum += float64(t)
+	}
+	mean := sum / float64(len(sorted))
+
+	if len(sorted) == 1 {
+		return mean, 0, 1
+	}
+
+	var squares float64
+	for _, t := range sorted {
+		squares += (float64(t) - mean) * (float64(t) - mean)
+	}
+
+	return mean, squares / float64(len(sorted)-1), len(sorted)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimingLeak(t *testing.T) {
+	var (
+		fast = []time.Duration{100, 102, 98, 101, 99, 100, 103, 97, 100, 100}
+		slow = []time.Duration{200, 202, 198, 201, 199, 200, 203, 197, 200, 200}
+	)
+
+	if leak := timingLeak(slow, fast); leak < 4.5 {
+		t.Errorf("slow vs fast: want t > 4.5, got %.2f", leak)
+	}
+	if leak := timingLeak(fast, slow); leak > -4.5 {
+		t.Errorf("fast vs slow: want t < -4.5, got %.2f", leak)
+	}
+	if leak := timingLeak(fast, fast); leak != 0 {
+		t.Errorf("fast vs fast: want t = 0, got %.2f", leak)
+	}
+	if leak := timingLeak(fast, nil); !math.IsNaN(leak) {
+		t.Errorf("fast vs nothing: want NaN, got %.2f", leak)
+	}
+}
+
+func TestTimingLeakOutliers(t *testing.T) {
+	var (
+		fast        = []time.Duration{100, 102, 98, 101, 99, 100, 103, 97, 100, 100}
+		interrupted = []time.Duration{100, 102, 98, 101, 99, 100, 103, 97, 100, 100000}
+	)
+
+	// the sample interrupted once is as fast as the other one.
+	if leak := timingLeak(interrupted, fast); math.Abs(leak) > 1 {
+		t.Errorf("want |t| <= 1, got %.2f", leak)
+	}
+}
+
+func TestMeasureTimings(t *testing.T) {
+	timings := measureTimings(
+		5,
+		2,
+		func() {},
+		func() { time.Sleep(time.Millisecond) },
+	)
+
+	if len(timings) != 2 || len(timings[0]) != 5 || len(timings[1]) != 5 {
+		t.Fatalf("want 2 samples of 5 timings, got %d", len(timings))
+	}
+	for _, d := range timings[1] {
+		if d < 2*time.Millisecond {
+			t.Errorf("batch of 2 sleeps of 1ms took %s", d)
+		}
+	}
+	if leak := timingLeak(timings[1], timings[0]); leak < 4.5 {
+		t.Errorf("want t > 4.5, got %.2f", leak)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// twoRegionOracle defines a type that encrypts a message with two regions we
+// control, e.g., a form with two fields, around data we don't know.
+// Like aesOracle, it must not modify its input.
+type twoRegionOracle func(first, second []byte) ([]byte, error)
+
+// twoRegionEcbOracle returns a twoRegionOracle that encrypts
+// [prefix || first || secret || second || suffix] with AES ECB, using the same
+// (randomly generated) key every time. The secret is provided by sp.
+// It honors withCompression and withLengthBuckets.
+func twoRegionEcbOracle(
+	prefix []byte,
+	sp secretProvider,
+	suffix []byte,
+	opts ...oracleOption,
+) (twoRegionOracle, error) {
+
+	options, err := newOracleOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := sp.secret()
+	if err != nil {
+		return nil, fmt.Errorf("getting oracle's secret: %w", err)
+	}
+	// the provider may hand out memory the caller can still modify.
+	secret = cloneBytes(secret)
+	prefix, suffix = cloneBytes(prefix), cloneBytes(suffix)
+
+	key, err := newAESKey(128)
+	if err != nil {
+		return nil, fmt.Errorf("generating random AES key: %w", err)
+	}
+
+	recordGroundTruth("twoRegionEcbOracle", key, secret)
+
+	encOracle := func(first, second []byte) ([]byte, error) {
+		encoded, err := options.encode(concatInto(nil, prefix, first, secret, second, suffix))
+		if err != nil {
+			return nil, err
+		}
+		return encryptAesEcb(encoded, key, withStdlib())
+	}
+
+	return encOracle, nil
+}
+
+// decryptMiddleSecret recovers the secret a twoRegionOracle encrypts between
+// the two regions we control, i.e., with the layout
+// [prefix || first || secret || second || suffix], with prefix and suffix of
+// unknown but fixed lengths.
+// It's the byte-at-a-time attack of challenge 12, aligned from both sides:
+//   - the first region aligns the secret: a filler of the right length, after
+//     the one that completes the prefix's last block, makes the block ending
+//     with the next unknown byte of the secret the target block.
+//   - the second region holds the guesses: the 256 blocks made of the 15
+//     bytes before the unknown one, followed by each possible value of it,
+//     after the filler that completes the secret's last block.
+//
+// Since the target block and the guesses come back in the same cipher text,
+// it takes a single query per byte of the secret, rather than up to 256.
+// Finding the lengths of the prefix and of the secret beforehand takes a few
+// dozen queries.
+// It honors withBlockSize, withMaxSecretLen and withExplain.
+func decryptMiddleSecret(oracle twoRegionOracle, opts ...attackOption) ([]byte, error) {
+	options := newAttackOptions(opts)
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	blockSize := options.blockSize
+
+	// where our input starts in each region, as if the other one was empty.
+	var (
+		viaFirst  = func(plainText []byte) ([]byte, error) { return oracle(plainText, nil) }
+		viaSecond = func(plainText []byte) ([]byte, error) { return oracle(nil, plainText) }
+	)
+	prefixLen, err := probePrefixLen(viaFirst, blockSize)
+	if err != nil {
+		return nil, fmt.Errorf("aligning the first region: %w", err)
+	}
+	secondStart, err := probePrefixLen(viaSecond, blockSize)
+	if err != nil {
+		return nil, fmt.Errorf("aligning the second region: %w", err)
+	}
+
+	secretLen := secondStart - prefixLen
+	if secretLen < 0 {
+		const formatStr = "the second region (at byte %d) comes before the first one (at byte %d)"
+		return nil, fmt.Errorf(formatStr, secondStart, prefixLen)
+	}
+	if err := options.checkSecretLen(secretLen); err != nil {
+		return nil, err
+	}
+
+	const formatStr = "the first region starts at byte %d and the second at byte %d: the secret is %d bytes long"
+	explainf(options.explain, formatStr, prefixLen, secondStart, secretLen)
+
+	var (
+		// the filler completing the prefix's last block, so that the first
+		// region starts on a block boundary.
+		prefixFill = make([]byte, fillLen(prefixLen, blockSize))
+		alignedAt  = prefixLen + len(prefixFill)
+
+		secret  = make([]byte, 0, secretLen)
+		guesses = make([]byte, 0, 256*blockSize)
+	)
+	for len(secret) < secretLen {
+		var (
+			i = len(secret)
+
+			// the filler that puts byte i of the secret at the end of a
+			// block: the target block.
+			filler = bytes.Repeat([]byte{'A'}, fillLen(i+1, blockSize))
+			first  = concatInto(nil, prefixFill, filler)
+
+			// the bytes before byte i in the target block, which we know.
+			known     = concatInto(nil, filler, secret)
+			knownTail = known[len(known)-(blockSize-1):]
+
+			// the target block is the one holding byte i.
+			targetStart = alignDown(alignedAt+len(known), blockSize)
+
+			// the second region must start on a block boundary too, which
+			// depends on how long the first one is.
+			secondFill   = make([]byte, fillLen(prefixLen+len(first)+secretLen, blockSize))
+			guessesStart = prefixLen + len(first) + secretLen + len(secondFill)
+		)
+
+		guesses = guesses[:0]
+		for b := range 256 {
+			guesses = append(guesses, knownTail...)
+			guesses = append(guesses, byte(b))
+		}
+
+		cipherText, err := oracle(first, concatInto(nil, secondFill, guesses))
+		if err != nil {
+			return secret, fmt.Errorf("recovering byte %d: %w", i, err)
+		}
+		if len(cipherText) < guessesStart+len(guesses) {
+			const formatStr = "recovering byte %d: cipher text is %d bytes long, want at least %d"
+			return secret, fmt.Errorf(formatStr, i, len(cipherText), guessesStart+len(guesses))
+		}
+
+		var (
+			target         = cipherText[targetStart : targetStart+blockSize]
+			encodedGuesses = cipherText[guessesStart:]
+			char           = -1
+		)
+		for b := range 256 {
+			if bytes.Equal(blockAt(encodedGuesses, b, blockSize), target) {
+				char = b
+				break
+			}
+		}
+		if char < 0 {
+			return secret, fmt.Errorf("recovering byte %d: no guess matches the target block", i)
+		}
+
+		secret = append(secret, byte(char))
+		explainf(options.explain, "%2d filler bytes: secret[%d] = %q", len(filler), i, byte(char))
+	}
+
+	return secret, nil
+}
+
+// fillLen returns how many bytes complete the last block of n bytes, i.e., how
+// many must follow them to reach a block boundary.
+func fillLen(n, blockSize int) int {
+	return alignUp(n, blockSize) - n
+}
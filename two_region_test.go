@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecryptMiddleSecret(t *testing.T) {
+	const secret = "Rollin' in my 5.0\nWith my rag-top down so my hair can blow"
+
+	tests := []struct {
+		name           string
+		prefix, suffix string
+		secret         string
+	}{
+		{name: "no prefix nor suffix", secret: secret},
+		{name: "aligned prefix", prefix: "comment1=cooking", suffix: ";comment2=%20like%20a%20pound", secret: secret},
+		{name: "unaligned prefix", prefix: "user=", suffix: "&role=user", secret: secret},
+		{name: "long prefix", prefix: strings.Repeat("p", 37), suffix: "s", secret: secret},
+		{name: "one byte secret", prefix: "user=", suffix: "&role=user", secret: "x"},
+		{name: "block sized secret", prefix: "user=", secret: "YELLOW SUBMARINE"},
+		{name: "empty secret", prefix: "user=", suffix: "&role=user"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oracle, err := twoRegionEcbOracle([]byte(test.prefix), staticSecret(test.secret), []byte(test.suffix))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := decryptMiddleSecret(oracle)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != test.secret {
+				t.Errorf("\nwant:\t%q\ngot:\t%q\n", test.secret, got)
+			}
+		})
+	}
+}
+
+func TestDecryptMiddleSecretQueries(t *testing.T) {
+	const secret = "Rollin' in my 5.0\nWith my rag-top down so my hair can blow"
+
+	oracle, err := twoRegionEcbOracle([]byte("user="), staticSecret(secret), []byte("&role=user"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var calls int
+	counted := func(first, second []byte) ([]byte, error) {
+		calls++
+		return oracle(first, second)
+	}
+
+	var explanation bytes.Buffer
+	if _, err := decryptMiddleSecret(counted, withExplain(&explanation)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// a query per byte, plus the ones aligning the regions: 2 per filler
+	// length tried, at most a block's worth per region.
+	if maxCalls := len(secret) + 2*2*16; calls > maxCalls {
+		t.Errorf("want at most %d oracle calls, got %d", maxCalls, calls)
+	}
+	if want := fmt.Sprintf("the secret is %d bytes long", len(secret)); !strings.Contains(explanation.String(), want) {
+		t.Errorf("explanation does not contain %q:\n%s", want, explanation.String())
+	}
+}
+
+func TestDecryptMiddleSecretTooLong(t *testing.T) {
+	oracle, err := twoRegionEcbOracle(nil, staticSecret(strings.Repeat("x", 100)), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := decryptMiddleSecret(oracle, withMaxSecretLen(64)); !errors.Is(err, errSecretTooLong) {
+		t.Errorf("want %v, got %v", errSecretTooLong, err)
+	}
+}
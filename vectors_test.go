@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alesforz/cryptopals/internal/testutil"
+)
+
+// _aesVectors are the AES-128 test vectors of NIST SP 800-38A, appendix F.1
+// (ECB) and F.2 (CBC). Our implementations only support AES-128, as they use
+// the key's length as the block size.
+var _aesVectors = []struct {
+	name       string
+	key        string
+	iv         string // empty for ECB.
+	plainText  string
+	cipherText string
+}{
+	{
+		name: "F.1.1 ECB-AES128",
+		key:  "2b7e151628aed2a6abf7158809cf4f3c",
+		plainText: "6bc1bee22e409f96e93d7e117393172a" +
+			"ae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52ef" +
+			"f69f2445df4f9b17ad2b417be66c3710",
+		cipherText: "3ad77bb40d7a3660a89ecaf32466ef97" +
+			"f5d3d58503b9699de785895a96fdbaaf" +
+			"43b1cd7f598ece23881b00e3ed030688" +
+			"7b0c785e27e8ad3f8223207104725dd4",
+	},
+	{
+		name: "F.2.1 CBC-AES128",
+		key:  "2b7e151628aed2a6abf7158809cf4f3c",
+		iv:   "000102030405060708090a0b0c0d0e0f",
+		plainText: "6bc1bee22e409f96e93d7e117393172a" +
+			"ae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52ef" +
+			"f69f2445df4f9b17ad2b417be66c3710",
+		cipherText: "7649abac8119b246cee98e9b12e9197d" +
+			"5086cb9b507219ee95db113a917678b2" +
+			"73bed6b8e3c1743b7116e69e22229516" +
+			"3ff1caa1681fac09120eca307586e1a7",
+	},
+}
+
+func TestAesVectors(t *testing.T) {
+	impls := []struct {
+		name string
+		opts []cipherOption
+	}{
+		{name: "educational", opts: []cipherOption{withoutPadding()}},
+		{name: "stdlib", opts: []cipherOption{withStdlib(), withoutPadding()}},
+	}
+
+	for _, v := range _aesVectors {
+		var (
+			key        = testutil.MustDecodeHex(t, v.key)
+			iv         = testutil.MustDecodeHex(t, v.iv)
+			plainText  = testutil.MustDecodeHex(t, v.plainText)
+			cipherText = testutil.MustDecodeHex(t, v.cipherText)
+		)
+
+		for _, impl := range impls {
+			t.Run(v.name+"/"+impl.name, func(t *testing.T) {
+				var (
+					encrypted []byte
+					decrypted []byte
+					err       error
+				)
+				if strings.Contains(v.name, "ECB") {
+					encrypted, err = encryptAesEcb(plainText, key, impl.opts...)
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					decrypted, err = decryptAesEcb(cipherText, key, impl.opts...)
+				} else {
+					encrypted, err = encryptAesCbc(plainText, key, iv, impl.opts...)
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					decrypted, err = decryptAesCbc(cipherText, key, iv, impl.opts...)
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				// the vectors are block aligned and have no padding.
+				if !bytes.Equal(encrypted, cipherText) {
+					t.Errorf("\nwant:\t%x\ngot:\t%x\n", cipherText, encrypted)
+				}
+
+				// the decryption leaves the padding in place; the vector's
+				// cipher text has none.
+				if !bytes.Equal(decrypted, plainText) {
+					t.Errorf("\nwant:\t%x\ngot:\t%x\n", plainText, decrypted)
+				}
+			})
+		}
+	}
+}
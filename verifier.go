@@ -0,0 +1,32 @@
+package main
+
+import "fmt"
+
+// verifier defines a type that reports whether an intermediate result of an
+// attack already achieves its practical goal, e.g., whether the bytes of a
+// secret recovered so far hold the password we're after, or whether the
+// target accepts a forged cookie. An error aborts the attack.
+type verifier func(result []byte) (bool, error)
+
+// withVerifier makes the attack call v with its intermediate results, and stop
+// as soon as v accepts one, returning it instead of the full result.
+// Each attack documents what it passes to v.
+func withVerifier(v verifier) attackOption {
+	return func(o *attackOptions) {
+		o.verifier = v
+	}
+}
+
+// verify reports whether the verifier set with withVerifier, if any, accepts
+// the given intermediate result.
+func (o attackOptions) verify(result []byte) (bool, error) {
+	if o.verifier == nil {
+		return false, nil
+	}
+
+	ok, err := o.verifier(result)
+	if err != nil {
+		return false, fmt.Errorf("verifying intermediate result: %w", err)
+	}
+	return ok, nil
+}
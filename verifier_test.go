@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestVerifierStopsAttacks(t *testing.T) {
+	const (
+		secret = "YELLOW SUBMARINE+RED SUNSHINES=IMMENSE HAPPINESS"
+		want   = "YELLOW SUBMARINE+RED SUN"
+	)
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	prefixed, err := randomPrefixEcbOracle(staticSecret(secret), 32)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var verified int
+	v := withVerifier(func(recovered []byte) (bool, error) {
+		verified++
+		return bytes.HasSuffix(recovered, []byte("SUN")), nil
+	})
+
+	for _, tt := range []struct {
+		name   string
+		oracle aesOracle
+		attack func(aesOracle) ([]byte, error)
+	}{
+		{"byte-at-a-time", o, func(o aesOracle) ([]byte, error) {
+			return decryptOracleSecret(o, v)
+		}},
+		{"pipelined", o, func(o aesOracle) ([]byte, error) {
+			return decryptOracleSecretPipelined(o, v, withParallelism(4))
+		}},
+		{"random-prefix", prefixed, func(o aesOracle) ([]byte, error) {
+			secret, _, err := decryptRandomPrefixOracleSecret(o, v)
+			return secret, err
+		}},
+	} {
+		verified = 0
+		got, err := tt.attack(tt.oracle)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s:\nwant:\t%q\ngot:\t%q\n", tt.name, want, got)
+		}
+		// the verifier is called after each byte.
+		if verified != len(want) {
+			t.Errorf("%s: want %d calls to the verifier, got %d", tt.name, len(want), verified)
+		}
+	}
+}
+
+func TestVerifierError(t *testing.T) {
+	o, err := ecbEncryptionOracle(staticSecret("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	errRejected := errors.New("target unreachable")
+	v := withVerifier(func([]byte) (bool, error) { return false, errRejected })
+
+	got, err := decryptOracleSecret(o, v)
+	if !errors.Is(err, errRejected) {
+		t.Errorf("want %v, got %v", errRejected, err)
+	}
+	if string(got) != "Y" {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", "Y", got)
+	}
+}
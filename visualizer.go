@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI escape codes used by the visualizer.
+const (
+	_ansiClear   = "\x1b[H\x1b[2J"
+	_ansiReverse = "\x1b[7m"
+	_ansiYellow  = "\x1b[33m"
+	_ansiReset   = "\x1b[0m"
+)
+
+// ansiVisualizer returns a progressReporter that draws the state of an attack
+// on w, which must be a terminal supporting ANSI escape codes.
+// On every event it redraws the blocks of the plain text sent to the oracle
+// and of the cipher text it returned, one block per line. The block under
+// attack is highlighted in yellow, and the byte being brute forced in reverse
+// video.
+func ansiVisualizer(w io.Writer) progressReporter {
+	return func(e attackEvent) {
+		var b strings.Builder
+
+		b.WriteString(_ansiClear)
+		fmt.Fprintf(&b, "target block: %d\n\nplain text:\n", e.targetBlock)
+		writePlainTextBlocks(&b, e)
+
+		b.WriteString("\ncipher text:\n")
+		writeCipherTextBlocks(&b, e)
+
+		fmt.Fprintf(&b, "\nrecovered (%d bytes): %q\n", len(e.recovered), e.recovered)
+
+		io.WriteString(w, b.String())
+	}
+}
+
+// writePlainTextBlocks writes the blocks of the event's plain text to b.
+// Non printable bytes are shown as '.'.
+func writePlainTextBlocks(b *strings.Builder, e attackEvent) {
+	for blockIdx, block := range blockRange(e.plainText, e.blockSize) {
+		start := blockIdx * e.blockSize
+		fmt.Fprintf(b, "%3d |", blockIdx)
+		if blockIdx == e.targetBlock {
+			b.WriteString(_ansiYellow)
+		}
+
+		for i, char := range block {
+			if char < ' ' || char > '~' {
+				char = '.'
+			}
+
+			if start+i == e.guessPos {
+				b.WriteString(_ansiReverse)
+				b.WriteByte(char)
+				b.WriteString(_ansiReset)
+				if blockIdx == e.targetBlock {
+					b.WriteString(_ansiYellow)
+				}
+				continue
+			}
+			b.WriteByte(char)
+		}
+
+		b.WriteString(_ansiReset)
+		b.WriteString("|\n")
+	}
+}
+
+// writeCipherTextBlocks writes the blocks of the event's cipher text to b, hex
+// encoded.
+func writeCipherTextBlocks(b *strings.Builder, e attackEvent) {
+	for blockIdx, block := range blockRange(e.cipherText, e.blockSize) {
+		encoded := hex.EncodeToString(block)
+		if blockIdx == e.targetBlock {
+			encoded = _ansiYellow + encoded + _ansiReset
+		}
+		fmt.Fprintf(b, "%3d |%s|\n", blockIdx, encoded)
+	}
+}
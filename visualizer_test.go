@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestANSIVisualizer(t *testing.T) {
+	const secret = "YELLOW SUBMARINE"
+
+	o, err := ecbEncryptionOracle(staticSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		out       bytes.Buffer
+		lastFrame string
+		visualize = ansiVisualizer(&out)
+	)
+	progress := func(e attackEvent) {
+		out.Reset()
+		visualize(e)
+		lastFrame = out.String()
+	}
+
+	if _, err := decryptOracleSecret(o, withProgress(progress)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{_ansiClear, _ansiReverse, "target block: 1", `"YELLOW SUBMARINE`} {
+		if !strings.Contains(lastFrame, want) {
+			t.Errorf("last frame does not contain %q:\n%s", want, lastFrame)
+		}
+	}
+}
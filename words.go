@@ -0,0 +1,100 @@
+package main
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// _englishWordsFile is a list of common English words, one per line, in
+// lowercase.
+//
+//go:embed files/english_words.txt
+var _englishWordsFile string
+
+// _englishWords is the set of the words in _englishWordsFile.
+var _englishWords = newWordSet(_englishWordsFile)
+
+// newWordSet returns the set of the words in list, one per line.
+func newWordSet(list string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.Fields(list) {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// wordFraction returns the fraction of the words of text that are in the
+// embedded English word list. Words are runs of letters and apostrophes, and
+// they're compared regardless of case.
+// Letter frequencies can't tell apart two candidates that differ in a few
+// characters (e.g., in their case), whereas real words can. It returns 0 for
+// a text without words.
+func wordFraction(text []byte) float64 {
+	var (
+		words, known int
+		word         strings.Builder
+	)
+	check := func() {
+		w := strings.Trim(word.String(), "'")
+		word.Reset()
+		if w == "" {
+			return
+		}
+
+		words++
+		if isEnglishWord(w) {
+			known++
+		}
+	}
+
+	for _, c := range text {
+		switch {
+		case c >= 'a' && c <= 'z', c == '\'':
+			word.WriteByte(c)
+		case c >= 'A' && c <= 'Z':
+			word.WriteByte(c + 'a' - 'A')
+		default:
+			check()
+		}
+	}
+	check()
+
+	if words == 0 {
+		return 0
+	}
+	return float64(known) / float64(words)
+}
+
+// _wordSuffixes are the suffixes isEnglishWord strips to find a word's stem in
+// the word list, so that the list doesn't need every form of every word.
+var _wordSuffixes = []string{"'s", "'ll", "'d", "'ve", "s", "es", "ed", "d", "ing", "ly", "er"}
+
+// isEnglishWord reports whether the given lowercase word, or its stem, is in
+// the embedded word list.
+func isEnglishWord(w string) bool {
+	if _, ok := _englishWords[w]; ok {
+		return true
+	}
+	for _, suffix := range _wordSuffixes {
+		stem, ok := strings.CutSuffix(w, suffix)
+		if !ok || len(stem) < 2 {
+			continue
+		}
+		if _, ok := _englishWords[stem]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withWordCheck makes the attacks that rank candidate plain texts check the
+// best ones against the embedded English word list: the candidates whose
+// score is within margin of the best one are ranked again by the fraction of
+// their words that are real words (see wordFraction).
+// It's useful when the scorer can't tell apart close candidates, e.g., with
+// short cipher texts.
+func withWordCheck(margin float64) attackOption {
+	return func(o *attackOptions) {
+		o.wordCheckMargin = margin
+	}
+}
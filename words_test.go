@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestWordFraction(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		want float64
+	}{
+		"all words":   {"It was a cold day", 1},
+		"stems":       {"Cooking, LOOKED; the world's kings", 1},
+		"half":        {"hello qzx there vbn", 0.5},
+		"none":        {"( o! 8", 0},
+		"no words":    {"1234 !?", 0},
+		"empty":       {"", 0},
+		"apostrophes": {"'don't' stop", 1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := wordFraction([]byte(test.text)); got != test.want {
+				t.Errorf("\nwant:\t%v\ngot:\t%v\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestWithWordCheck(t *testing.T) {
+	// too short for letter frequencies alone to get it right.
+	const plainText = "go now"
+	cipherText := []byte(plainText)
+
+	best := singleByteXORCandidates(cipherText, 1)[0]
+	if string(best.plainText) == plainText {
+		t.Fatalf("the scorer alone recovered %q, the test needs a harder cipher text", plainText)
+	}
+
+	best = singleByteXORCandidates(cipherText, 1, withWordCheck(0.02))[0]
+	if string(best.plainText) != plainText {
+		t.Errorf("\nwant:\t%q\ngot:\t%q\n", plainText, best.plainText)
+	}
+}
+
+// checkEnglish fails the test if less than minFraction of the words of text
+// are English words. It's a quick way to verify that an attack recovered the
+// actual plain text, without hard coding it in the test. The word list is
+// small, so real text scores around 0.8, and garbage close to 0.
+func checkEnglish(tb testing.TB, text []byte, minFraction float64) {
+	tb.Helper()
+
+	if got := wordFraction(text); got < minFraction {
+		tb.Errorf("only %.2f of the words are English, want at least %.2f:\n%s", got, minFraction, text)
+	}
+}